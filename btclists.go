@@ -21,12 +21,77 @@ var (
 	ErrRateNotFound = errors.New("unable to retrieve or find rate")
 )
 
+// Pair defines a crypto-currency and fiat-currency pairing (e.g BTC/USD).
+type Pair struct {
+	Coin string `json:"coin" yaml:"coin"`
+	Fiat string `json:"fiat" yaml:"fiat"`
+}
+
+// String returns pair in the form of {coin}/{fiat}.
+func (p Pair) String() string {
+	return p.Coin + "/" + p.Fiat
+}
+
+// Order defines the sort order of ranged results by date.
+type Order string
+
+const (
+	Ascending  Order = "asc"
+	Descending Order = "desc"
+)
+
+// Page defines pagination options for ranged results.
+type Page struct {
+	// Limit sets the maximum number of results to be returned.
+	Limit int
+
+	// After, if not zero, is the cursor from which results should continue,
+	// only results after it in the direction of Order will be returned.
+	After time.Time
+
+	// Order sets the direction results are sorted by date.
+	Order Order
+}
+
 type Rate struct {
-	Id   int             `json:"id" yaml:"id"`
-	Date time.Time       `json:"date" yaml:"date"`
-	Rate decimal.Decimal `json:"rate" yaml:"rate"`
-	Coin string          `json:"coin" yaml:"coin"`
-	Fiat string          `json:"fiat" yaml:"fiat"`
+	Id         int             `json:"id" yaml:"id"`
+	Date       time.Time       `json:"date" yaml:"date"`
+	Rate       decimal.Decimal `json:"rate" yaml:"rate"`
+	Coin       string          `json:"coin" yaml:"coin"`
+	Fiat       string          `json:"fiat" yaml:"fiat"`
+	Resolution Resolution      `json:"resolution,omitempty" yaml:"resolution,omitempty"`
+
+	// Sources lists the market data providers which agreed on rate, where
+	// rate was derived from several providers.
+	Sources []string `json:"sources,omitempty" yaml:"-"`
+}
+
+// Candle defines the open, high, low, close and volume (OHLCV) data of a
+// crypto-currency and fiat-currency pair within a time period.
+type Candle struct {
+	Id         int             `json:"id" yaml:"id"`
+	Coin       string          `json:"coin" yaml:"coin"`
+	Fiat       string          `json:"fiat" yaml:"fiat"`
+	Resolution Resolution      `json:"resolution" yaml:"resolution"`
+	Start      time.Time       `json:"start" yaml:"start"`
+	End        time.Time       `json:"end" yaml:"end"`
+	Open       decimal.Decimal `json:"open" yaml:"open"`
+	High       decimal.Decimal `json:"high" yaml:"high"`
+	Low        decimal.Decimal `json:"low" yaml:"low"`
+	Close      decimal.Decimal `json:"close" yaml:"close"`
+	Volume     decimal.Decimal `json:"volume" yaml:"volume"`
+	Trades     int64           `json:"trades" yaml:"trades"`
+}
+
+// Rate returns candle as a Rate using it's closing price at end of period.
+func (c Candle) Rate() Rate {
+	return Rate{
+		Date:       c.End,
+		Rate:       c.Close,
+		Coin:       c.Coin,
+		Fiat:       c.Fiat,
+		Resolution: c.Resolution,
+	}
 }
 
 // Client is defined here as an interface for 2 specific reasons:
@@ -51,22 +116,117 @@ type RateService interface {
 	Range(ctx context.Context, crypto string, currency string, start time.Time, end time.Time) ([]Rate, error)
 }
 
+// RatePager defines a service able to return ranged results in pages.
+type RatePager interface {
+	// RangePage returns a page of Rate for crypto-currency and fiat-currency pair
+	// within time range (i.e from 'start' to 'end' time range).
+	RangePage(ctx context.Context, crypto string, currency string, start time.Time, end time.Time, page Page) ([]Rate, error)
+}
+
+// PriorRateService defines a service able to return the last known rate of a pair at a
+// time, suited to pairs whose rates are sparse (e.g daily reference rates).
+type PriorRateService interface {
+	// Before returns the last known Rate for crypto-currency and fiat-currency pair at or
+	// before provided time, dated no earlier than maxAge before it.
+	Before(ctx context.Context, crypto string, fiat string, when time.Time, maxAge time.Duration) (Rate, error)
+}
+
+// CandleService defines a service able to return OHLCV candles for a pair.
+type CandleService interface {
+	// Candles returns all known Candle for crypto-currency and fiat-currency pair
+	// within time range (i.e from 'start' to 'end' time range), ordered by start of period.
+	Candles(ctx context.Context, crypto string, currency string, start time.Time, end time.Time) ([]Candle, error)
+}
+
 type RatingsAverageService interface {
 	AverageForRange(ctx context.Context, crypto string, currency string, start time.Time, end time.Time) (decimal.Decimal, error)
 }
 
+// Percentile is the value below which giving percent (0 to 100) of rates fall.
+type Percentile struct {
+	Percent float64         `json:"percent"`
+	Value   decimal.Decimal `json:"value"`
+}
+
+// Stats holds summary statistics of rates for a pair within a time range.
+type Stats struct {
+	Coin        string          `json:"coin"`
+	Fiat        string          `json:"fiat"`
+	From        time.Time       `json:"from"`
+	To          time.Time       `json:"to"`
+	Count       int             `json:"count"`
+	Min         decimal.Decimal `json:"min"`
+	Max         decimal.Decimal `json:"max"`
+	First       decimal.Decimal `json:"first"`
+	Last        decimal.Decimal `json:"last"`
+	Mean        decimal.Decimal `json:"mean"`
+	Median      decimal.Decimal `json:"median"`
+	StdDev      decimal.Decimal `json:"stddev"`
+	Percentiles []Percentile    `json:"percentiles,omitempty"`
+}
+
+// StatsService defines a service able to return summary statistics of rates for a pair.
+type StatsService interface {
+	// StatsForRange returns summary statistics of Rate for crypto-currency and fiat-currency
+	// pair within time range (i.e from 'start' to 'end' time range), along with requested
+	// percentiles (each within 0 to 100).
+	StatsForRange(ctx context.Context, crypto string, currency string, start time.Time, end time.Time, percentiles []float64) (Stats, error)
+}
+
+// WeightedAverageService defines a service able to return weighted averages of a pair,
+// which unlike RatingsAverageService are not skewed by uneven sample density.
+type WeightedAverageService interface {
+	// TimeWeightedAverage returns the time-weighted average price (TWAP) for crypto-currency
+	// and fiat-currency pair within time range (i.e from 'start' to 'end' time range).
+	TimeWeightedAverage(ctx context.Context, crypto string, currency string, start time.Time, end time.Time) (decimal.Decimal, error)
+
+	// VolumeWeightedAverage returns the volume-weighted average price (VWAP) for crypto-currency
+	// and fiat-currency pair within time range (i.e from 'start' to 'end' time range).
+	VolumeWeightedAverage(ctx context.Context, crypto string, currency string, start time.Time, end time.Time) (decimal.Decimal, error)
+}
+
+// CoverageService defines a service able to report how much of a time range is
+// covered by it's known rates.
+type CoverageService interface {
+	// CoverageForRange returns the ratio (0 to 1) of time range (i.e from 'start' to 'end')
+	// covered by known Rate for crypto-currency and fiat-currency pair.
+	CoverageForRange(ctx context.Context, crypto string, currency string, start time.Time, end time.Time) (float64, error)
+}
+
+type upsertKey struct{}
+
+// WithUpsert returns a new context making rates added with it replace rates already
+// stored for the same pair and date (e.g corrections), rather than being ignored.
+func WithUpsert(ctx context.Context) context.Context {
+	return context.WithValue(ctx, upsertKey{}, true)
+}
+
+// UpsertFrom returns true if ctx was set with WithUpsert.
+func UpsertFrom(ctx context.Context) bool {
+	var upsert, _ = ctx.Value(upsertKey{}).(bool)
+	return upsert
+}
+
 // RatesDB defines expectation for minimum support required
 // a db store for storing and retrieving Rates.
 type RatesDB interface {
 	RateService
+	RatePager
+	CandleService
 	RatingsAverageService
+	StatsService
 
-	// Add adds giving rate into db
+	// Add adds giving rate into db, a rate already stored for the same pair and
+	// date is kept unless ctx is set with WithUpsert.
 	Add(ctx context.Context, rate Rate) error
 
-	// AddBatch adds provided batch into db.
+	// AddBatch adds provided batch into db, rates already stored for the same pair
+	// and date are kept unless ctx is set with WithUpsert.
 	AddBatch(ctx context.Context, rate []Rate) error
 
+	// AddCandles adds provided candles into db.
+	AddCandles(ctx context.Context, candles []Candle) error
+
 	// Oldest returns oldest rate since time began.
 	Oldest(ctx context.Context, coin string, fiat string) (Rate, error)
 
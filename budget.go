@@ -0,0 +1,60 @@
+package btclists
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	ErrBudgetExhausted = errors.New("provider credit budget is below reserve, refusing low priority request")
+)
+
+// Budget describes the request credits left with a market data provider
+// till it's quota resets.
+type Budget struct {
+	Provider  string    `json:"provider"`
+	Limit     int64     `json:"limit"`
+	Remaining int64     `json:"remaining"`
+	ResetAt   time.Time `json:"reset_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// BudgetStore defines expectation for a store sharing provider budgets,
+// so several replicas share one view of remaining credits.
+type BudgetStore interface {
+	// UpdateBudget stores provided budget, a budget older than the one
+	// already stored is ignored.
+	UpdateBudget(ctx context.Context, budget Budget) error
+
+	// Budget returns last known budget for provider.
+	Budget(ctx context.Context, provider string) (Budget, error)
+}
+
+// Priority defines the priority of requests made to market data providers.
+type Priority int
+
+const (
+	// HighPriority requests serve users and are always sent.
+	HighPriority Priority = iota
+
+	// LowPriority requests, such as background backfills, are refused
+	// when provider credits run low.
+	LowPriority
+)
+
+type priorityKey struct{}
+
+// WithPriority returns a new context carrying priority for requests made with it.
+func WithPriority(ctx context.Context, priority Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, priority)
+}
+
+// PriorityFrom returns priority set on context with WithPriority, defaulting
+// to HighPriority.
+func PriorityFrom(ctx context.Context) Priority {
+	if priority, ok := ctx.Value(priorityKey{}).(Priority); ok {
+		return priority
+	}
+	return HighPriority
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+
+	"github.com/influx6/btclists/pkg"
+)
+
+// runBackfill fills gaps in stored rates of pairs within a time range from the provider,
+// writing the report of each pair to stdout.
+// e.g btclistings backfill -pairs BTC/USD -from 2020-04-01 -to 2020-04-02
+func runBackfill(ctx context.Context, cfg config, args []string) error {
+	var flags = flag.NewFlagSet("backfill", flag.ContinueOnError)
+	var pairsList = flags.String("pairs", "", "comma separated list of pairs to backfill e.g BTC/USD,ETH/EUR (defaults to PAIRS)")
+	var fromTs = flags.String("from", "", "start of time range as a RFC3339 timestamp or YYYY-MM-DD date (defaults to -lookback before -to)")
+	var toTs = flags.String("to", "", "end of time range as a RFC3339 timestamp or YYYY-MM-DD date (defaults to now)")
+	var lookback = flags.Duration("lookback", pkg.DefaultBackfillLookback, "time range before -to backfilled where -from is not set")
+	var cadence = flags.Duration("cadence", pkg.DefaultCadence, "expected time between rates, where finer than the RESOLUTION of a pair its resolution is used")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	var pairs, err = cfg.pairs(*pairsList)
+	if err != nil {
+		return err
+	}
+
+	var from, to, rangeErr = parseTimeRange(*fromTs, *toTs, *lookback)
+	if rangeErr != nil {
+		return rangeErr
+	}
+
+	var db, dbErr = cfg.openDB(ctx)
+	if dbErr != nil {
+		return dbErr
+	}
+
+	defer db.Close()
+
+	var coinAPI, providerErr = cfg.marketAPI(db)
+	if providerErr != nil {
+		return providerErr
+	}
+
+	var backfiller = pkg.NewBackfiller(db, coinAPI)
+	backfiller.Cadence = *cadence
+	backfiller.Resolution = cfg.Resolution
+	backfiller.PairResolutions = cfg.PairResolutions
+
+	var encoder = json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+
+	for _, pair := range pairs.List() {
+		var report, backfillErr = backfiller.Backfill(ctx, pair.Coin, pair.Fiat, from, to)
+		if backfillErr != nil {
+			return backfillErr
+		}
+
+		if err := encoder.Encode(report); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/influx6/btclists"
+	"github.com/influx6/btclists/pkg"
+)
+
+// store is the storage of rates and provider budgets used by commands.
+type store interface {
+	btclists.RatesDB
+	btclists.BudgetStore
+	btclists.PriorRateService
+	Close() error
+}
+
+// config holds the settings shared by all commands, loaded from the environment.
+type config struct {
+	Store           string
+	Pairs           pkg.Pairs
+	Resolution      btclists.Resolution
+	PairResolutions map[btclists.Pair]btclists.Resolution
+}
+
+// loadConfig loads and validates shared settings from the environment.
+func loadConfig() (config, error) {
+	var cfg = config{Resolution: pkg.PeriodInterval}
+
+	switch cfg.Store = strings.ToLower(STORE); cfg.Store {
+	case "":
+		cfg.Store = "postgres"
+	case "postgres", "memory":
+	default:
+		return cfg, fmt.Errorf("unknown store %q, expected one of postgres or memory", STORE)
+	}
+
+	var pairsList = PAIRS
+	if pairsList == "" {
+		pairsList = fmt.Sprintf("%s/%s", CryptoCoin, FiatCurrency)
+	}
+
+	var err error
+	if cfg.Pairs, err = pkg.ParsePairs(pairsList); err != nil {
+		return cfg, fmt.Errorf("failed to parse supported pairs: %s", err)
+	}
+
+	if cfg.PairResolutions, err = pkg.ParsePairResolutions(PAIR_RESOLUTIONS); err != nil {
+		return cfg, fmt.Errorf("failed to parse pair resolutions: %s", err)
+	}
+
+	if RESOLUTION != "" {
+		if cfg.Resolution, err = btclists.ParseResolution(RESOLUTION); err != nil {
+			return cfg, fmt.Errorf("failed to parse resolution: %s", err)
+		}
+	}
+	return cfg, nil
+}
+
+// openDB returns the store set by STORE, connecting to the database at DATABASE_URL
+// and verifying the connection where it is postgres.
+func (c config) openDB(ctx context.Context) (store, error) {
+	if c.Store == "memory" {
+		log.Println("[BTC Listings] | [INFO] | Using in-memory store, rates are lost on exit")
+		return pkg.NewMemoryDB(), nil
+	}
+	return c.openPostgres(ctx)
+}
+
+// openPostgres connects to the database at DATABASE_URL, verifying the connection.
+func (c config) openPostgres(ctx context.Context) (*pkg.PostgresDB, error) {
+	if c.Store != "postgres" {
+		return nil, fmt.Errorf("command requires the postgres store but STORE is %q", c.Store)
+	}
+
+	var db, err = pkg.NewPostgresDBFromURL(DATABASE_URL, "ratings")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create database from url: %s", err)
+	}
+
+	if pingErr := db.DB().PingContext(ctx); pingErr != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to verify database connection: %s", pingErr)
+	}
+	return db, nil
+}
+
+// marketAPI returns the market data provider set by PROVIDER.
+func (c config) marketAPI(db store) (pkg.CoinMarketAPI, error) {
+	var guard, guardErr = c.creditGuard(db)
+	if guardErr != nil {
+		return nil, guardErr
+	}
+
+	var api, err = newMarketAPI(PROVIDER, c.Resolution, c.PairResolutions, db, guard)
+	if err != nil {
+		return nil, fmt.Errorf("failed to setup market data provider: %s", err)
+	}
+	return api, nil
+}
+
+// creditGuard returns a guard keeping COIN_API_RESERVE CoinAPI credits for user requests,
+// nil if no reserve is set.
+func (c config) creditGuard(db store) (*pkg.CreditGuard, error) {
+	if COIN_API_RESERVE == "" {
+		return nil, nil
+	}
+
+	var reserve, err = strconv.ParseInt(COIN_API_RESERVE, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse CoinAPI reserve: %s", err)
+	}
+
+	return &pkg.CreditGuard{
+		Budgets:  db,
+		Provider: pkg.CoinAPIProvider,
+		Reserve:  reserve,
+	}, nil
+}
+
+// pairs parses a comma separated list of pairs provided to a command, returning
+// all supported pairs where list is empty.
+func (c config) pairs(list string) (pkg.Pairs, error) {
+	if list == "" {
+		return c.Pairs, nil
+	}
+	return pkg.ParsePairs(list)
+}
+
+// parsePair parses a single pair in the format {coin}/{fiat} (e.g BTC/USD).
+func parsePair(value string) (btclists.Pair, error) {
+	var pairs, err = pkg.ParsePairs(value)
+	if err != nil {
+		return btclists.Pair{}, err
+	}
+	if len(pairs) != 1 {
+		return btclists.Pair{}, fmt.Errorf("expected a single pair but got %q", value)
+	}
+	return pairs.List()[0], nil
+}
+
+// parseTimestamp parses a RFC3339 timestamp or YYYY-MM-DD date.
+func parseTimestamp(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, errors.New("timestamp is required")
+	}
+
+	if dateTime, err := time.Parse(btclists.DateTimeFormat, value); err == nil {
+		return dateTime.UTC(), nil
+	}
+
+	var date, err = time.Parse(btclists.DateFormat, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is neither a RFC3339 timestamp or YYYY-MM-DD date", value)
+	}
+	return date.UTC(), nil
+}
+
+// parseTimeRange parses the from and to timestamps provided to a command, where to
+// defaults to now and from to lookback before to.
+func parseTimeRange(fromTs string, toTs string, lookback time.Duration) (time.Time, time.Time, error) {
+	var to = time.Now().UTC()
+	if toTs != "" {
+		var err error
+		if to, err = parseTimestamp(toTs); err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid -to: %s", err)
+		}
+	}
+
+	var from = to.Add(-lookback)
+	if fromTs != "" {
+		var err error
+		if from, err = parseTimestamp(fromTs); err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid -from: %s", err)
+		}
+	}
+
+	if from.After(to) {
+		return time.Time{}, time.Time{}, errors.New("-from must be before -to")
+	}
+	return from, to, nil
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/influx6/btclists/pkg"
+)
+
+// runExport exports rates of pairs within a time range from the database as a csv or parquet file
+// e.g btclistings export -pairs BTC/USD -from 2020-04-01 -to 2020-05-01 -format parquet -out rates.parquet
+func runExport(ctx context.Context, cfg config, args []string) error {
+	var flags = flag.NewFlagSet("export", flag.ContinueOnError)
+	var pairsList = flags.String("pairs", "", "comma separated list of pairs to export e.g BTC/USD,ETH/EUR (defaults to PAIRS)")
+	var fromTs = flags.String("from", "", "start of time range as a RFC3339 timestamp or YYYY-MM-DD date")
+	var toTs = flags.String("to", "", "end of time range as a RFC3339 timestamp or YYYY-MM-DD date (defaults to now)")
+	var columnsList = flags.String("columns", "", "comma separated list of columns from id, date, coin, fiat, rate and resolution (defaults to all)")
+	var formatName = flags.String("format", "", "csv or parquet (defaults to extension of -out, else csv)")
+	var out = flags.String("out", "-", "file to write export into, - for stdout")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *fromTs == "" {
+		return errors.New("-from is required")
+	}
+
+	var pairs, err = cfg.pairs(*pairsList)
+	if err != nil {
+		return err
+	}
+
+	var from, to, rangeErr = parseTimeRange(*fromTs, *toTs, 0)
+	if rangeErr != nil {
+		return rangeErr
+	}
+
+	var columns, columnsErr = pkg.ParseExportColumns(*columnsList)
+	if columnsErr != nil {
+		return columnsErr
+	}
+
+	if *formatName == "" {
+		*formatName = string(pkg.CSVFormat)
+		if strings.EqualFold(filepath.Ext(*out), ".parquet") {
+			*formatName = string(pkg.ParquetFormat)
+		}
+	}
+
+	var format, formatErr = pkg.ParseExportFormat(*formatName)
+	if formatErr != nil {
+		return formatErr
+	}
+
+	var db, dbErr = cfg.openDB(ctx)
+	if dbErr != nil {
+		return dbErr
+	}
+
+	defer db.Close()
+
+	var writer io.Writer = os.Stdout
+	if *out != "-" {
+		var file, fileErr = os.Create(*out)
+		if fileErr != nil {
+			return fileErr
+		}
+
+		defer file.Close()
+		writer = file
+	}
+
+	var written, exportErr = pkg.Export(ctx, db, writer, pkg.ExportOptions{
+		Pairs:   pairs.List(),
+		From:    from,
+		To:      to,
+		Columns: columns,
+		Format:  format,
+	})
+	if exportErr != nil {
+		return exportErr
+	}
+
+	log.Printf("[BTC Listings] | Exported rates | %d\n", written)
+	return nil
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"io"
+	"os"
+
+	"github.com/influx6/btclists/pkg"
+)
+
+// runImport imports rates from csv, jsonl or yaml files into the database, writing
+// a report of each file to stdout.
+// e.g btclistings import -pair BTC/USD history.csv fixtures/ratings.yml
+func runImport(ctx context.Context, cfg config, args []string) error {
+	var flags = flag.NewFlagSet("import", flag.ContinueOnError)
+	var formatName = flags.String("format", "", "csv, jsonl or yaml (defaults to extension of each file, required for stdin)")
+	var pairName = flags.String("pair", "", "pair of rows without coin and fiat e.g BTC/USD")
+	var chunkSize = flags.Int("chunk", pkg.DefaultImportChunk, "number of rows inserted at once")
+	var upsert = flags.Bool("upsert", false, "replace stored rates of the same pair and date e.g to apply corrections")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if flags.NArg() == 0 {
+		return errors.New("no files provided, use - to read from stdin")
+	}
+
+	var db, dbErr = cfg.openDB(ctx)
+	if dbErr != nil {
+		return dbErr
+	}
+
+	defer db.Close()
+
+	var importer = pkg.NewImporter(db)
+	importer.ChunkSize = *chunkSize
+	importer.Upsert = *upsert
+
+	if *pairName != "" {
+		var pair, err = parsePair(*pairName)
+		if err != nil {
+			return err
+		}
+		importer.Pair = pair
+	}
+
+	var encoder = json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+
+	for _, path := range flags.Args() {
+		var format pkg.ImportFormat
+		var formatErr error
+		if *formatName != "" {
+			format, formatErr = pkg.ParseImportFormat(*formatName)
+		} else {
+			format, formatErr = pkg.ImportFormatOf(path)
+		}
+		if formatErr != nil {
+			return formatErr
+		}
+
+		var report, err = importFile(ctx, importer, path, format)
+		if err != nil {
+			return err
+		}
+
+		if err := encoder.Encode(struct {
+			File string `json:"file"`
+			pkg.ImportReport
+		}{File: path, ImportReport: report}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func importFile(ctx context.Context, importer *pkg.Importer, path string, format pkg.ImportFormat) (pkg.ImportReport, error) {
+	var reader io.Reader = os.Stdin
+	if path != "-" {
+		var file, err = os.Open(path)
+		if err != nil {
+			return pkg.ImportReport{}, err
+		}
+
+		defer file.Close()
+		reader = file
+	}
+	return importer.Import(ctx, reader, format)
+}
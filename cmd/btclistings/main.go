@@ -2,17 +2,18 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
-	"sync"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
-	"github.com/go-chi/chi"
-	"github.com/go-chi/chi/middleware"
+	"github.com/influx6/btclists"
 	"github.com/influx6/btclists/pkg"
 )
 
@@ -27,6 +28,73 @@ var (
 	DATABASE_URL   = os.Getenv("DATABASE_URL")
 	COIN_API_TOKEN = os.Getenv("COIN_API_TOKEN")
 
+	// STORE sets where rates are stored, one of postgres (default), using the database
+	// at DATABASE_URL, or memory, keeping rates in memory for local development.
+	STORE = os.Getenv("STORE")
+
+	// AUTO_MIGRATE applies pending schema migrations on server boot where true.
+	AUTO_MIGRATE = os.Getenv("AUTO_MIGRATE")
+
+	// GRPC_PORT sets the port the gRPC API is served on next to the http API, disabled if empty.
+	GRPC_PORT = os.Getenv("GRPC_PORT")
+
+	// PROVIDER sets market data provider to use, one of coinapi (default), coingecko or kraken.
+	// Where a comma separated list is provided (e.g coinapi,kraken,coingecko), the consensus
+	// of all listed providers is used.
+	PROVIDER = os.Getenv("PROVIDER")
+
+	// PROVIDER_MODE sets how a list of providers is used, one of consensus (default) or failover,
+	// where failover tries providers in listed order.
+	PROVIDER_MODE = os.Getenv("PROVIDER_MODE")
+
+	// FAILOVER_COOLDOWN sets how long a provider tripped by quota or auth errors is
+	// left alone before being probed again (defaults to 5m).
+	FAILOVER_COOLDOWN = os.Getenv("FAILOVER_COOLDOWN")
+
+	// CONSENSUS_STRATEGY sets how consensus of providers is reached, one of median (default) or weighted.
+	CONSENSUS_STRATEGY = os.Getenv("CONSENSUS_STRATEGY")
+
+	// CONSENSUS_QUORUM sets the minimum number of providers which must agree (defaults to a majority of providers).
+	CONSENSUS_QUORUM = os.Getenv("CONSENSUS_QUORUM")
+
+	// COIN_API_RESERVE sets the CoinAPI credits kept for user requests, low priority
+	// calls such as backfills are refused once remaining credits fall below it.
+	COIN_API_RESERVE = os.Getenv("COIN_API_RESERVE")
+
+	// BACKFILL_INTERVAL sets how often stored rates of the last BACKFILL_LOOKBACK (defaults
+	// to 24h) are scanned for gaps which are then backfilled, disabled if empty.
+	BACKFILL_INTERVAL = os.Getenv("BACKFILL_INTERVAL")
+	BACKFILL_LOOKBACK = os.Getenv("BACKFILL_LOOKBACK")
+
+	// BASE_CURRENCY sets the currency conversions are triangulated through where
+	// no direct pair is supported (defaults to USD).
+	BASE_CURRENCY = os.Getenv("BASE_CURRENCY")
+
+	// FX_SOURCE sets the URL or file path of ECB euro foreign exchange reference rates
+	// (e.g https://www.ecb.europa.eu/stats/eurofxref/eurofxref-daily.xml), stored under
+	// EUR/{currency} pairs for cross-rates. Disabled if empty.
+	FX_SOURCE = os.Getenv("FX_SOURCE")
+
+	// FX_INTERVAL sets how often reference rates are refreshed (defaults to 6h).
+	FX_INTERVAL = os.Getenv("FX_INTERVAL")
+
+	// INGESTION sets how latest rates are ingested, one of poll (default), polling the provider
+	// each minute, or stream, consuming exchange rates pushed over CoinAPI's WebSocket API.
+	INGESTION = os.Getenv("INGESTION")
+
+	// STREAM_RESOLUTION sets the period streamed exchange rates are aggregated into rates
+	// by (defaults to 10SEC).
+	STREAM_RESOLUTION = os.Getenv("STREAM_RESOLUTION")
+
+	// PAIRS is a comma separated list of supported pairs e.g BTC/USD,ETH/EUR.
+	PAIRS = os.Getenv("PAIRS")
+
+	// RESOLUTION sets default candle resolution for history e.g 2MIN, 1HRS, 1DAY.
+	RESOLUTION = os.Getenv("RESOLUTION")
+
+	// PAIR_RESOLUTIONS sets candle resolution for specific pairs e.g BTC/USD=1MIN,ETH/EUR=1HRS.
+	PAIR_RESOLUTIONS = os.Getenv("PAIR_RESOLUTIONS")
+
 	httpClient = &http.Client{
 		Timeout: time.Second * 10,
 	}
@@ -47,88 +115,162 @@ func (c loggingClient) Do(req *http.Request) (*http.Response, error) {
 
 	var res, err = httpClient.Do(req)
 	if err != nil {
-		log.Printf("[BTC Listings] | [COIN API] | %s | %d\n", req.URL.String(), res.StatusCode)
+		log.Printf("[BTC Listings] | [COIN API] | %s | %s\n", req.URL.String(), err)
+		return res, err
 	}
+
+	log.Printf("[BTC Listings] | [COIN API] | %s | %d\n", req.URL.String(), res.StatusCode)
 	return res, err
 }
 
-func main() {
-	var stopChan = make(chan os.Signal, 1)
-	signal.Notify(stopChan, signals...)
+// newMarketAPI returns the market data provider implementation for giving provider names,
+// where more than one provider is named, a consensus of all providers is returned.
+func newMarketAPI(providers string, resolution btclists.Resolution, pairResolutions map[btclists.Pair]btclists.Resolution, budgets btclists.BudgetStore, guard *pkg.CreditGuard) (pkg.CoinMarketAPI, error) {
+	var names = strings.Split(providers, ",")
+	if len(names) == 1 {
+		return newProvider(strings.TrimSpace(names[0]), resolution, pairResolutions, budgets, guard)
+	}
 
-	var ctx, ctxCancelFunc = context.WithCancel(context.Background())
+	var sources = make([]pkg.MarketSource, 0, len(names))
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
 
-	// listen for close signal and cancel root context.
-	go func() {
-		<-stopChan
-		ctxCancelFunc()
-		log.Println("[BTC Listings] | received closed signal")
-	}()
+		var provider, err = newProvider(name, resolution, pairResolutions, budgets, guard)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, pkg.MarketSource{Name: name, API: provider})
+	}
 
-	var db, err = pkg.NewPostgresDBFromURL(DATABASE_URL, "ratings")
-	if err != nil {
-		log.Fatalf("[BTC Listings] | Failed to create database from url: %s", err)
-		return
+	switch strings.ToLower(PROVIDER_MODE) {
+	case "", "consensus":
+	case "failover":
+		var coolDown = pkg.DefaultCoolDown
+		if FAILOVER_COOLDOWN != "" {
+			var err error
+			if coolDown, err = time.ParseDuration(FAILOVER_COOLDOWN); err != nil {
+				return nil, fmt.Errorf("invalid failover cool-down %q: %s", FAILOVER_COOLDOWN, err)
+			}
+		}
+		return pkg.NewFailoverMarket(coolDown, sources...), nil
+	default:
+		return nil, fmt.Errorf("unknown provider mode %q, expected one of consensus or failover", PROVIDER_MODE)
 	}
 
-	if pingErr := db.DB().PingContext(ctx); pingErr != nil {
-		log.Fatalf("[BTC Listings] | Failed to verify database connection: %s", pingErr)
-		return
+	var strategy = pkg.Median
+	switch strings.ToLower(CONSENSUS_STRATEGY) {
+	case "", "median":
+	case "weighted":
+		strategy = pkg.WeightedMean
+	default:
+		return nil, fmt.Errorf("unknown consensus strategy %q, expected one of median or weighted", CONSENSUS_STRATEGY)
 	}
 
-	// setup api service implementation
-	var coinAPI = pkg.NewCoinAPI(pkg.CoinApiProdURL, COIN_API_TOKEN, &loggingClient{})
+	var consensus = pkg.NewConsensusMarket(strategy, sources...)
+	if CONSENSUS_QUORUM != "" {
+		var quorum, err = strconv.Atoi(CONSENSUS_QUORUM)
+		if err != nil {
+			return nil, fmt.Errorf("invalid consensus quorum %q: %s", CONSENSUS_QUORUM, err)
+		}
+		consensus.Quorum = quorum
+	}
+	return consensus, nil
+}
 
-	var ratingService = pkg.NewCoinRatingService(ctx, db, coinAPI)
+// newProvider returns the market data provider implementation for giving provider name.
+func newProvider(provider string, resolution btclists.Resolution, pairResolutions map[btclists.Pair]btclists.Resolution, budgets btclists.BudgetStore, guard *pkg.CreditGuard) (pkg.CoinMarketAPI, error) {
+	switch strings.ToLower(provider) {
+	case "", "coinapi":
+		var coinAPI = pkg.NewCoinAPI(pkg.CoinApiProdURL, COIN_API_TOKEN, &loggingClient{})
+		coinAPI.Resolution = resolution
+		coinAPI.PairResolutions = pairResolutions
+		coinAPI.Budgets = budgets
+		coinAPI.Guard = guard
+		return coinAPI, nil
+	case "coingecko":
+		return pkg.NewCoinGecko(pkg.CoinGeckoURL, &loggingClient{}), nil
+	case "kraken":
+		var kraken = pkg.NewKraken(pkg.KrakenURL, &loggingClient{})
+		if RESOLUTION != "" {
+			kraken.Resolution = resolution
+		}
+		return kraken, nil
+	default:
+		return nil, fmt.Errorf("unknown provider %q, expected one of coinapi, coingecko or kraken", provider)
+	}
+}
+
+// command is a subcommand of the btclistings binary.
+type command struct {
+	Name  string
+	Usage string
+	Run   func(ctx context.Context, cfg config, args []string) error
+}
 
-	router := chi.NewRouter()
-	router.Use(middleware.Logger)
-	router.Get("/at", pkg.GetLatestAt(ratingService, FiatCurrency, CryptoCoin))
-	router.Get("/latest", pkg.GetLatest(ratingService, FiatCurrency, CryptoCoin))
-	router.Get("/avg", pkg.GetAverageFor(ratingService, ratingService, FiatCurrency, CryptoCoin))
+var commands = []command{
+	{Name: "serve", Usage: "serve the http and gRPC APIs while ingesting rates (default)", Run: runServe},
+	{Name: "backfill", Usage: "fill gaps in stored rates of pairs within a time range from the provider", Run: runBackfill},
+	{Name: "import", Usage: "import rates from csv, jsonl or yaml files", Run: runImport},
+	{Name: "export", Usage: "export rates of pairs within a time range as csv or parquet", Run: runExport},
+	{Name: "migrate", Usage: "apply or revert schema migrations, or report the schema version", Run: runMigrate},
+	{Name: "query", Usage: "query stored rates of a pair e.g latest, at, range, avg, count, gaps", Run: runQuery},
+}
 
-	var addr = fmt.Sprintf("%s:%s", HOST, PORT)
-	var server = &http.Server{
-		Addr:         addr,
-		Handler:      router,
-		ReadTimeout:  5 * time.Second,
-		WriteTimeout: 10 * time.Second,
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: btclistings <command> [flags]\n\nCommands:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", cmd.Name, cmd.Usage)
 	}
+	fmt.Fprintf(os.Stderr, "\nRun btclistings <command> -h for flags of a command, all commands read\n")
+	fmt.Fprintf(os.Stderr, "DATABASE_URL, PAIRS and provider settings from the environment.\n")
+}
 
-	var waiter sync.WaitGroup
-	waiter.Add(2)
+func main() {
+	// serve where no command is given, as prior to subcommands.
+	var name, args = "serve", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
 
-	// Start routing for periodic updates
-	go func() {
-		defer waiter.Done()
-		defer log.Println("[BTC Listings] | periodic rating update routine stopped")
+	if name == "help" {
+		usage()
+		return
+	}
 
-		log.Println("[BTC Listings] | Starting periodic rating update routine")
-		pkg.PeriodicRatingUpdate(ctx, db, coinAPI, CryptoCoin, FiatCurrency)
+	var cmd *command
+	for index := range commands {
+		if commands[index].Name == name {
+			cmd = &commands[index]
+		}
+	}
 
-		defer log.Println("[BTC Listings] | stopping periodic rating update routine")
-	}()
+	if cmd == nil {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", name)
+		usage()
+		os.Exit(2)
+	}
+
+	var cfg, cfgErr = loadConfig()
+	if cfgErr != nil {
+		log.Fatalf("[BTC Listings] | %s", cfgErr)
+	}
+
+	var stopChan = make(chan os.Signal, 1)
+	signal.Notify(stopChan, signals...)
+
+	var ctx, ctxCancelFunc = context.WithCancel(context.Background())
 
-	// listen for closed signal to closer server
+	// listen for close signal and cancel root context.
 	go func() {
-		defer waiter.Done()
-		<-ctx.Done()
+		<-stopChan
+		ctxCancelFunc()
+		log.Println("[BTC Listings] | received closed signal")
+	}()
 
-		// shut server down in 1 minute.
-		var wait5, _ = context.WithTimeout(ctx, time.Minute*1)
-		if err := server.Shutdown(wait5); err != nil {
-			log.Printf("[BTC Listings] | Server shutdown had issues | %s\n", err)
+	if err := cmd.Run(ctx, cfg, args); err != nil {
+		if err == flag.ErrHelp {
 			return
 		}
-		log.Println("[BTC Listings] | Server successfully shutdown")
-	}()
-
-	//  boot up http server
-	log.Printf("[BTC Listings] | Booting up http server | %s\n", addr)
-	if err := server.ListenAndServe(); err != nil {
-		log.Println("[BTC Listings] | Server shutting down, if you did this, I will find you... :)")
+		log.Fatalf("[BTC Listings] | Failed to %s: %s", cmd.Name, err)
 	}
-
-	// ensure all go-routines are clean-ed out.
-	waiter.Wait()
 }
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+
+	"github.com/influx6/btclists/pkg"
+)
+
+// runMigrate applies (up) or reverts (down) schema migrations, or reports the
+// schema version (status).
+// e.g btclistings migrate up, btclistings migrate -steps 2 down
+func runMigrate(ctx context.Context, cfg config, args []string) error {
+	var flags = flag.NewFlagSet("migrate", flag.ContinueOnError)
+	var steps = flags.Int("steps", 1, "number of migrations reverted by down")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: btclistings migrate [flags] [up|down|status]\n")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	var direction = "up"
+	if flags.NArg() > 0 {
+		direction = flags.Arg(0)
+	}
+
+	var db, err = cfg.openPostgres(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer db.Close()
+
+	var migrator, migratorErr = pkg.NewMigrator(db.DB())
+	if migratorErr != nil {
+		return migratorErr
+	}
+
+	switch direction {
+	case "up":
+		var applied, upErr = migrator.Up(ctx)
+		if upErr != nil {
+			return upErr
+		}
+		log.Printf("[BTC Listings] | Applied migrations | %d\n", len(applied))
+	case "down":
+		var reverted, downErr = migrator.Down(ctx, *steps)
+		if downErr != nil {
+			return downErr
+		}
+		log.Printf("[BTC Listings] | Reverted migrations | %d\n", len(reverted))
+	case "status":
+	default:
+		flags.Usage()
+		return fmt.Errorf("unknown migration direction %q", direction)
+	}
+
+	var version, versionErr = migrator.Version(ctx)
+	if versionErr != nil {
+		return versionErr
+	}
+
+	var migrations, _ = pkg.Migrations()
+	log.Printf("[BTC Listings] | Schema version | %d of %d\n", version, len(migrations))
+	return nil
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/influx6/btclists/pkg"
+)
+
+// runQuery writes the result of a query of stored rates of a pair to stdout as json,
+// queries are latest, oldest, at, range, avg, count, stats and gaps.
+// e.g btclistings query -pair BTC/USD -from 2020-04-01 -to 2020-04-02 count
+func runQuery(ctx context.Context, cfg config, args []string) error {
+	var flags = flag.NewFlagSet("query", flag.ContinueOnError)
+	var pairName = flags.String("pair", fmt.Sprintf("%s/%s", CryptoCoin, FiatCurrency), "pair to query e.g BTC/USD")
+	var atTs = flags.String("at", "", "time of rate as a RFC3339 timestamp or YYYY-MM-DD date, required by at")
+	var fromTs = flags.String("from", "", "start of time range as a RFC3339 timestamp or YYYY-MM-DD date (defaults to -lookback before -to)")
+	var toTs = flags.String("to", "", "end of time range as a RFC3339 timestamp or YYYY-MM-DD date (defaults to now)")
+	var lookback = flags.Duration("lookback", 24*time.Hour, "time range before -to queried where -from is not set")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: btclistings query [flags] latest|oldest|at|range|avg|count|stats|gaps\n")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return fmt.Errorf("expected a single query but got %d", flags.NArg())
+	}
+
+	var pair, err = parsePair(*pairName)
+	if err != nil {
+		return err
+	}
+
+	var from, to, rangeErr = parseTimeRange(*fromTs, *toTs, *lookback)
+	if rangeErr != nil {
+		return rangeErr
+	}
+
+	var db, dbErr = cfg.openDB(ctx)
+	if dbErr != nil {
+		return dbErr
+	}
+
+	defer db.Close()
+
+	var result interface{}
+	var queryErr error
+	switch query := flags.Arg(0); query {
+	case "latest":
+		result, queryErr = db.Latest(ctx, pair.Coin, pair.Fiat)
+	case "oldest":
+		result, queryErr = db.Oldest(ctx, pair.Coin, pair.Fiat)
+	case "at":
+		var at, atErr = parseTimestamp(*atTs)
+		if atErr != nil {
+			return fmt.Errorf("invalid -at: %s", atErr)
+		}
+		result, queryErr = db.At(ctx, pair.Coin, pair.Fiat, at)
+	case "range":
+		result, queryErr = db.Range(ctx, pair.Coin, pair.Fiat, from, to)
+	case "avg":
+		var average, averageErr = db.AverageForRange(ctx, pair.Coin, pair.Fiat, from, to)
+		result, queryErr = map[string]interface{}{"average": average}, averageErr
+	case "count":
+		var count, countErr = db.CountForRange(ctx, pair.Coin, pair.Fiat, from, to)
+		result, queryErr = map[string]interface{}{"count": count}, countErr
+	case "stats":
+		result, queryErr = db.StatsForRange(ctx, pair.Coin, pair.Fiat, from, to, nil)
+	case "gaps":
+		var backfiller = pkg.NewBackfiller(db, nil)
+		backfiller.Resolution = cfg.Resolution
+		backfiller.PairResolutions = cfg.PairResolutions
+		result, queryErr = backfiller.Gaps(ctx, pair.Coin, pair.Fiat, from, to)
+	default:
+		flags.Usage()
+		return fmt.Errorf("unknown query %q", query)
+	}
+
+	if queryErr != nil {
+		return queryErr
+	}
+
+	var encoder = json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(result)
+}
@@ -0,0 +1,288 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/influx6/btclists"
+	"github.com/influx6/btclists/pkg"
+	"github.com/influx6/btclists/rpc"
+	"google.golang.org/grpc"
+)
+
+// runServe serves the http and gRPC APIs, ingesting rates of supported pairs
+// till ctx is cancelled.
+func runServe(ctx context.Context, cfg config, args []string) error {
+	var flags = flag.NewFlagSet("serve", flag.ContinueOnError)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	var pairs = cfg.Pairs
+
+	var ingestion = strings.ToLower(INGESTION)
+	switch ingestion {
+	case "", "poll", "stream":
+	default:
+		return fmt.Errorf("unknown ingestion %q, expected one of poll or stream", INGESTION)
+	}
+
+	var streamResolution = pkg.DefaultStreamResolution
+	if STREAM_RESOLUTION != "" {
+		var resErr error
+		if streamResolution, resErr = btclists.ParseResolution(STREAM_RESOLUTION); resErr != nil {
+			return fmt.Errorf("failed to parse stream resolution: %s", resErr)
+		}
+	}
+
+	var db, err = cfg.openDB(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer db.Close()
+
+	// the memory store has no schema to migrate.
+	var autoMigrate, _ = strconv.ParseBool(AUTO_MIGRATE)
+	if postgres, ok := db.(*pkg.PostgresDB); ok && autoMigrate {
+		var migrator, migratorErr = pkg.NewMigrator(postgres.DB())
+		if migratorErr != nil {
+			return migratorErr
+		}
+
+		if _, migrateErr := migrator.Up(ctx); migrateErr != nil {
+			return fmt.Errorf("failed to migrate database: %s", migrateErr)
+		}
+	}
+
+	// setup api service implementation
+	var coinAPI, providerErr = cfg.marketAPI(db)
+	if providerErr != nil {
+		return providerErr
+	}
+
+	var ratingService = pkg.NewCoinRatingService(ctx, db, coinAPI)
+	ratingService.SetResolutions(cfg.Resolution, cfg.PairResolutions)
+
+	// rates stored by periodic updates are pushed to feed and stream subscribers.
+	var feed = pkg.NewRateHub()
+	var feedDB = pkg.NewPublishingRatesDB(db, feed)
+
+	var backfiller = pkg.NewBackfiller(db, coinAPI)
+	backfiller.Resolution = cfg.Resolution
+	backfiller.PairResolutions = cfg.PairResolutions
+	var converter = pkg.NewConverter(ratingService, pkg.NewPairs(pairs.List()...))
+	converter.Stored = db
+	if BASE_CURRENCY != "" {
+		converter.Base = BASE_CURRENCY
+	}
+
+	var fxInterval = pkg.DefaultReferenceRateInterval
+	if FX_INTERVAL != "" {
+		var intervalErr error
+		if fxInterval, intervalErr = time.ParseDuration(FX_INTERVAL); intervalErr != nil {
+			return fmt.Errorf("failed to parse reference rate interval: %s", intervalErr)
+		}
+	}
+
+	// reference pairs are only known once pulled, from then on they are served for conversions
+	// from stored rates only.
+	var addReferencePairs = func(rates []btclists.Rate) {
+		var referencePairs = pkg.ReferencePairs(rates)
+		ratingService.AddReferencePairs(referencePairs)
+		for _, pair := range referencePairs.List() {
+			converter.AddStoredPair(pair, pkg.ReferenceRateMaxAge)
+		}
+	}
+
+	var fxSource pkg.ReferenceRateSource
+	if FX_SOURCE != "" {
+		fxSource = pkg.NewECB(FX_SOURCE, &loggingClient{})
+
+		// conversions through reference pairs become available once an import succeeds,
+		// so a failing source does not keep the rest of the API from serving.
+		if fxRates, fxErr := pkg.ImportReferenceRates(ctx, db, fxSource); fxErr != nil {
+			log.Printf("[BTC Listings] | [ERROR] | Failed to import reference rates, retrying in %s | %s\n", fxInterval, fxErr)
+		} else {
+			addReferencePairs(fxRates)
+		}
+	}
+
+	if BACKFILL_LOOKBACK != "" {
+		var lookbackErr error
+		if backfiller.Lookback, lookbackErr = time.ParseDuration(BACKFILL_LOOKBACK); lookbackErr != nil {
+			return fmt.Errorf("failed to parse backfill lookback: %s", lookbackErr)
+		}
+	}
+
+	var backfillInterval time.Duration
+	if BACKFILL_INTERVAL != "" {
+		var intervalErr error
+		if backfillInterval, intervalErr = time.ParseDuration(BACKFILL_INTERVAL); intervalErr != nil {
+			return fmt.Errorf("failed to parse backfill interval: %s", intervalErr)
+		}
+	}
+
+	router := chi.NewRouter()
+	router.Use(middleware.Logger)
+	router.Get("/at", pkg.GetLatestAt(ratingService, FiatCurrency, CryptoCoin))
+	router.Get("/latest", pkg.GetLatest(ratingService, FiatCurrency, CryptoCoin))
+	router.Get("/avg", pkg.GetAverageFor(ratingService, ratingService, FiatCurrency, CryptoCoin))
+	router.Get("/twap", pkg.GetTimeWeightedAverage(ratingService, FiatCurrency, CryptoCoin))
+	router.Get("/vwap", pkg.GetVolumeWeightedAverage(ratingService, FiatCurrency, CryptoCoin))
+	router.Get("/stats", pkg.GetStats(ratingService, FiatCurrency, CryptoCoin))
+	router.Get("/range", pkg.GetRange(ratingService, FiatCurrency, CryptoCoin))
+	router.Get("/ohlc", pkg.GetCandles(ratingService, FiatCurrency, CryptoCoin))
+	router.Get("/convert", pkg.GetConversion(converter))
+	router.Get("/v1/convert", pkg.GetConversion(converter))
+	router.Get("/v1/feed", pkg.GetRateFeed(feed, pairs))
+	router.Get("/v1/stream", pkg.GetRateStream(feed, db, pairs))
+	router.Get("/v1/export", pkg.GetExport(db, pairs))
+	if reporter, ok := coinAPI.(pkg.ProviderStatusReporter); ok {
+		router.Get("/v1/status/providers", pkg.GetProviderStatus(reporter))
+	}
+
+	router.Route("/v1/{coin}/{fiat}", func(r chi.Router) {
+		r.Get("/at", pkg.GetLatestAtForPair(ratingService, pairs))
+		r.Get("/latest", pkg.GetLatestForPair(ratingService, pairs))
+		r.Get("/avg", pkg.GetAverageForPair(ratingService, ratingService, pairs))
+		r.Get("/twap", pkg.GetTimeWeightedAverageForPair(ratingService, pairs))
+		r.Get("/vwap", pkg.GetVolumeWeightedAverageForPair(ratingService, pairs))
+		r.Get("/stats", pkg.GetStatsForPair(ratingService, pairs))
+		r.Get("/range", pkg.GetRangeForPair(ratingService, pairs))
+		r.Get("/ohlc", pkg.GetCandlesForPair(ratingService, pairs))
+		r.Get("/feed", pkg.GetRateFeedForPair(feed, pairs))
+		r.Get("/stream", pkg.GetRateStreamForPair(feed, db, pairs))
+		r.Get("/export", pkg.GetExportForPair(db, pairs))
+		r.Post("/backfill", pkg.PostBackfillForPair(backfiller, pairs))
+	})
+
+	var addr = fmt.Sprintf("%s:%s", HOST, PORT)
+	var server = &http.Server{
+		Addr:         addr,
+		Handler:      router,
+		ReadTimeout:  5 * time.Second,
+		WriteTimeout: 10 * time.Second,
+	}
+
+	var grpcServer = grpc.NewServer()
+	rpc.RegisterRateServiceServer(grpcServer, pkg.NewRateServer(ratingService, ratingService, feed, pairs))
+
+	var waiter sync.WaitGroup
+	waiter.Add(1)
+
+	// boot up gRPC server next to http server.
+	if GRPC_PORT != "" {
+		var grpcAddr = fmt.Sprintf("%s:%s", HOST, GRPC_PORT)
+		var listener, listenErr = net.Listen("tcp", grpcAddr)
+		if listenErr != nil {
+			return fmt.Errorf("failed to listen for gRPC: %s", listenErr)
+		}
+
+		waiter.Add(1)
+		go func() {
+			defer waiter.Done()
+
+			log.Printf("[BTC Listings] | Booting up gRPC server | %s\n", grpcAddr)
+			if err := grpcServer.Serve(listener); err != nil {
+				log.Printf("[BTC Listings] | gRPC server had issues | %s\n", err)
+			}
+		}()
+	}
+
+	switch ingestion {
+	case "", "poll":
+		// Start routing for periodic updates for each supported pair.
+		for _, pair := range pairs.List() {
+			waiter.Add(1)
+			go func(pair btclists.Pair) {
+				defer waiter.Done()
+				defer log.Printf("[BTC Listings] | periodic rating update routine stopped | %s\n", pair)
+
+				log.Printf("[BTC Listings] | Starting periodic rating update routine | %s\n", pair)
+				pkg.PeriodicRatingUpdate(ctx, feedDB, coinAPI, pair.Coin, pair.Fiat)
+
+				defer log.Printf("[BTC Listings] | stopping periodic rating update routine | %s\n", pair)
+			}(pair)
+		}
+	case "stream":
+		// Start routine streaming rates of all supported pairs.
+		var stream = pkg.NewCoinAPIStream(pkg.CoinApiStreamURL, COIN_API_TOKEN, db)
+		stream.Hub = feed
+		stream.Resolution = streamResolution
+
+		waiter.Add(1)
+		go func() {
+			defer waiter.Done()
+			defer log.Println("[BTC Listings] | streaming rating ingestion routine stopped")
+
+			log.Printf("[BTC Listings] | Starting streaming rating ingestion routine | %s\n", stream.Resolution)
+			stream.Run(ctx, pairs)
+		}()
+	}
+
+	// Start routine for periodic reference rate updates.
+	if fxSource != nil {
+		waiter.Add(1)
+		go func() {
+			defer waiter.Done()
+			defer log.Println("[BTC Listings] | reference rate update routine stopped")
+
+			log.Printf("[BTC Listings] | Starting reference rate update routine | every %s\n", fxInterval)
+			pkg.PeriodicReferenceRateUpdate(ctx, db, fxSource, fxInterval, addReferencePairs)
+		}()
+	}
+
+	// Start routine for scheduled backfills of supported pairs.
+	if backfillInterval > 0 {
+		waiter.Add(1)
+		go func() {
+			defer waiter.Done()
+			defer log.Println("[BTC Listings] | backfill routine stopped")
+
+			log.Printf("[BTC Listings] | Starting backfill routine | every %s\n", backfillInterval)
+			backfiller.Run(ctx, pairs, backfillInterval)
+		}()
+	}
+
+	// listen for closed signal to closer server
+	go func() {
+		defer waiter.Done()
+		<-ctx.Done()
+
+		// hijacked feed connections are not closed by server shutdown, while
+		// streams would hold it up.
+		feed.Close()
+		grpcServer.GracefulStop()
+
+		// shut server down in 1 minute.
+		var wait5, cancelWait = context.WithTimeout(context.Background(), time.Minute*1)
+		defer cancelWait()
+
+		if err := server.Shutdown(wait5); err != nil {
+			log.Printf("[BTC Listings] | Server shutdown had issues | %s\n", err)
+			return
+		}
+		log.Println("[BTC Listings] | Server successfully shutdown")
+	}()
+
+	//  boot up http server
+	log.Printf("[BTC Listings] | Booting up http server | %s\n", addr)
+	if err := server.ListenAndServe(); err != nil {
+		log.Println("[BTC Listings] | Server shutting down, if you did this, I will find you... :)")
+	}
+
+	// ensure all go-routines are clean-ed out.
+	waiter.Wait()
+	return nil
+}
@@ -0,0 +1,217 @@
+package pkg
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/influx6/btclists"
+)
+
+const (
+	// DefaultBackfillLookback is the time range before now scanned for gaps by
+	// scheduled backfills.
+	DefaultBackfillLookback = 24 * time.Hour
+)
+
+var (
+	_ BackfillService = (*Backfiller)(nil)
+)
+
+// BackfillReport describes the outcome of a backfill of a pair.
+type BackfillReport struct {
+	Coin    string    `json:"coin"`
+	Fiat    string    `json:"fiat"`
+	From    time.Time `json:"from"`
+	To      time.Time `json:"to"`
+	Gaps    []Gap     `json:"gaps"`
+	Fetched int       `json:"fetched"`
+	Failed  int       `json:"failed"`
+}
+
+// BackfillService defines a type able to fill gaps of a pair within a time range.
+type BackfillService interface {
+	Backfill(ctx context.Context, coin string, fiat string, from time.Time, to time.Time) (BackfillReport, error)
+}
+
+// Backfiller scans RatesDB for gaps in the rates of pairs against the expected cadence
+// (e.g missed by PeriodicRatingUpdate while the provider errored or the process was down),
+// fetching only the missing windows from the exchange service and storing them.
+//
+// Exchange calls are made with btclists.LowPriority, so providers guarding their credits
+// (see CoinAPI.Guard) refuse them once credits fall below their reserve, stopping backfills.
+type Backfiller struct {
+	DB       btclists.RatesDB
+	Exchange CoinMarketAPI
+
+	// Cadence sets the expected time between rates, defaults to DefaultCadence. Where
+	// the resolution of a pair is coarser, it's resolution is the expected cadence.
+	Cadence time.Duration
+
+	// Resolution and PairResolutions set the resolution rates of pairs are fetched
+	// at from the exchange service, as set on CoinAPI.
+	Resolution      btclists.Resolution
+	PairResolutions map[btclists.Pair]btclists.Resolution
+
+	// Tolerance sets how much further than Cadence rates may be apart before
+	// being considered a gap, defaults to Cadence.
+	Tolerance time.Duration
+
+	// Lookback sets the time range before now scanned by Run, defaults
+	// to DefaultBackfillLookback.
+	Lookback time.Duration
+}
+
+func NewBackfiller(db btclists.RatesDB, exchange CoinMarketAPI) *Backfiller {
+	return &Backfiller{
+		DB:       db,
+		Exchange: exchange,
+		Cadence:  DefaultCadence,
+		Lookback: DefaultBackfillLookback,
+	}
+}
+
+// Gaps returns the gaps in stored rates of pair within provided time range.
+func (b *Backfiller) Gaps(ctx context.Context, coin string, fiat string, from time.Time, to time.Time) ([]Gap, error) {
+	var rates, err = b.DB.Range(ctx, coin, fiat, from, to)
+	if err != nil {
+		log.Printf("[BTC Listings] | [ERROR] | [BACKFILL] | Failed to retrieve rates | %s\n", err)
+		return nil, err
+	}
+
+	var cadence, tolerance = b.cadence(coin, fiat)
+	var gaps = FindGaps(rates, from, to, cadence, tolerance)
+	for index := range gaps {
+		gaps[index].Coin = coin
+		gaps[index].Fiat = fiat
+	}
+	return gaps, nil
+}
+
+// Backfill fetches rates for all gaps of pair within provided time range, storing them
+// with RatesDB.AddBatch.
+//
+// Gaps failing to be fetched are counted in the report and skipped, except where
+// provider's budget is exhausted, in which case backfill stops with btclists.ErrBudgetExhausted.
+func (b *Backfiller) Backfill(ctx context.Context, coin string, fiat string, from time.Time, to time.Time) (BackfillReport, error) {
+	var report = BackfillReport{Coin: coin, Fiat: fiat, From: from, To: to}
+
+	var gaps, err = b.scan(ctx, coin, fiat, from, to)
+	if err != nil {
+		return report, err
+	}
+	report.Gaps = gaps
+
+	ctx = btclists.WithPriority(ctx, btclists.LowPriority)
+
+	var cadence, _ = b.cadence(coin, fiat)
+	for _, gap := range gaps {
+		var limit = int(gap.To.Sub(gap.From)/cadence) + 1
+		if limit > MaxLimit {
+			limit = MaxLimit
+		}
+
+		var rates, fetchErr = b.Exchange.Range(ctx, coin, fiat, gap.From, gap.To, limit)
+		if fetchErr == btclists.ErrBudgetExhausted {
+			return report, fetchErr
+		}
+		if fetchErr != nil {
+			log.Printf("[BTC Listings] | [ERROR] | [BACKFILL] | Failed to fetch gap | %s/%s | %s - %s | %s\n", coin, fiat, gap.From, gap.To, fetchErr)
+			report.Failed++
+			continue
+		}
+
+		if len(rates) == 0 {
+			continue
+		}
+
+		if dbErr := b.DB.AddBatch(ctx, rates); dbErr != nil {
+			log.Printf("[BTC Listings] | [CRITICAL] | [BACKFILL] | Failed to store gap | %s/%s | %s\n", coin, fiat, dbErr)
+			return report, dbErr
+		}
+		report.Fetched += len(rates)
+	}
+
+	log.Printf("[BTC Listings] | [INFO] | [BACKFILL] | Backfilled pair | %s/%s | gaps: %d | fetched: %d | failed: %d\n", coin, fiat, len(gaps), report.Fetched, report.Failed)
+	return report, nil
+}
+
+// Run boots up a loop backfilling the Lookback range of provided pairs on each interval,
+// till ctx is cancelled. A first backfill runs immediately.
+func (b *Backfiller) Run(ctx context.Context, pairs Pairs, interval time.Duration) {
+	var ticker = time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		b.backfillPairs(ctx, pairs)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (b *Backfiller) backfillPairs(ctx context.Context, pairs Pairs) {
+	var lookback = b.Lookback
+	if lookback <= 0 {
+		lookback = DefaultBackfillLookback
+	}
+
+	var to = time.Now().UTC()
+	var from = to.Add(-lookback)
+	for _, pair := range pairs.List() {
+		if _, err := b.Backfill(ctx, pair.Coin, pair.Fiat, from, to); err != nil {
+			log.Printf("[BTC Listings] | [ERROR] | [BACKFILL] | Backfill of pair stopped | %s | %s\n", pair, err)
+			if err == btclists.ErrBudgetExhausted {
+				return
+			}
+		}
+	}
+}
+
+// scan returns the gaps of pair within time range as Gaps does, scanned by DB where it
+// implements RangeScanner, in which case gaps beyond MaxGapRequests are coalesced into one.
+func (b *Backfiller) scan(ctx context.Context, coin string, fiat string, from time.Time, to time.Time) ([]Gap, error) {
+	var scanner, ok = b.DB.(RangeScanner)
+	if !ok || b.Tolerance > 0 {
+		return b.Gaps(ctx, coin, fiat, from, to)
+	}
+
+	var cadence, _ = b.cadence(coin, fiat)
+	var scan, err = scanner.ScanRange(ctx, coin, fiat, from, to, cadence)
+	if err != nil {
+		log.Printf("[BTC Listings] | [ERROR] | [BACKFILL] | Failed to scan rates | %s\n", err)
+		return nil, err
+	}
+
+	for index := range scan.Gaps {
+		scan.Gaps[index].Coin = coin
+		scan.Gaps[index].Fiat = fiat
+	}
+	return scan.Gaps, nil
+}
+
+// cadence returns the expected time between rates of pair and the tolerance
+// beyond it before rates are considered apart by a gap.
+func (b *Backfiller) cadence(coin string, fiat string) (time.Duration, time.Duration) {
+	var cadence = b.Cadence
+	if cadence <= 0 {
+		cadence = DefaultCadence
+	}
+
+	var resolution, ok = b.PairResolutions[btclists.Pair{Coin: coin, Fiat: fiat}]
+	if !ok {
+		resolution = b.Resolution
+	}
+	if resolution.Duration() > cadence {
+		cadence = resolution.Duration()
+	}
+
+	var tolerance = b.Tolerance
+	if tolerance <= 0 {
+		tolerance = cadence
+	}
+	return cadence, tolerance
+}
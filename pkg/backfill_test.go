@@ -0,0 +1,132 @@
+package pkg_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/influx6/btclists"
+	"github.com/influx6/btclists/pkg"
+)
+
+func TestBackfiller_Backfill(t *testing.T) {
+	var db = new(MockRateDB)
+	var market = new(MockCoinMarket)
+
+	var from = time.Date(2020, 4, 8, 14, 0, 0, 0, time.UTC)
+	var to = from.Add(5 * time.Minute)
+
+	var stored = []btclists.Rate{
+		{Date: from, Coin: COIN, Fiat: FIAT},
+		{Date: from.Add(time.Minute), Coin: COIN, Fiat: FIAT},
+		{Date: to, Coin: COIN, Fiat: FIAT},
+	}
+
+	var missing = []btclists.Rate{
+		{Date: from.Add(2 * time.Minute), Coin: COIN, Fiat: FIAT, Rate: decimal.NewFromFloat(7201.5)},
+		{Date: from.Add(3 * time.Minute), Coin: COIN, Fiat: FIAT, Rate: decimal.NewFromFloat(7202.5)},
+		{Date: from.Add(4 * time.Minute), Coin: COIN, Fiat: FIAT, Rate: decimal.NewFromFloat(7203.5)},
+	}
+
+	var requested []pkg.Gap
+	market.RangeFunc = func(ctx context.Context, coin string, fiat string, from time.Time, to time.Time, limit int) ([]btclists.Rate, error) {
+		require.Equal(t, btclists.LowPriority, btclists.PriorityFrom(ctx))
+		requested = append(requested, pkg.Gap{From: from, To: to})
+		return missing, nil
+	}
+
+	db.On("Range", COIN, FIAT, from, to).Return(stored, nil)
+	db.On("AddBatch", missing).Return(nil)
+
+	var backfiller = pkg.NewBackfiller(db, market)
+	var report, err = backfiller.Backfill(context.Background(), COIN, FIAT, from, to)
+	require.NoError(t, err)
+	require.Equal(t, 3, report.Fetched)
+	require.Len(t, report.Gaps, 1)
+	require.Equal(t, []pkg.Gap{{From: from.Add(time.Minute), To: to}}, requested)
+
+	db.AssertExpectations(t)
+}
+
+func TestBackfiller_Backfill_BudgetExhausted(t *testing.T) {
+	var db = new(MockRateDB)
+	var market = new(MockCoinMarket)
+
+	var from = time.Date(2020, 4, 8, 14, 0, 0, 0, time.UTC)
+	var to = from.Add(10 * time.Minute)
+
+	var calls int
+	market.RangeFunc = func(ctx context.Context, coin string, fiat string, from time.Time, to time.Time, limit int) ([]btclists.Rate, error) {
+		calls++
+		require.Equal(t, btclists.LowPriority, btclists.PriorityFrom(ctx))
+		return nil, btclists.ErrBudgetExhausted
+	}
+
+	db.On("Range", COIN, FIAT, from, to).Return([]btclists.Rate{
+		{Date: from.Add(5 * time.Minute), Coin: COIN, Fiat: FIAT},
+	}, nil)
+
+	var backfiller = pkg.NewBackfiller(db, market)
+
+	var report, err = backfiller.Backfill(context.Background(), COIN, FIAT, from, to)
+	require.Equal(t, btclists.ErrBudgetExhausted, err)
+	require.Len(t, report.Gaps, 2)
+	require.Equal(t, 1, calls)
+	db.AssertNotCalled(t, "AddBatch", mock.Anything)
+}
+
+func TestBackfiller_Backfill_Resolution(t *testing.T) {
+	var db = pkg.NewMemoryDB()
+	var market = new(MockCoinMarket)
+
+	var from = time.Date(2020, 4, 8, 0, 0, 0, 0, time.UTC)
+	var to = from.Add(10 * time.Hour)
+
+	var hourly = func(first int, last int) []btclists.Rate {
+		var rates []btclists.Rate
+		for hour := first; hour <= last; hour++ {
+			rates = append(rates, btclists.Rate{
+				Date:       from.Add(time.Duration(hour) * time.Hour),
+				Coin:       COIN,
+				Fiat:       FIAT,
+				Rate:       decimal.NewFromInt(int64(hour)),
+				Resolution: btclists.Resolution1Hour,
+			})
+		}
+		return rates
+	}
+	require.NoError(t, db.AddBatch(context.Background(), hourly(0, 6)))
+
+	var requested []pkg.Gap
+	market.RangeFunc = func(ctx context.Context, coin string, fiat string, from time.Time, to time.Time, limit int) ([]btclists.Rate, error) {
+		requested = append(requested, pkg.Gap{From: from, To: to})
+		return hourly(7, 10), nil
+	}
+
+	var backfiller = pkg.NewBackfiller(db, market)
+	backfiller.Resolution = btclists.Resolution2Min
+	backfiller.PairResolutions = map[btclists.Pair]btclists.Resolution{
+		{Coin: COIN, Fiat: FIAT}: btclists.Resolution1Hour,
+	}
+
+	var report, err = backfiller.Backfill(context.Background(), COIN, FIAT, from, to)
+	require.NoError(t, err)
+	require.Equal(t, 4, report.Fetched)
+	require.Equal(t, []pkg.Gap{{From: from.Add(6 * time.Hour), To: to}}, requested)
+
+	t.Logf("Should find no gaps once rates are stored at the resolution of pair")
+	{
+		var report, err = backfiller.Backfill(context.Background(), COIN, FIAT, from, to)
+		require.NoError(t, err)
+		require.Empty(t, report.Gaps)
+		require.Len(t, requested, 1)
+
+		var gaps, gapsErr = backfiller.Gaps(context.Background(), COIN, FIAT, from, to)
+		require.NoError(t, gapsErr)
+		require.Empty(t, gaps)
+	}
+}
@@ -6,8 +6,10 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
 	"net/url"
+	"strconv"
 	"time"
 
 	"github.com/shopspring/decimal"
@@ -17,9 +19,12 @@ import (
 
 const (
 	MaxLimit          = 5000
-	PeriodInterval    = "2MIN"
+	PeriodInterval    = btclists.Resolution2Min
 	CoinApiProdURL    = "https://rest.coinapi.io"
 	CoinApiSandboxURL = "https://rest-sandbox.coinapi.io"
+
+	// CoinAPIProvider is the name CoinAPI budgets are stored under.
+	CoinAPIProvider = "coinapi"
 )
 
 var (
@@ -56,6 +61,23 @@ type CandleSticks struct {
 	TradesCount  uint32          `json:"trades_count"`
 }
 
+// Candle returns candle stick as a btclists.Candle for giving pair.
+func (c CandleSticks) Candle(coin string, fiat string, resolution btclists.Resolution) btclists.Candle {
+	return btclists.Candle{
+		Coin:       coin,
+		Fiat:       fiat,
+		Resolution: resolution,
+		Start:      c.Start.UTC(),
+		End:        c.End.UTC(),
+		Open:       c.PriceOpen,
+		High:       c.PriceHigh,
+		Low:        c.PriceLow,
+		Close:      c.PriceClose,
+		Volume:     c.VolumeTraded,
+		Trades:     int64(c.TradesCount),
+	}
+}
+
 // CoinAPI wraps out necessary decorate to make http requests to the
 // CoinAPI API for retrieving crypto-currency rates.
 //
@@ -65,10 +87,24 @@ type CandleSticks struct {
 // We could consider CoinAPI or other providers for more precision data.
 // For example, CoinAPI provides endpoints for retrieving on a per minute, hour or more
 // base for candle-sticks data values on the rate changes for a giving coin.
+//
+// The candle resolution used for history is picked in order of: the resolution set on
+// the request context (see btclists.WithResolution), the resolution set for the pair in
+// PairResolutions, Resolution and finally PeriodInterval.
+//
+// Where Budgets is set, the remaining request credits reported by CoinAPI with each
+// response are stored into it under CoinAPIProvider. Where Guard is set, it is consulted
+// before every request, refusing low priority requests once CoinAPI credits run low.
 type CoinAPI struct {
 	URL    string
 	Token  string
 	Client btclists.Client
+
+	Resolution      btclists.Resolution
+	PairResolutions map[btclists.Pair]btclists.Resolution
+
+	Budgets btclists.BudgetStore
+	Guard   *CreditGuard
 }
 
 func NewCoinAPI(url string, token string, client btclists.Client) *CoinAPI {
@@ -87,6 +123,10 @@ func (c *CoinAPI) Rate(ctx context.Context, coin string, fiat string, time time.
 		query.Set("time", time.Format(btclists.DateTimeFormat))
 	}
 
+	if guardErr := c.allow(ctx); guardErr != nil {
+		return rate, guardErr
+	}
+
 	var req, err = buildRequest(ctx, c.Token, "GET", path, query, nil)
 	if err != nil {
 		return rate, err
@@ -94,11 +134,13 @@ func (c *CoinAPI) Rate(ctx context.Context, coin string, fiat string, time time.
 
 	var res, resErr = c.Client.Do(req)
 	if resErr != nil {
-		return rate, err
+		return rate, resErr
 	}
 
 	defer res.Body.Close()
 
+	c.recordBudget(ctx, res.Header)
+
 	switch res.StatusCode {
 	case http.StatusBadRequest:
 		return rate, ErrBadRequest
@@ -120,7 +162,7 @@ func (c *CoinAPI) Rate(ctx context.Context, coin string, fiat string, time time.
 	}
 
 	if validErr := exchange.Valid(); validErr != nil {
-		return rate, err
+		return rate, validErr
 	}
 
 	rate.Rate = exchange.Rate
@@ -138,13 +180,33 @@ func (c *CoinAPI) RangeFrom(ctx context.Context, coin string, fiat string, from
 // Range retrieves all rates for giving coin for giving fiat and crypto-coin pair from provided
 // time range (if to is not provided, then till limit requested). Note CoinAPI has a 100,000 record
 // limit.
+//
+// Rates are the closing prices of the candles returned by CoinAPI.Candles.
 func (c *CoinAPI) Range(ctx context.Context, coin string, fiat string, from time.Time, to time.Time, limit int) ([]btclists.Rate, error) {
+	var candles, err = c.Candles(ctx, coin, fiat, from, to, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	var rates = make([]btclists.Rate, 0, len(candles))
+	for _, candle := range candles {
+		rates = append(rates, candle.Rate())
+	}
+
+	return rates, nil
+}
+
+// Candles retrieves all OHLCV candles for giving fiat and crypto-coin pair from provided
+// time range (if to is not provided, then till limit requested).
+func (c *CoinAPI) Candles(ctx context.Context, coin string, fiat string, from time.Time, to time.Time, limit int) ([]btclists.Candle, error) {
 	if from.IsZero() {
 		return nil, errors.New("invalid 'from' time range provided")
 	}
 
+	var resolution = c.ResolutionFor(ctx, coin, fiat)
+
 	var query = url.Values{}
-	query.Set("period_id", PeriodInterval)
+	query.Set("period_id", string(resolution))
 	query.Set("include_empty_items", "false")
 	query.Set("limit", fmt.Sprintf("%d", limit))
 	query.Set("time_start", from.Format(btclists.DateTimeFormat))
@@ -153,6 +215,10 @@ func (c *CoinAPI) Range(ctx context.Context, coin string, fiat string, from time
 		query.Set("time_end", to.Format(btclists.DateTimeFormat))
 	}
 
+	if guardErr := c.allow(ctx); guardErr != nil {
+		return nil, guardErr
+	}
+
 	var path = fmt.Sprintf("%s/v1/ohlcv/%s/%s/history", c.URL, coin, fiat)
 	var req, err = buildRequest(ctx, c.Token, "GET", path, query, nil)
 	if err != nil {
@@ -161,14 +227,16 @@ func (c *CoinAPI) Range(ctx context.Context, coin string, fiat string, from time
 
 	var res, resErr = c.Client.Do(req)
 	if resErr != nil {
-		return nil, err
+		return nil, resErr
 	}
 
 	defer res.Body.Close()
 
+	c.recordBudget(ctx, res.Header)
+
 	switch res.StatusCode {
 	case http.StatusBadRequest:
-		return nil, errors.New("bad request")
+		return nil, ErrBadRequest
 	case 429:
 		return nil, btclists.ErrLimitReached
 	case http.StatusUnauthorized:
@@ -186,17 +254,73 @@ func (c *CoinAPI) Range(ctx context.Context, coin string, fiat string, from time
 		return nil, err
 	}
 
-	var rates = make([]btclists.Rate, 0, len(sticks))
-	for _, candle := range sticks {
-		var rate btclists.Rate
-		rate.Rate = candle.PriceClose
-		rate.Date = candle.End
-		rate.Fiat = fiat
-		rate.Coin = coin
-		rates = append(rates, rate)
+	var candles = make([]btclists.Candle, 0, len(sticks))
+	for _, stick := range sticks {
+		candles = append(candles, stick.Candle(coin, fiat, resolution))
 	}
 
-	return rates, nil
+	return candles, nil
+}
+
+// ResolutionFor returns the candle resolution to be used for history requests of
+// giving pair.
+func (c *CoinAPI) ResolutionFor(ctx context.Context, coin string, fiat string) btclists.Resolution {
+	if resolution, ok := btclists.ResolutionFrom(ctx); ok {
+		return resolution
+	}
+	if resolution, ok := c.PairResolutions[btclists.Pair{Coin: coin, Fiat: fiat}]; ok {
+		return resolution
+	}
+	if c.Resolution != btclists.Spot {
+		return c.Resolution
+	}
+	return PeriodInterval
+}
+
+// allow returns an error if request with ctx is to be refused by Guard.
+func (c *CoinAPI) allow(ctx context.Context) error {
+	if c.Guard == nil {
+		return nil
+	}
+	return c.Guard.Allow(ctx)
+}
+
+// recordBudget stores the request credits budget reported by CoinAPI
+// with response headers, if any.
+func (c *CoinAPI) recordBudget(ctx context.Context, header http.Header) {
+	if c.Budgets == nil {
+		return
+	}
+
+	var budget, ok = coinAPIBudget(header)
+	if !ok {
+		return
+	}
+
+	if err := c.Budgets.UpdateBudget(ctx, budget); err != nil {
+		log.Printf("[BTC Listings] | [ERROR] | Failed to update CoinAPI budget | %s\n", err)
+	}
+}
+
+// coinAPIBudget returns budget from the X-RateLimit-* headers of a CoinAPI response.
+func coinAPIBudget(header http.Header) (btclists.Budget, bool) {
+	var budget = btclists.Budget{Provider: CoinAPIProvider, UpdatedAt: time.Now().UTC()}
+
+	var remaining, remainingErr = strconv.ParseInt(header.Get("X-RateLimit-Remaining"), 10, 64)
+	if remainingErr != nil {
+		return budget, false
+	}
+	budget.Remaining = remaining
+
+	if limit, err := strconv.ParseInt(header.Get("X-RateLimit-Limit"), 10, 64); err == nil {
+		budget.Limit = limit
+	}
+
+	if reset, err := time.Parse(time.RFC3339Nano, header.Get("X-RateLimit-Reset")); err == nil {
+		budget.ResetAt = reset.UTC()
+	}
+
+	return budget, true
 }
 
 func buildRequest(ctx context.Context, token string, method string, path string, queries url.Values, body io.Reader) (*http.Request, error) {
@@ -208,3 +332,38 @@ func buildRequest(ctx context.Context, token string, method string, path string,
 	req.Header.Add("X-CoinAPI-Key", token)
 	return req, nil
 }
+
+// CreditGuard refuses low priority calls (see btclists.WithPriority) to a provider
+// whose remaining request credits have fallen below Reserve, keeping the rest
+// of the budget for calls serving users.
+type CreditGuard struct {
+	Budgets  btclists.BudgetStore
+	Provider string
+	Reserve  int64
+}
+
+// Allow returns btclists.ErrBudgetExhausted if call with ctx is of low priority and
+// provider's remaining credits are below Reserve.
+//
+// Calls are allowed where budget of provider is unknown or it's reset time has passed.
+func (g *CreditGuard) Allow(ctx context.Context) error {
+	if btclists.PriorityFrom(ctx) != btclists.LowPriority {
+		return nil
+	}
+
+	var budget, err = g.Budgets.Budget(ctx, g.Provider)
+	if err != nil {
+		log.Printf("[BTC Listings] | [ERROR] | Unable to retrieve budget, allowing call | %s | %s\n", g.Provider, err)
+		return nil
+	}
+
+	if !budget.ResetAt.IsZero() && time.Now().After(budget.ResetAt) {
+		return nil
+	}
+
+	if budget.Remaining < g.Reserve {
+		log.Printf("[BTC Listings] | [INFO] | Refusing low priority call, budget below reserve | %s | %d\n", g.Provider, budget.Remaining)
+		return btclists.ErrBudgetExhausted
+	}
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"log"
+	"sync"
 	"time"
 
 	"github.com/shopspring/decimal"
@@ -12,9 +13,14 @@ import (
 )
 
 var (
-	zeroTime                 = time.Time{}
-	ErrDBError               = errors.New("db error occurred")
-	_          CoinMarketAPI = (*CoinAPI)(nil)
+	zeroTime                   = time.Time{}
+	ErrDBError                 = errors.New("db error occurred")
+	_          CoinMarketAPI   = (*CoinAPI)(nil)
+	_          CandleMarketAPI = (*CoinAPI)(nil)
+
+	_ btclists.CoverageService        = (*CoinRatingService)(nil)
+	_ btclists.WeightedAverageService = (*CoinRatingService)(nil)
+	_ btclists.StatsService           = (*CoinRatingService)(nil)
 )
 
 // CoinMarketAPI exposes the minimal contract desirable for an exchange service api.
@@ -29,6 +35,16 @@ type CoinMarketAPI interface {
 	Range(ctx context.Context, coin string, fiat string, from time.Time, to time.Time, limit int) ([]btclists.Rate, error)
 }
 
+// CandleMarketAPI exposes the contract for an exchange service api able to provide full
+// OHLCV candles for a pair.
+//
+// It is kept apart from CoinMarketAPI, as not all exchange services provide candles, where
+// a CoinMarketAPI also implements CandleMarketAPI, CoinRatingService will store the
+// full candles it pulls rather than only their closing price.
+type CandleMarketAPI interface {
+	Candles(ctx context.Context, coin string, fiat string, from time.Time, to time.Time, limit int) ([]btclists.Candle, error)
+}
+
 // PeriodicRatingUpdates boots up a loop to periodically pull latest ratings from
 // provided exchange service, adding new records to provided db.
 func PeriodicRatingUpdate(ctx context.Context, tdb btclists.RatesDB, exchange CoinMarketAPI, coin string, fiat string) {
@@ -70,6 +86,12 @@ type CoinRatingService struct {
 	exchange CoinMarketAPI
 	tdb      btclists.RatesDB
 	ctx      context.Context
+
+	resolution      btclists.Resolution
+	pairResolutions map[btclists.Pair]btclists.Resolution
+
+	referenceMu sync.RWMutex
+	reference   Pairs
 }
 
 func NewCoinRatingService(ctx context.Context, db btclists.RatesDB, exchange CoinMarketAPI) *CoinRatingService {
@@ -80,6 +102,36 @@ func NewCoinRatingService(ctx context.Context, db btclists.RatesDB, exchange Coi
 	}
 }
 
+// SetResolutions sets the candle resolution candles are served at when none is set on
+// the request context, resolution being used for pairs missing from pairResolutions. It
+// should match the resolution history is pulled at from the exchange service.
+func (t *CoinRatingService) SetResolutions(resolution btclists.Resolution, pairResolutions map[btclists.Pair]btclists.Resolution) {
+	t.resolution = resolution
+	t.pairResolutions = pairResolutions
+}
+
+// AddReferencePairs marks provided pairs as reference pairs (e.g EUR/USD), whose rates
+// are only served from db as no exchange service provides them. It is safe to add
+// pairs while requests are served.
+func (t *CoinRatingService) AddReferencePairs(pairs Pairs) {
+	t.referenceMu.Lock()
+	defer t.referenceMu.Unlock()
+
+	if t.reference == nil {
+		t.reference = Pairs{}
+	}
+	for pair := range pairs {
+		t.reference.Add(pair.Coin, pair.Fiat)
+	}
+}
+
+// isReference returns true if pair was added with AddReferencePairs.
+func (t *CoinRatingService) isReference(coin string, fiat string) bool {
+	t.referenceMu.RLock()
+	defer t.referenceMu.RUnlock()
+	return t.reference.Has(coin, fiat)
+}
+
 // Latest implements RateService.Latest method, fulfilling RateService contract.
 func (t *CoinRatingService) Latest(ctx context.Context, coin string, fiat string) (btclists.Rate, error) {
 	var latest, err = t.tdb.Latest(ctx, coin, fiat)
@@ -93,11 +145,15 @@ func (t *CoinRatingService) Latest(ctx context.Context, coin string, fiat string
 	if err != nil {
 		log.Printf("[BTC Listings] | [ERROR] | DB just said no record, find out why | %s\n", err)
 
+		if t.isReference(coin, fiat) {
+			return btclists.Rate{}, btclists.ErrRateNotFound
+		}
+
 		// retrieve latest ratings pair for current time.
 		latest, err = t.exchange.Rate(ctx, coin, fiat, zeroTime)
 		if err != nil {
 			log.Printf("[BTC Listings] | [ERROR] | Failed to update latest rating  | %s\n", err)
-			return btclists.Rate{}, nil
+			return btclists.Rate{}, err
 		}
 
 		log.Printf("[BTC Listings] | [INFO] | Retreive latest from API at | %s | %s\n", latest.Date, latest.Rate)
@@ -136,6 +192,12 @@ func (t *CoinRatingService) At(ctx context.Context, coin string, fiat string, ts
 	//	 within this window. But this also needs to be done with consideration to our exchange rate data hold policy.
 	//
 	// For now, we will keep it simple, so option 1.
+	//
+	// Reference pairs are not served by the API, so there is nothing to fallback to.
+	if t.isReference(coin, fiat) {
+		return btclists.Rate{}, btclists.ErrRateNotFound
+	}
+
 	var ratingFromAPI, apiErr = t.exchange.Rate(ctx, coin, fiat, ts)
 	if apiErr != nil {
 		log.Printf("[BTC Listings] | [ERROR] | API has failed us | %s\n", err)
@@ -157,116 +219,358 @@ func (t *CoinRatingService) At(ctx context.Context, coin string, fiat string, ts
 /* AverageForRange implements RatingsAverageServe interface.
 *
 * NOTE to reviewer:
-* If we can't find suitable records in db, then API will be used, else we pull
-* available records in DB, even if lagging behind latest from API.
-*
-* A possible alternative:
-*
-* If we wish to ensure consistent accurate response, then we can check if time
-* range completely exists in db, by checking `from` against latest and oldest record time,
-* if below oldest record time then check pull from API. If above latest record time,
-* then pull from API, else if within range, just use db record.
+* Stored rates for the range are compared against the expected cadence, where parts of
+* the range are not covered (see FindGaps), only those parts are pulled from the API and
+* stored before the db averages the range. This way a week long range holding a single
+* stored rate is not served as a week average.
 *
+* Where the API fails and we have some stored rates, the average of stored rates is
+* served, see CoverageForRange for how much of range they cover.
 *
 * Note: Average will be returned if it was pulled from the API even if an error occurred
-* whilst saving retrieved ratings into DB. Caller should decide on how to proceed.
+* whilst saving retrieved ratings into DB, in which case rates are averaged weighted by
+* the time they hold as pulled rates may not share the cadence of stored rates.
+* Caller should decide on how to proceed.
 *
  */
 func (t *CoinRatingService) AverageForRange(ctx context.Context, coin string, fiat string, from time.Time, to time.Time) (decimal.Decimal, error) {
 	var average decimal.Decimal
 
-	// Do we have have any records for this range ?
-	var total, terr = t.tdb.CountForRange(ctx, coin, fiat, from, to)
-	if terr != nil {
-		// fail fast. It could be many things, but we won't mitigate
-		// these here, let call fail and force new call by caller.
-		return average, terr
+	var scan, fetched, err = t.fillGaps(ctx, coin, fiat, from, to)
+	if err != nil && err != ErrDBError {
+		return average, err
 	}
 
-	// Pull from API and calculate average
-	if total == 0 {
-		var results, apiErr = t.exchange.Range(ctx, coin, fiat, from, to, MaxLimit)
-		if apiErr != nil {
-			log.Printf("[BTC Listings] | [ERROR] | API fails us | %s\n", apiErr)
-			return average, apiErr
-		}
-
-		if len(results) == 0 {
-			log.Println("[BTC Listings] | [INFO] | No records returned from API")
-			return average, nil
-		}
+	if scan.Count == 0 && len(fetched) == 0 {
+		log.Println("[BTC Listings] | [INFO] | No records found for range")
+		return average, nil
+	}
 
-		for _, result := range results {
-			average = average.Add(result.Rate)
+	// Pulled rates are not stored, so average them along stored rates ourselves.
+	if err == ErrDBError {
+		var results, rangeErr = t.storedWith(ctx, coin, fiat, from, to, fetched)
+		if rangeErr != nil {
+			return average, rangeErr
 		}
 
-		average = average.Div(decimal.NewFromInt(int64(len(results))))
-
-		var dbSaveErr error
-		if dbSaveErr = t.tdb.AddBatch(ctx, results); dbSaveErr != nil {
-			log.Printf("[BTC Listings] | [CRITICAL] | DB failures are not good | %s\n", dbSaveErr)
+		var weighted, avgErr = TimeWeightedAverage(results, from, to)
+		if avgErr != nil {
+			return weighted, avgErr
 		}
 
-		return average, dbSaveErr
+		log.Printf("[BTC Listings] | [INFO] | Retreive average | %s | %s | %s\n", from, to, weighted)
+		return weighted, err
 	}
 
-	var err error
 	average, err = t.tdb.AverageForRange(ctx, coin, fiat, from, to)
 	if err != nil {
 		log.Printf("[BTC Listings] | [ERROR] | Failed to retreive average | %s\n", err)
+		return average, err
 	}
 
 	log.Printf("[BTC Listings] | [INFO] | Retreive average | %s | %s | %s\n", from, to, average)
+	return average, nil
+}
+
+// TimeWeightedAverage implements btclists.WeightedAverageService interface.
+//
+// Follows the same rules as CoinRatingService.AverageForRange, where parts of time range
+// are not covered by db, they are pulled from API before averaging.
+func (t *CoinRatingService) TimeWeightedAverage(ctx context.Context, coin string, fiat string, from time.Time, to time.Time) (decimal.Decimal, error) {
+	var results, err = t.Range(ctx, coin, fiat, from, to)
+	if err != nil && err != ErrDBError {
+		return decimal.Decimal{}, err
+	}
+
+	var average, avgErr = TimeWeightedAverage(results, from, to)
+	if avgErr != nil {
+		return average, avgErr
+	}
+
+	log.Printf("[BTC Listings] | [INFO] | Retreive time-weighted average | %s | %s | %s\n", from, to, average)
 	return average, err
 }
 
+// VolumeWeightedAverage implements btclists.WeightedAverageService interface.
+//
+// Volume is taken from candles as served by CoinRatingService.Candles, hence of a single
+// resolution so volume is not counted twice by candles of overlapping periods. Where exchange
+// service is not able to provide candles, only stored candles are used.
+func (t *CoinRatingService) VolumeWeightedAverage(ctx context.Context, coin string, fiat string, from time.Time, to time.Time) (decimal.Decimal, error) {
+	var candles, err = t.Candles(ctx, coin, fiat, from, to)
+	if err != nil && len(candles) == 0 {
+		return decimal.Decimal{}, err
+	}
+
+	var average, avgErr = VolumeWeightedAverage(candles)
+	if avgErr != nil {
+		return average, avgErr
+	}
+
+	log.Printf("[BTC Listings] | [INFO] | Retreive volume-weighted average | %s | %s | %s\n", from, to, average)
+	return average, err
+}
+
+// StatsForRange implements btclists.StatsService interface.
+//
+// Follows the same rules as CoinRatingService.AverageForRange, where parts of time range
+// are not covered by db, they are pulled from API and stored before statistics are computed by db.
+func (t *CoinRatingService) StatsForRange(ctx context.Context, coin string, fiat string, from time.Time, to time.Time, percentiles []float64) (btclists.Stats, error) {
+	if _, _, err := t.fillGaps(ctx, coin, fiat, from, to); err != nil && err != ErrDBError {
+		return btclists.Stats{}, err
+	}
+
+	var stats, err = t.tdb.StatsForRange(ctx, coin, fiat, from, to, percentiles)
+	if err != nil {
+		log.Printf("[BTC Listings] | [ERROR] | Failed to retreive stats | %s\n", err)
+	}
+	return stats, err
+}
+
 /* Range implements RateService.Range method, fulfilling RateService contract.
 *
 *  We are enforcing certain rules to govern how range should work:
 *
-*  1. If time range is not in db, this will occur if time range is too far back
-*	or in the future, if so then we pull from API and store new info, serving
-*	API results.
+*  1. Parts of time range not covered by db, this will occur if time range is too far back,
+*	in the future or updates were missed, are pulled from API, stored and merged with
+*	stored rates.
 *
-*  2. If time range is in db, even if not totally accurate to existing records in API
-*	we will serve those and allow our service to catch up.
+*  2. If the API fails, we will serve what we have stored and allow our service to catch up.
 *
 * Note: Results may be returned if it was pulled from the API and the returned error was
 * possibly a DB insert failure. Caller should decide on how to proceed.
 *
 * */
 func (t *CoinRatingService) Range(ctx context.Context, coin string, fiat string, from time.Time, to time.Time) ([]btclists.Rate, error) {
-	var results []btclists.Rate
+	var _, fetched, err = t.fillGaps(ctx, coin, fiat, from, to)
+	if err != nil && err != ErrDBError {
+		log.Printf("[BTC Listings] | [ERROR] | failed to retrieve result | %s\n", err)
+		return nil, err
+	}
+
+	var results, rangeErr = t.storedWith(ctx, coin, fiat, from, to, fetched)
+	if rangeErr != nil {
+		log.Printf("[BTC Listings] | [ERROR] | failed to retrieve result | %s\n", rangeErr)
+		return nil, rangeErr
+	}
+	return results, err
+}
+
+// RangePage implements btclists.RatePager interface.
+//
+// Follows the same rules as CoinRatingService.Range, where parts of time range are not in db,
+// we pull them from API and store new info when serving the first page, but pages are always
+// served from the db ensuring cursors remain consistent between calls.
+func (t *CoinRatingService) RangePage(ctx context.Context, coin string, fiat string, from time.Time, to time.Time, page btclists.Page) ([]btclists.Rate, error) {
+	if page.After.IsZero() {
+		var _, _, fillErr = t.fillGaps(ctx, coin, fiat, from, to)
+		if fillErr != nil && fillErr != ErrDBError {
+			return nil, fillErr
+		}
+	}
+
+	var results, err = t.tdb.RangePage(ctx, coin, fiat, from, to, page)
+	if err != nil {
+		log.Printf("[BTC Listings] | [ERROR] | failed to retrieve result | %s\n", err)
+	}
+	return results, err
+}
 
-	var total, terr = t.tdb.CountForRange(ctx, coin, fiat, from, to)
-	if terr != nil {
+// CoverageForRange implements btclists.CoverageService interface, returning the ratio
+// (0 to 1) of time range covered by stored rates.
+func (t *CoinRatingService) CoverageForRange(ctx context.Context, coin string, fiat string, from time.Time, to time.Time) (float64, error) {
+	var scan, err = t.scan(ctx, coin, fiat, from, to, t.cadenceFor(ctx, coin, fiat))
+	if err != nil {
+		log.Printf("[BTC Listings] | [ERROR] | failed to retrieve result | %s\n", err)
+		return 0, err
+	}
+	return scan.Coverage, nil
+}
+
+// scan returns the RangeScan of stored rates for time range, computed by db where it
+// implements RangeScanner, else from stored rates.
+func (t *CoinRatingService) scan(ctx context.Context, coin string, fiat string, from time.Time, to time.Time, cadence time.Duration) (RangeScan, error) {
+	if scanner, ok := t.tdb.(RangeScanner); ok {
+		return scanner.ScanRange(ctx, coin, fiat, from, to, cadence)
+	}
+
+	var stored, err = t.tdb.Range(ctx, coin, fiat, from, to)
+	if err != nil {
+		return RangeScan{}, err
+	}
+	return ScanRates(stored, from, to, cadence), nil
+}
+
+// storedWith returns stored rates for time range merged with provided fetched rates,
+// for when fetched rates could not be saved into db.
+func (t *CoinRatingService) storedWith(ctx context.Context, coin string, fiat string, from time.Time, to time.Time, fetched []btclists.Rate) ([]btclists.Rate, error) {
+	var stored, err = t.tdb.Range(ctx, coin, fiat, from, to)
+	if err != nil {
+		return nil, err
+	}
+	return mergeRates(stored, fetched), nil
+}
+
+// fillGaps pulls rates from API for parts of time range not covered by db and saves
+// them into db, returning the scan of stored rates prior to pulling and the rates pulled.
+//
+// Returns ErrDBError with pulled rates if saving them fails. Where API fails, no error
+// is returned if db has rates for time range, else the API error.
+func (t *CoinRatingService) fillGaps(ctx context.Context, coin string, fiat string, from time.Time, to time.Time) (RangeScan, []btclists.Rate, error) {
+	var cadence = t.cadenceFor(ctx, coin, fiat)
+	var scan, err = t.scan(ctx, coin, fiat, from, to, cadence)
+	if err != nil {
 		// fail fast. It could be many things, but we won't mitigate
 		// these here, let call fail and force new call by caller.
-		return results, terr
+		return scan, nil, err
+	}
+
+	// reference pairs are not served by the API, so their gaps can not be filled.
+	if len(scan.Gaps) == 0 || t.isReference(coin, fiat) {
+		return scan, nil, nil
 	}
 
-	// if we have no records for said time range, then pull directly from API
-	// and serve that as results after saving.
-	if total == 0 {
-		var apiErr error
-		results, apiErr = t.exchange.Range(ctx, coin, fiat, from, to, MaxLimit)
+	var fetched []btclists.Rate
+	for _, gap := range scan.Gaps {
+		var results, apiErr = t.fetchRange(ctx, coin, fiat, gap.From, gap.To)
 		if apiErr != nil {
 			log.Printf("[BTC Listings] | [ERROR] | API fails us | %s\n", apiErr)
-			return results, apiErr
+			if scan.Count == 0 && len(fetched) == 0 {
+				return scan, nil, apiErr
+			}
+			continue
 		}
+		fetched = append(fetched, results...)
+	}
 
-		if dbSaveErr := t.tdb.AddBatch(ctx, results); dbSaveErr != nil {
-			log.Printf("[BTC Listings] | [CRITICAL] | DB failures are not good | %s\n", dbSaveErr)
-			return results, dbSaveErr
+	if len(fetched) == 0 {
+		return scan, nil, nil
+	}
+
+	log.Printf("[BTC Listings] | [INFO] | Filled gaps in range | %s | %s | gaps: %d | fetched: %d | coverage: %.2f\n", from, to, len(scan.Gaps), len(fetched), scan.Coverage)
+
+	if dbSaveErr := t.tdb.AddBatch(ctx, fetched); dbSaveErr != nil {
+		log.Printf("[BTC Listings] | [CRITICAL] | DB failures are not good | %s\n", dbSaveErr)
+		return scan, fetched, ErrDBError
+	}
+
+	return scan, fetched, nil
+}
+
+// cadenceFor returns expected time between rates of pair, being the resolution set on ctx
+// or with SetResolutions where coarser than DefaultCadence.
+func (t *CoinRatingService) cadenceFor(ctx context.Context, coin string, fiat string) time.Duration {
+	if resolution, ok := t.configuredResolution(ctx, coin, fiat); ok && resolution.Duration() > DefaultCadence {
+		return resolution.Duration()
+	}
+	return DefaultCadence
+}
+
+// Candles implements btclists.CandleService interface.
+//
+// Candles are served at a single resolution, the one set on ctx (see btclists.WithResolution)
+// else the one set for the pair (see SetResolutions). Parts of time range not covered by stored
+// candles are pulled from API and stored before being served, as done for rates, if the exchange
+// service is able to provide candles. Where API fails, stored candles are served if any.
+func (t *CoinRatingService) Candles(ctx context.Context, coin string, fiat string, from time.Time, to time.Time) ([]btclists.Candle, error) {
+	var resolution = t.resolutionFor(ctx, coin, fiat)
+	ctx = btclists.WithResolution(ctx, resolution)
+
+	var candles, err = t.tdb.Candles(ctx, coin, fiat, from, to)
+	if err != nil {
+		log.Printf("[BTC Listings] | [ERROR] | failed to retrieve candles | %s\n", err)
+		return nil, err
+	}
+
+	var candleAPI, hasCandles = t.exchange.(CandleMarketAPI)
+	if !hasCandles || t.isReference(coin, fiat) {
+		return candles, nil
+	}
+
+	var gaps = candleGaps(candles, from, to, resolution.Duration())
+	if len(gaps) == 0 {
+		return candles, nil
+	}
+
+	var fetched []btclists.Candle
+	for _, gap := range gaps {
+		var results, apiErr = candleAPI.Candles(ctx, coin, fiat, gap.From, gap.To, MaxLimit)
+		if apiErr == ErrCandlesUnsupported {
+			return candles, nil
 		}
+		if apiErr != nil {
+			log.Printf("[BTC Listings] | [ERROR] | API fails us | %s\n", apiErr)
+			if len(candles) == 0 && len(fetched) == 0 {
+				return nil, apiErr
+			}
+			continue
+		}
+		fetched = append(fetched, results...)
+	}
+
+	if len(fetched) == 0 {
+		return candles, nil
+	}
 
-		return results, nil
+	var merged = mergeCandles(candles, fetched, resolution)
+	log.Printf("[BTC Listings] | [INFO] | Filled candle gaps in range | %s | %s | %s | gaps: %d\n", from, to, resolution, len(gaps))
+
+	if dbSaveErr := t.tdb.AddCandles(ctx, fetched); dbSaveErr != nil {
+		log.Printf("[BTC Listings] | [CRITICAL] | DB failures are not good | %s\n", dbSaveErr)
+		return merged, dbSaveErr
 	}
 
-	var err error
-	results, err = t.tdb.Range(ctx, coin, fiat, from, to)
+	return merged, nil
+}
+
+// resolutionFor returns the candle resolution of pair, picked in order of: the resolution
+// set on ctx, the resolution set for the pair, the default resolution and finally PeriodInterval.
+func (t *CoinRatingService) resolutionFor(ctx context.Context, coin string, fiat string) btclists.Resolution {
+	if resolution, ok := t.configuredResolution(ctx, coin, fiat); ok {
+		return resolution
+	}
+	return PeriodInterval
+}
+
+// configuredResolution returns the candle resolution of pair as resolutionFor does, false
+// if none is set on ctx or with SetResolutions.
+func (t *CoinRatingService) configuredResolution(ctx context.Context, coin string, fiat string) (btclists.Resolution, bool) {
+	if resolution, ok := btclists.ResolutionFrom(ctx); ok {
+		return resolution, true
+	}
+	if resolution, ok := t.pairResolutions[btclists.Pair{Coin: coin, Fiat: fiat}]; ok {
+		return resolution, true
+	}
+	if t.resolution != btclists.Spot {
+		return t.resolution, true
+	}
+	return btclists.Spot, false
+}
+
+// fetchRange pulls rates for time range from API. Where exchange service provides
+// candles, the full candles are stored and their closing prices returned as rates,
+// so we are not paying for data we throw away.
+func (t *CoinRatingService) fetchRange(ctx context.Context, coin string, fiat string, from time.Time, to time.Time) ([]btclists.Rate, error) {
+	var candleAPI, hasCandles = t.exchange.(CandleMarketAPI)
+	if !hasCandles {
+		return t.exchange.Range(ctx, coin, fiat, from, to, MaxLimit)
+	}
+
+	var candles, err = candleAPI.Candles(ctx, coin, fiat, from, to, MaxLimit)
+	if err == ErrCandlesUnsupported {
+		return t.exchange.Range(ctx, coin, fiat, from, to, MaxLimit)
+	}
 	if err != nil {
-		log.Printf("[BTC Listings] | [ERROR] | failed to retrieve result | %s\n", err)
+		return nil, err
 	}
-	return results, err
+
+	if dbSaveErr := t.tdb.AddCandles(ctx, candles); dbSaveErr != nil {
+		log.Printf("[BTC Listings] | [CRITICAL] | Failed to save candles to db | %s\n", dbSaveErr)
+	}
+
+	var rates = make([]btclists.Rate, 0, len(candles))
+	for _, candle := range candles {
+		rates = append(rates, candle.Rate())
+	}
+	return rates, nil
 }
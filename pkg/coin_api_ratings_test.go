@@ -63,11 +63,31 @@ func (m *MockRateDB) Range(ctx context.Context, coin string, fiat string, from t
 	return result.Get(0).([]btclists.Rate), result.Error(1)
 }
 
+func (m *MockRateDB) RangePage(ctx context.Context, coin string, fiat string, from time.Time, to time.Time, page btclists.Page) ([]btclists.Rate, error) {
+	var result = m.Called(coin, fiat, from, to, page)
+	return result.Get(0).([]btclists.Rate), result.Error(1)
+}
+
+func (m *MockRateDB) AddCandles(ctx context.Context, candles []btclists.Candle) error {
+	var result = m.Called(candles)
+	return result.Error(0)
+}
+
+func (m *MockRateDB) Candles(ctx context.Context, coin string, fiat string, from time.Time, to time.Time) ([]btclists.Candle, error) {
+	var result = m.Called(coin, fiat, from, to)
+	return result.Get(0).([]btclists.Candle), result.Error(1)
+}
+
 func (m *MockRateDB) AverageForRange(ctx context.Context, coin string, fiat string, from time.Time, to time.Time) (decimal.Decimal, error) {
 	var result = m.Called(coin, fiat, from, to)
 	return result.Get(0).(decimal.Decimal), result.Error(1)
 }
 
+func (m *MockRateDB) StatsForRange(ctx context.Context, coin string, fiat string, from time.Time, to time.Time, percentiles []float64) (btclists.Stats, error) {
+	var result = m.Called(coin, fiat, from, to, percentiles)
+	return result.Get(0).(btclists.Stats), result.Error(1)
+}
+
 type MockCoinMarket struct {
 	RateFunc      func(ctx context.Context, coin string, fiat string, at time.Time) (btclists.Rate, error)
 	RangeFromFunc func(ctx context.Context, coin string, fiat string, from time.Time, limit int) ([]btclists.Rate, error)
@@ -86,6 +106,16 @@ func (c *MockCoinMarket) Range(ctx context.Context, coin string, fiat string, fr
 	return c.RangeFunc(ctx, coin, fiat, from, to, limit)
 }
 
+// MockCandleMarket extends MockCoinMarket with support for candles.
+type MockCandleMarket struct {
+	MockCoinMarket
+	CandlesFunc func(ctx context.Context, coin string, fiat string, from time.Time, to time.Time, limit int) ([]btclists.Candle, error)
+}
+
+func (c *MockCandleMarket) Candles(ctx context.Context, coin string, fiat string, from time.Time, to time.Time, limit int) ([]btclists.Candle, error) {
+	return c.CandlesFunc(ctx, coin, fiat, from, to, limit)
+}
+
 func TestNewCoinRatingService_Latest_ToAPI(t *testing.T) {
 	var db = new(MockRateDB)
 	var market = new(MockCoinMarket)
@@ -130,3 +160,388 @@ func TestNewCoinRatingService_Latest_ToDB(t *testing.T) {
 	require.False(t, calledAPI)
 	db.AssertExpectations(t)
 }
+
+func TestNewCoinRatingService_RangePage_ToAPI(t *testing.T) {
+	var db = new(MockRateDB)
+	var market = new(MockCoinMarket)
+
+	var calledAPI = false
+	market.RangeFunc = func(ctx context.Context, coin string, fiat string, from time.Time, to time.Time, limit int) ([]btclists.Rate, error) {
+		calledAPI = true
+		return []btclists.Rate{someRate}, nil
+	}
+
+	var service = pkg.NewCoinRatingService(context.Background(), db, market)
+
+	var page = btclists.Page{Limit: 10, Order: btclists.Ascending}
+	db.On("Range", COIN, FIAT, someTime, someTimeLater).Return([]btclists.Rate{}, nil)
+	db.On("AddBatch", []btclists.Rate{someRate}).Return(nil)
+	db.On("RangePage", COIN, FIAT, someTime, someTimeLater, page).Return([]btclists.Rate{someRate}, nil)
+
+	var results, resErr = service.RangePage(context.Background(), COIN, FIAT, someTime, someTimeLater, page)
+	require.NoError(t, resErr)
+	require.Equal(t, []btclists.Rate{someRate}, results)
+
+	require.True(t, calledAPI)
+	db.AssertExpectations(t)
+}
+
+func TestNewCoinRatingService_RangePage_ToDB(t *testing.T) {
+	var db = new(MockRateDB)
+	var market = new(MockCoinMarket)
+
+	var calledAPI = false
+	market.RangeFunc = func(ctx context.Context, coin string, fiat string, from time.Time, to time.Time, limit int) ([]btclists.Rate, error) {
+		calledAPI = true
+		return nil, nil
+	}
+
+	var service = pkg.NewCoinRatingService(context.Background(), db, market)
+
+	var until = someTime.Add(2 * time.Minute)
+	var stored = []btclists.Rate{
+		{Date: someTime, Coin: COIN, Fiat: FIAT},
+		{Date: someTime.Add(time.Minute), Coin: COIN, Fiat: FIAT},
+		{Date: until, Coin: COIN, Fiat: FIAT},
+	}
+
+	var page = btclists.Page{Limit: 10, Order: btclists.Descending}
+	db.On("Range", COIN, FIAT, someTime, until).Return(stored, nil)
+	db.On("RangePage", COIN, FIAT, someTime, until, page).Return([]btclists.Rate{someRate}, nil)
+
+	var results, resErr = service.RangePage(context.Background(), COIN, FIAT, someTime, until, page)
+	require.NoError(t, resErr)
+	require.Equal(t, []btclists.Rate{someRate}, results)
+
+	require.False(t, calledAPI)
+	db.AssertExpectations(t)
+}
+
+func TestNewCoinRatingService_Candles_ToAPI(t *testing.T) {
+	var db = new(MockRateDB)
+	var market = new(MockCandleMarket)
+
+	var candle = btclists.Candle{
+		Coin:       COIN,
+		Fiat:       FIAT,
+		Resolution: pkg.PeriodInterval,
+		Start:      someTime,
+		End:        someTime.Add(time.Minute * 2),
+		Open:       decimal.NewFromFloat(40),
+		High:       decimal.NewFromFloat(45),
+		Low:        decimal.NewFromFloat(39),
+		Close:      decimal.NewFromFloat(43.322),
+		Volume:     decimal.NewFromFloat(2.5),
+		Trades:     12,
+	}
+
+	var calledAPI = false
+	market.CandlesFunc = func(ctx context.Context, coin string, fiat string, from time.Time, to time.Time, limit int) ([]btclists.Candle, error) {
+		calledAPI = true
+		return []btclists.Candle{candle}, nil
+	}
+
+	var service = pkg.NewCoinRatingService(context.Background(), db, market)
+
+	db.On("Candles", COIN, FIAT, someTime, someTimeLater).Return([]btclists.Candle(nil), nil)
+	db.On("AddCandles", []btclists.Candle{candle}).Return(nil)
+
+	var results, resErr = service.Candles(context.Background(), COIN, FIAT, someTime, someTimeLater)
+	require.NoError(t, resErr)
+	require.Equal(t, []btclists.Candle{candle}, results)
+
+	require.True(t, calledAPI)
+	db.AssertExpectations(t)
+}
+
+func TestNewCoinRatingService_Candles_FillsGaps(t *testing.T) {
+	var db = pkg.NewMemoryDB()
+	var market = new(MockCandleMarket)
+
+	var start = time.Date(2020, 4, 8, 14, 0, 0, 0, time.UTC)
+	var until = start.Add(10 * time.Minute)
+	var candle = func(offset time.Duration, resolution btclists.Resolution, price int64, volume int64) btclists.Candle {
+		return btclists.Candle{
+			Coin:       COIN,
+			Fiat:       FIAT,
+			Resolution: resolution,
+			Start:      start.Add(offset),
+			End:        start.Add(offset + resolution.Duration()),
+			High:       decimal.NewFromInt(price),
+			Low:        decimal.NewFromInt(price),
+			Close:      decimal.NewFromInt(price),
+			Volume:     decimal.NewFromInt(volume),
+		}
+	}
+
+	require.NoError(t, db.AddCandles(context.Background(), []btclists.Candle{
+		candle(0, btclists.Resolution1Min, 10, 1),
+		candle(time.Minute, btclists.Resolution1Min, 10, 1),
+		candle(0, btclists.Resolution1Hour, 1000, 100),
+	}))
+
+	var requested [][]time.Time
+	market.CandlesFunc = func(ctx context.Context, coin string, fiat string, from time.Time, to time.Time, limit int) ([]btclists.Candle, error) {
+		var resolution, _ = btclists.ResolutionFrom(ctx)
+		require.Equal(t, btclists.Resolution1Min, resolution)
+
+		requested = append(requested, []time.Time{from, to})
+
+		var candles []btclists.Candle
+		for offset := 2 * time.Minute; offset <= 10*time.Minute; offset += time.Minute {
+			candles = append(candles, candle(offset, btclists.Resolution1Min, 20, 1))
+		}
+		return candles, nil
+	}
+
+	var service = pkg.NewCoinRatingService(context.Background(), db, market)
+	service.SetResolutions(btclists.Resolution2Min, map[btclists.Pair]btclists.Resolution{
+		{Coin: COIN, Fiat: FIAT}: btclists.Resolution1Min,
+	})
+
+	var candles, err = service.Candles(context.Background(), COIN, FIAT, start, until)
+	require.NoError(t, err)
+	require.Len(t, candles, 11)
+
+	t.Logf("Should only fetch candles missing after stored ones")
+	{
+		require.Equal(t, [][]time.Time{{start.Add(time.Minute), until}}, requested)
+	}
+
+	t.Logf("Should weight volume of candles of the pair's resolution only")
+	{
+		requested = nil
+
+		var average, avgErr = service.VolumeWeightedAverage(context.Background(), COIN, FIAT, start, until)
+		require.NoError(t, avgErr)
+		require.Equal(t, "18.1818181818181818", average.String())
+		require.Empty(t, requested)
+	}
+}
+
+func TestNewCoinRatingService_Range_StoresCandles(t *testing.T) {
+	var db = new(MockRateDB)
+	var market = new(MockCandleMarket)
+
+	var candle = btclists.Candle{
+		Coin:  COIN,
+		Fiat:  FIAT,
+		Start: someTime,
+		End:   someTime.Add(time.Minute * 2),
+		Close: decimal.NewFromFloat(43.322),
+	}
+
+	market.RangeFunc = func(ctx context.Context, coin string, fiat string, from time.Time, to time.Time, limit int) ([]btclists.Rate, error) {
+		t.Fatal("Range should not be called when candles are supported")
+		return nil, nil
+	}
+	market.CandlesFunc = func(ctx context.Context, coin string, fiat string, from time.Time, to time.Time, limit int) ([]btclists.Candle, error) {
+		return []btclists.Candle{candle}, nil
+	}
+
+	var service = pkg.NewCoinRatingService(context.Background(), db, market)
+
+	db.On("Range", COIN, FIAT, someTime, someTimeLater).Return([]btclists.Rate{}, nil)
+	db.On("AddCandles", []btclists.Candle{candle}).Return(nil)
+	db.On("AddBatch", []btclists.Rate{candle.Rate()}).Return(nil)
+
+	var results, resErr = service.Range(context.Background(), COIN, FIAT, someTime, someTimeLater)
+	require.NoError(t, resErr)
+	require.Equal(t, []btclists.Rate{candle.Rate()}, results)
+
+	db.AssertExpectations(t)
+}
+
+func TestNewCoinRatingService_AverageForRange_PartialCoverage(t *testing.T) {
+	var db = pkg.NewMemoryDB()
+	var market = new(MockCoinMarket)
+
+	var start = someTime.Truncate(time.Second)
+	var until = start.Add(5 * time.Minute)
+	require.NoError(t, db.AddBatch(context.Background(), []btclists.Rate{
+		{Date: until, Coin: COIN, Fiat: FIAT, Rate: decimal.NewFromInt(40)},
+		{Date: start, Coin: COIN, Fiat: FIAT, Rate: decimal.NewFromInt(10)},
+	}))
+
+	var missing = []btclists.Rate{
+		{Date: start, Coin: COIN, Fiat: FIAT, Rate: decimal.NewFromInt(10)},
+		{Date: start.Add(2 * time.Minute), Coin: COIN, Fiat: FIAT, Rate: decimal.NewFromInt(20)},
+		{Date: start.Add(4 * time.Minute), Coin: COIN, Fiat: FIAT, Rate: decimal.NewFromInt(30)},
+	}
+
+	var requested [][]time.Time
+	market.RangeFunc = func(ctx context.Context, coin string, fiat string, from time.Time, to time.Time, limit int) ([]btclists.Rate, error) {
+		requested = append(requested, []time.Time{from, to})
+		return missing, nil
+	}
+
+	var service = pkg.NewCoinRatingService(context.Background(), db, market)
+
+	var average, err = service.AverageForRange(context.Background(), COIN, FIAT, start, until)
+	require.NoError(t, err)
+	require.Equal(t, "25", average.String())
+
+	t.Logf("Should only fetch uncovered sub-range")
+	{
+		require.Equal(t, [][]time.Time{{start, until}}, requested)
+	}
+
+	t.Logf("Should store fetched rates before averaging")
+	{
+		var count, countErr = db.CountForRange(context.Background(), COIN, FIAT, start, until)
+		require.NoError(t, countErr)
+		require.Equal(t, 4, count)
+	}
+}
+
+func TestNewCoinRatingService_AverageForRange_Resolution(t *testing.T) {
+	var db = pkg.NewMemoryDB()
+	var market = new(MockCoinMarket)
+
+	var start = someTime.Truncate(time.Hour)
+	var until = start.Add(10 * time.Hour)
+
+	var hourly = func(from int, to int) []btclists.Rate {
+		var rates []btclists.Rate
+		for hour := from; hour <= to; hour++ {
+			rates = append(rates, btclists.Rate{
+				Date:       start.Add(time.Duration(hour) * time.Hour),
+				Coin:       COIN,
+				Fiat:       FIAT,
+				Rate:       decimal.NewFromInt(int64(hour)),
+				Resolution: btclists.Resolution1Hour,
+			})
+		}
+		return rates
+	}
+	require.NoError(t, db.AddBatch(context.Background(), hourly(0, 6)))
+
+	var calls int
+	market.RangeFunc = func(ctx context.Context, coin string, fiat string, from time.Time, to time.Time, limit int) ([]btclists.Rate, error) {
+		calls++
+		return hourly(7, 10), nil
+	}
+
+	var service = pkg.NewCoinRatingService(context.Background(), db, market)
+	service.SetResolutions(btclists.Resolution1Hour, nil)
+
+	var _, err = service.AverageForRange(context.Background(), COIN, FIAT, start, until)
+	require.NoError(t, err)
+	require.Equal(t, 1, calls)
+
+	t.Logf("Should not fetch rates again once range is covered at the configured resolution")
+	{
+		var _, err = service.AverageForRange(context.Background(), COIN, FIAT, start, until)
+		require.NoError(t, err)
+		require.Equal(t, 1, calls)
+	}
+}
+
+func TestNewCoinRatingService_AverageForRange_DBFailure(t *testing.T) {
+	var db = new(MockRateDB)
+	var market = new(MockCoinMarket)
+
+	var until = someTime.Add(5 * time.Minute)
+	var stored = []btclists.Rate{
+		{Date: until, Coin: COIN, Fiat: FIAT, Rate: decimal.NewFromInt(40)},
+		{Date: someTime, Coin: COIN, Fiat: FIAT, Rate: decimal.NewFromInt(10)},
+	}
+
+	var missing = []btclists.Rate{
+		{Date: someTime.Add(2 * time.Minute), Coin: COIN, Fiat: FIAT, Rate: decimal.NewFromInt(20)},
+		{Date: someTime.Add(4 * time.Minute), Coin: COIN, Fiat: FIAT, Rate: decimal.NewFromInt(30)},
+	}
+
+	market.RangeFunc = func(ctx context.Context, coin string, fiat string, from time.Time, to time.Time, limit int) ([]btclists.Rate, error) {
+		return missing, nil
+	}
+
+	var service = pkg.NewCoinRatingService(context.Background(), db, market)
+
+	db.On("Range", COIN, FIAT, someTime, until).Return(stored, nil)
+	db.On("AddBatch", missing).Return(errors.New("connection refused"))
+
+	t.Logf("Should weight stored and fetched rates by the time they hold")
+	{
+		var average, err = service.AverageForRange(context.Background(), COIN, FIAT, someTime, until)
+		require.Equal(t, pkg.ErrDBError, err)
+		require.Equal(t, "18", average.String())
+	}
+
+	db.AssertExpectations(t)
+}
+
+func TestNewCoinRatingService_CoverageForRange(t *testing.T) {
+	var db = new(MockRateDB)
+	var market = new(MockCoinMarket)
+
+	var until = someTime.Add(4 * time.Minute)
+	db.On("Range", COIN, FIAT, someTime, until).Return([]btclists.Rate{
+		{Date: someTime, Coin: COIN, Fiat: FIAT},
+		{Date: someTime.Add(time.Minute), Coin: COIN, Fiat: FIAT},
+	}, nil)
+
+	var service = pkg.NewCoinRatingService(context.Background(), db, market)
+
+	var coverage, err = service.CoverageForRange(context.Background(), COIN, FIAT, someTime, until)
+	require.NoError(t, err)
+	require.Equal(t, 0.5, coverage)
+
+	db.AssertExpectations(t)
+}
+
+func TestNewCoinRatingService_Latest_APIFailure(t *testing.T) {
+	var db = new(MockRateDB)
+	var market = new(MockCoinMarket)
+
+	market.RateFunc = func(ctx context.Context, coin string, fiat string, at time.Time) (btclists.Rate, error) {
+		return btclists.Rate{}, btclists.ErrLimitReached
+	}
+
+	var service = pkg.NewCoinRatingService(context.Background(), db, market)
+
+	db.On("Latest", COIN, FIAT).Return(btclists.Rate{}, errors.New("not in db"))
+
+	var _, resErr = service.Latest(context.Background(), COIN, FIAT)
+	require.Equal(t, btclists.ErrLimitReached, resErr)
+	db.AssertExpectations(t)
+}
+
+func TestNewCoinRatingService_ReferencePairs(t *testing.T) {
+	var db = pkg.NewMemoryDB()
+	var start = time.Date(2020, 4, 8, 0, 0, 0, 0, time.UTC)
+	require.NoError(t, db.AddBatch(context.Background(), []btclists.Rate{
+		{Date: start, Coin: "EUR", Fiat: "USD", Rate: decimal.NewFromFloat(1.09), Resolution: btclists.Resolution1Day},
+	}))
+
+	var market = new(MockCandleMarket)
+	market.RateFunc = func(ctx context.Context, coin string, fiat string, at time.Time) (btclists.Rate, error) {
+		t.Fatalf("rate of reference pair %s/%s requested from API", coin, fiat)
+		return btclists.Rate{}, nil
+	}
+	market.CandlesFunc = func(ctx context.Context, coin string, fiat string, from time.Time, to time.Time, limit int) ([]btclists.Candle, error) {
+		t.Fatalf("candles of reference pair %s/%s requested from API", coin, fiat)
+		return nil, nil
+	}
+
+	var service = pkg.NewCoinRatingService(context.Background(), db, market)
+	service.AddReferencePairs(pkg.NewPairs(btclists.Pair{Coin: "EUR", Fiat: "USD"}, btclists.Pair{Coin: "EUR", Fiat: "GBP"}))
+
+	var rates, err = service.Range(context.Background(), "EUR", "USD", start, start.Add(48*time.Hour))
+	require.NoError(t, err)
+	require.Len(t, rates, 1)
+
+	var average, avgErr = service.AverageForRange(context.Background(), "EUR", "USD", start, start.Add(48*time.Hour))
+	require.NoError(t, avgErr)
+	require.Equal(t, "1.09", average.String())
+
+	var _, atErr = service.At(context.Background(), "EUR", "USD", start.Add(time.Hour))
+	require.Equal(t, btclists.ErrRateNotFound, atErr)
+
+	var _, latestErr = service.Latest(context.Background(), "EUR", "GBP")
+	require.Equal(t, btclists.ErrRateNotFound, latestErr)
+
+	var candles, candlesErr = service.Candles(context.Background(), "EUR", "USD", start, start.Add(48*time.Hour))
+	require.NoError(t, candlesErr)
+	require.Empty(t, candles)
+}
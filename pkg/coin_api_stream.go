@@ -0,0 +1,341 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/influx6/btclists"
+)
+
+const (
+	CoinApiStreamURL        = "wss://ws.coinapi.io/v1/"
+	CoinApiStreamSandboxURL = "wss://ws-sandbox.coinapi.io/v1/"
+
+	// DefaultStreamResolution is the default period streamed exchange rates are
+	// aggregated into rates by.
+	DefaultStreamResolution btclists.Resolution = "10SEC"
+
+	// DefaultReconnectDelay is the default delay before a dropped stream is reconnected,
+	// doubling with each failed attempt up to MaxReconnectDelay.
+	DefaultReconnectDelay = 1 * time.Second
+	MaxReconnectDelay     = 1 * time.Minute
+
+	// streamReadTimeout is how long a stream may go without messages before being
+	// considered dead, CoinAPI sends heartbeats every second when asked to.
+	streamReadTimeout = 30 * time.Second
+)
+
+var (
+	ErrStreamReconnect = errors.New("stream asked to reconnect by provider")
+)
+
+// coinAPIHello is the first message sent over a CoinAPI stream, subscribing to
+// exchange rates of provided assets.
+type coinAPIHello struct {
+	Type           string   `json:"type"`
+	APIKey         string   `json:"apikey"`
+	Heartbeat      bool     `json:"heartbeat"`
+	DataTypes      []string `json:"subscribe_data_type"`
+	FilterAssetIds []string `json:"subscribe_filter_asset_id"`
+}
+
+// coinAPIStreamMessage defines the fields used of messages received over a CoinAPI
+// stream, where Type is exrate, the ExchangeRate fields are set.
+type coinAPIStreamMessage struct {
+	ExchangeRate
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+// CoinAPIStream ingests exchange rates of pairs pushed over CoinAPI's WebSocket API, instead
+// of polling for them each minute like PeriodicRatingUpdate, costing no request credits.
+//
+// Exchange rates received within each period of Resolution are aggregated into a single rate,
+// being the last exchange rate of the period, dated at the end of the period as with candles.
+// Rates are written with RatesDB.AddBatch once their period closes and published to Hub if set.
+//
+// Dropped connections are reconnected and resubscribed with an exponential backoff starting
+// at ReconnectDelay up to MaxReconnectDelay.
+type CoinAPIStream struct {
+	URL   string
+	Token string
+	DB    btclists.RatesDB
+	Hub   *RateHub
+
+	// Resolution sets the period exchange rates are aggregated by, defaults
+	// to DefaultStreamResolution.
+	Resolution btclists.Resolution
+
+	// ReconnectDelay sets the delay before the first reconnect attempt, defaults
+	// to DefaultReconnectDelay.
+	ReconnectDelay time.Duration
+
+	Dialer *websocket.Dialer
+}
+
+func NewCoinAPIStream(url string, token string, db btclists.RatesDB) *CoinAPIStream {
+	return &CoinAPIStream{
+		URL:            url,
+		Token:          token,
+		DB:             db,
+		Resolution:     DefaultStreamResolution,
+		ReconnectDelay: DefaultReconnectDelay,
+		Dialer:         websocket.DefaultDialer,
+	}
+}
+
+// Run consumes exchange rates of provided pairs till ctx is cancelled, reconnecting
+// as connections drop. Periods still open when ctx is cancelled are closed early, their
+// rates written dated at the end of the period as others are.
+func (s *CoinAPIStream) Run(ctx context.Context, pairs Pairs) {
+	var resolution = s.Resolution
+	if !resolution.Valid() {
+		resolution = DefaultStreamResolution
+	}
+
+	var aggregate = newRateAggregator(resolution)
+
+	var flushed = make(chan struct{})
+	go func() {
+		defer close(flushed)
+
+		var ticker = time.NewTicker(resolution.Duration())
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				s.write(context.Background(), aggregate.Flush(time.Time{}))
+				return
+			case now := <-ticker.C:
+				s.write(ctx, aggregate.Flush(now.UTC()))
+			}
+		}
+	}()
+
+	defer func() { <-flushed }()
+
+	var delay = s.reconnectDelay()
+	for {
+		var received, err = s.consume(ctx, pairs, aggregate)
+		if ctx.Err() != nil {
+			return
+		}
+
+		if received {
+			delay = s.reconnectDelay()
+		}
+
+		if err == ErrStreamReconnect {
+			log.Printf("[BTC Listings] | [INFO] | [COIN API STREAM] | Reconnecting on request of provider\n")
+			continue
+		}
+
+		log.Printf("[BTC Listings] | [ERROR] | [COIN API STREAM] | Stream dropped, reconnecting in %s | %s\n", delay, err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+
+		if delay *= 2; delay > MaxReconnectDelay {
+			delay = MaxReconnectDelay
+		}
+	}
+}
+
+// consume connects and subscribes to exchange rates of pairs, adding them to aggregate
+// till connection drops. It reports if any exchange rate was received.
+func (s *CoinAPIStream) consume(ctx context.Context, pairs Pairs, aggregate *rateAggregator) (bool, error) {
+	var dialer = s.Dialer
+	if dialer == nil {
+		dialer = websocket.DefaultDialer
+	}
+
+	var conn, _, err = dialer.DialContext(ctx, s.URL, nil)
+	if err != nil {
+		return false, err
+	}
+
+	defer conn.Close()
+
+	// close connection once ctx is done, unblocking reads.
+	var done = make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = conn.Close()
+		case <-done:
+		}
+	}()
+
+	var hello = coinAPIHello{
+		Type:      "hello",
+		APIKey:    s.Token,
+		Heartbeat: true,
+		DataTypes: []string{"exrate"},
+	}
+	for _, pair := range pairs.List() {
+		hello.FilterAssetIds = append(hello.FilterAssetIds, pair.String())
+	}
+
+	if writeErr := conn.WriteJSON(hello); writeErr != nil {
+		return false, writeErr
+	}
+
+	log.Printf("[BTC Listings] | [INFO] | [COIN API STREAM] | Subscribed to exchange rates | %v\n", hello.FilterAssetIds)
+
+	var received bool
+	for {
+		_ = conn.SetReadDeadline(time.Now().Add(streamReadTimeout))
+
+		var message coinAPIStreamMessage
+		if readErr := conn.ReadJSON(&message); readErr != nil {
+			return received, readErr
+		}
+
+		switch message.Type {
+		case "exrate":
+			if validErr := message.ExchangeRate.Valid(); validErr != nil {
+				log.Printf("[BTC Listings] | [ERROR] | [COIN API STREAM] | Invalid exchange rate | %s\n", validErr)
+				continue
+			}
+
+			var pair = normalizePair(message.AssetIdBase, message.AssetIdQuote)
+			if !pairs.Has(pair.Coin, pair.Fiat) {
+				continue
+			}
+
+			received = true
+			aggregate.Add(btclists.Rate{
+				Date: message.Time.UTC(),
+				Rate: message.Rate,
+				Coin: pair.Coin,
+				Fiat: pair.Fiat,
+			})
+		case "error":
+			return received, fmt.Errorf("coinapi stream error: %s", message.Message)
+		case "reconnect":
+			return received, ErrStreamReconnect
+		}
+	}
+}
+
+// write stores rates with AddBatch, publishing them to Hub once stored.
+func (s *CoinAPIStream) write(ctx context.Context, rates []btclists.Rate) {
+	if len(rates) == 0 {
+		return
+	}
+
+	if err := s.DB.AddBatch(ctx, rates); err != nil {
+		log.Printf("[BTC Listings] | [CRITICAL] | [COIN API STREAM] | Failed to store streamed rates | %s\n", err)
+		return
+	}
+
+	if s.Hub != nil {
+		for _, rate := range rates {
+			s.Hub.Publish(rate)
+		}
+	}
+
+	log.Printf("[BTC Listings] | [LOG] | [COIN API STREAM] | stored streamed rates | %d\n", len(rates))
+}
+
+func (s *CoinAPIStream) reconnectDelay() time.Duration {
+	if s.ReconnectDelay <= 0 {
+		return DefaultReconnectDelay
+	}
+	return s.ReconnectDelay
+}
+
+// rateAggregator aggregates rates of pairs into the last rate of each period
+// of it's resolution.
+type rateAggregator struct {
+	resolution btclists.Resolution
+	period     time.Duration
+
+	mu      sync.Mutex
+	open    map[btclists.Pair]btclists.Rate
+	closed  []btclists.Rate
+	flushed map[btclists.Pair]time.Time
+}
+
+func newRateAggregator(resolution btclists.Resolution) *rateAggregator {
+	return &rateAggregator{
+		resolution: resolution,
+		period:     resolution.Duration(),
+		open:       map[btclists.Pair]btclists.Rate{},
+		flushed:    map[btclists.Pair]time.Time{},
+	}
+}
+
+// Add adds rate into the period of it's date, closing the previous period of it's
+// pair if rate starts a new one. Rates older than the open period, or of periods
+// already flushed, are ignored.
+func (a *rateAggregator) Add(rate btclists.Rate) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var pair = btclists.Pair{Coin: rate.Coin, Fiat: rate.Fiat}
+	if flushed, ok := a.flushed[pair]; ok && !a.end(rate.Date).After(flushed) {
+		return
+	}
+
+	var current, ok = a.open[pair]
+	if ok && rate.Date.Before(current.Date) {
+		return
+	}
+
+	if ok && a.end(rate.Date).After(a.end(current.Date)) {
+		a.closed = append(a.closed, a.closePeriod(current))
+	}
+	a.open[pair] = rate
+}
+
+// Flush returns all closed periods as rates along with open periods which ended by now.
+// Where now is zero, all open periods are closed and returned too.
+func (a *rateAggregator) Flush(now time.Time) []btclists.Rate {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var rates = a.closed
+	a.closed = nil
+
+	for pair, current := range a.open {
+		if now.IsZero() || !a.end(current.Date).After(now) {
+			rates = append(rates, a.closePeriod(current))
+			delete(a.open, pair)
+		}
+	}
+
+	for _, rate := range rates {
+		var pair = btclists.Pair{Coin: rate.Coin, Fiat: rate.Fiat}
+		if rate.Date.After(a.flushed[pair]) {
+			a.flushed[pair] = rate.Date
+		}
+	}
+
+	sort.SliceStable(rates, func(i, j int) bool {
+		return rates[i].Date.Before(rates[j].Date)
+	})
+	return rates
+}
+
+func (a *rateAggregator) closePeriod(last btclists.Rate) btclists.Rate {
+	last.Date = a.end(last.Date)
+	last.Resolution = a.resolution
+	return last
+}
+
+// end returns the end of the period date falls within.
+func (a *rateAggregator) end(date time.Time) time.Time {
+	return date.Truncate(a.period).Add(a.period)
+}
@@ -0,0 +1,288 @@
+package pkg_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+
+	"github.com/influx6/btclists"
+	"github.com/influx6/btclists/pkg"
+)
+
+// RecordingRatesDB records batches of rates added.
+type RecordingRatesDB struct {
+	btclists.RatesDB
+
+	mu    sync.Mutex
+	rates []btclists.Rate
+}
+
+func (r *RecordingRatesDB) AddBatch(ctx context.Context, rates []btclists.Rate) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rates = append(r.rates, rates...)
+	return nil
+}
+
+func (r *RecordingRatesDB) Rates() []btclists.Rate {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]btclists.Rate(nil), r.rates...)
+}
+
+func exchangeRateMessage(coin string, fiat string, date time.Time, rate string) map[string]interface{} {
+	return map[string]interface{}{
+		"type":           "exrate",
+		"asset_id_base":  coin,
+		"asset_id_quote": fiat,
+		"time":           date.Format("2006-01-02T15:04:05.0000000Z"),
+		"rate":           rate,
+	}
+}
+
+func TestCoinAPIStream_Run(t *testing.T) {
+	var start = time.Date(2020, 4, 8, 14, 0, 0, 0, time.UTC)
+
+	// each connection is served the next list of messages, before being dropped.
+	var connections = [][]map[string]interface{}{
+		{
+			exchangeRateMessage("BTC", "USD", start.Add(200*time.Millisecond), "7200.5"),
+			exchangeRateMessage("BTC", "USD", start.Add(500*time.Millisecond), "7201.5"),
+			{"type": "hearbeat"},
+			exchangeRateMessage("BTC", "USD", start.Add(1200*time.Millisecond), "7202.5"),
+		},
+		{
+			exchangeRateMessage("ETH", "USD", start.Add(2100*time.Millisecond), "170.5"),
+			exchangeRateMessage("BTC", "USD", start.Add(2500*time.Millisecond), "7203.5"),
+		},
+	}
+
+	var mu sync.Mutex
+	var served int
+
+	var upgrader websocket.Upgrader
+	var server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var conn, err = upgrader.Upgrade(w, r, nil)
+		require.NoError(t, err)
+		defer conn.Close()
+
+		var hello map[string]interface{}
+		require.NoError(t, conn.ReadJSON(&hello))
+		require.Equal(t, "hello", hello["type"])
+		require.Equal(t, APIToken, hello["apikey"])
+		require.Equal(t, []interface{}{"exrate"}, hello["subscribe_data_type"])
+		require.Equal(t, []interface{}{"BTC/USD"}, hello["subscribe_filter_asset_id"])
+
+		mu.Lock()
+		var index = served
+		served++
+		mu.Unlock()
+
+		if index >= len(connections) {
+			// hold connection open till client goes away.
+			_, _, _ = conn.ReadMessage()
+			return
+		}
+
+		for _, message := range connections[index] {
+			require.NoError(t, conn.WriteJSON(message))
+		}
+	}))
+	defer server.Close()
+
+	var db = new(RecordingRatesDB)
+	var hub = pkg.NewRateHub()
+	var sub = hub.Subscribe(nil)
+
+	var stream = pkg.NewCoinAPIStream("ws"+strings.TrimPrefix(server.URL, "http"), APIToken, db)
+	stream.Resolution = btclists.Resolution1Sec
+	stream.ReconnectDelay = 10 * time.Millisecond
+	stream.Hub = hub
+
+	var ctx, cancel = context.WithCancel(context.Background())
+	var stopped = make(chan struct{})
+	go func() {
+		defer close(stopped)
+		stream.Run(ctx, pkg.NewPairs(btclists.Pair{Coin: "BTC", Fiat: "USD"}))
+	}()
+
+	require.Eventually(t, func() bool {
+		return len(db.Rates()) == 3
+	}, 5*time.Second, 20*time.Millisecond)
+
+	cancel()
+	<-stopped
+
+	mu.Lock()
+	require.GreaterOrEqual(t, served, 2)
+	mu.Unlock()
+
+	var rates = db.Rates()
+	var expected = []struct {
+		date time.Time
+		rate string
+	}{
+		{start.Add(time.Second), "7201.5"},
+		{start.Add(2 * time.Second), "7202.5"},
+		{start.Add(3 * time.Second), "7203.5"},
+	}
+	for index, want := range expected {
+		require.True(t, want.date.Equal(rates[index].Date))
+		require.Equal(t, want.rate, rates[index].Rate.String())
+		require.Equal(t, "BTC", rates[index].Coin)
+		require.Equal(t, "USD", rates[index].Fiat)
+		require.Equal(t, btclists.Resolution1Sec, rates[index].Resolution)
+		require.Equal(t, rates[index], <-sub.Rates)
+	}
+}
+
+func TestCoinAPIStream_Run_ClosesOpenPeriods(t *testing.T) {
+	var start = time.Date(2020, 4, 8, 14, 0, 0, 0, time.UTC)
+
+	var mu sync.Mutex
+	var served int
+
+	var upgrader websocket.Upgrader
+	var server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var conn, err = upgrader.Upgrade(w, r, nil)
+		require.NoError(t, err)
+		defer conn.Close()
+
+		var hello map[string]interface{}
+		require.NoError(t, conn.ReadJSON(&hello))
+
+		mu.Lock()
+		var index = served
+		served++
+		mu.Unlock()
+
+		if index > 0 {
+			// hold connection open till client goes away.
+			_, _, _ = conn.ReadMessage()
+			return
+		}
+
+		// dropping the connection after sending, so a second connection
+		// means both rates were received.
+		require.NoError(t, conn.WriteJSON(exchangeRateMessage("BTC", "USD", start.Add(10*time.Minute), "7200.5")))
+		require.NoError(t, conn.WriteJSON(exchangeRateMessage("BTC", "USD", start.Add(70*time.Minute), "7201.5")))
+	}))
+	defer server.Close()
+
+	var db = new(RecordingRatesDB)
+	var stream = pkg.NewCoinAPIStream("ws"+strings.TrimPrefix(server.URL, "http"), APIToken, db)
+	stream.Resolution = btclists.Resolution1Hour
+	stream.ReconnectDelay = 10 * time.Millisecond
+
+	var ctx, cancel = context.WithCancel(context.Background())
+	var stopped = make(chan struct{})
+	go func() {
+		defer close(stopped)
+		stream.Run(ctx, pkg.NewPairs(btclists.Pair{Coin: "BTC", Fiat: "USD"}))
+	}()
+
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return served >= 2
+	}, 5*time.Second, 10*time.Millisecond)
+
+	cancel()
+	<-stopped
+
+	t.Logf("Should write open periods at shutdown dated at the end of their period")
+	{
+		var rates = db.Rates()
+		require.Len(t, rates, 2)
+		require.True(t, start.Add(time.Hour).Equal(rates[0].Date))
+		require.Equal(t, "7200.5", rates[0].Rate.String())
+		require.True(t, start.Add(2*time.Hour).Equal(rates[1].Date))
+		require.Equal(t, "7201.5", rates[1].Rate.String())
+		require.Equal(t, btclists.Resolution1Hour, rates[1].Resolution)
+	}
+}
+
+func TestCoinAPIStream_Run_IgnoresLateRates(t *testing.T) {
+	var start = time.Date(2020, 4, 8, 14, 0, 0, 0, time.UTC)
+
+	var mu sync.Mutex
+	var served int
+	var late = make(chan struct{})
+
+	var upgrader websocket.Upgrader
+	var server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var conn, err = upgrader.Upgrade(w, r, nil)
+		require.NoError(t, err)
+		defer conn.Close()
+
+		var hello map[string]interface{}
+		require.NoError(t, conn.ReadJSON(&hello))
+
+		mu.Lock()
+		var index = served
+		served++
+		mu.Unlock()
+
+		if index > 0 {
+			// hold connection open till client goes away.
+			_, _, _ = conn.ReadMessage()
+			return
+		}
+
+		require.NoError(t, conn.WriteJSON(exchangeRateMessage("BTC", "USD", start.Add(500*time.Millisecond), "7201.5")))
+
+		// the late rate of the period is sent once the period is flushed, dropping
+		// the connection after, so a second connection means it was received.
+		<-late
+		require.NoError(t, conn.WriteJSON(exchangeRateMessage("BTC", "USD", start.Add(700*time.Millisecond), "7202.5")))
+	}))
+	defer server.Close()
+
+	var db = new(RecordingRatesDB)
+	var hub = pkg.NewRateHub()
+	var sub = hub.Subscribe(nil)
+
+	var stream = pkg.NewCoinAPIStream("ws"+strings.TrimPrefix(server.URL, "http"), APIToken, db)
+	stream.Resolution = btclists.Resolution1Sec
+	stream.ReconnectDelay = 10 * time.Millisecond
+	stream.Hub = hub
+
+	var ctx, cancel = context.WithCancel(context.Background())
+	var stopped = make(chan struct{})
+	go func() {
+		defer close(stopped)
+		stream.Run(ctx, pkg.NewPairs(btclists.Pair{Coin: "BTC", Fiat: "USD"}))
+	}()
+
+	require.Eventually(t, func() bool {
+		return len(db.Rates()) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	close(late)
+
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return served >= 2
+	}, 5*time.Second, 10*time.Millisecond)
+
+	cancel()
+	<-stopped
+
+	t.Logf("Should ignore rates of periods already flushed")
+	{
+		var rates = db.Rates()
+		require.Len(t, rates, 1)
+		require.True(t, start.Add(time.Second).Equal(rates[0].Date))
+		require.Equal(t, "7201.5", rates[0].Rate.String())
+
+		require.Equal(t, rates[0], <-sub.Rates)
+		require.Len(t, sub.Rates, 0)
+	}
+}
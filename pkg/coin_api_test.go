@@ -4,11 +4,14 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"net/url"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/influx6/btclists"
 	"github.com/influx6/btclists/pkg"
 
 	"github.com/stretchr/testify/require"
@@ -112,3 +115,205 @@ func TestCoinAPI_Rate_ValidateURLWithTime(t *testing.T) {
 
 	_, _ = coinLayer.Rate(context.Background(), COIN, FIAT, someTime)
 }
+
+func TestCoinAPI_Candles(t *testing.T) {
+	var httpClient MockClient
+	var coinLayer = pkg.CoinAPI{
+		URL:    APIURI,
+		Token:  APIToken,
+		Client: &httpClient,
+	}
+
+	httpClient.DoFunc = func(req *http.Request) (response *http.Response, err error) {
+		require.Equal(t, APIToken, req.Header.Get("X-CoinAPI-Key"))
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body: ioutil.NopCloser(strings.NewReader(`[
+				{
+					"time_period_start": "2020-04-08T14:00:00.0000000Z",
+					"time_period_end": "2020-04-08T14:02:00.0000000Z",
+					"time_open": "2020-04-08T14:00:01.0000000Z",
+					"time_close": "2020-04-08T14:01:59.0000000Z",
+					"price_open": 7201.5,
+					"price_high": 7210.25,
+					"price_low": 7199.1,
+					"price_close": 7205.75,
+					"volume_traded": 12.3456789,
+					"trades_count": 230
+				}
+			]`)),
+		}, nil
+	}
+
+	var candles, err = coinLayer.Candles(context.Background(), COIN, FIAT, someTime, someTimeLater, 1)
+	require.NoError(t, err)
+	require.Len(t, candles, 1)
+
+	var candle = candles[0]
+	require.Equal(t, COIN, candle.Coin)
+	require.Equal(t, FIAT, candle.Fiat)
+	require.Equal(t, "7201.5", candle.Open.String())
+	require.Equal(t, "7210.25", candle.High.String())
+	require.Equal(t, "7199.1", candle.Low.String())
+	require.Equal(t, "7205.75", candle.Close.String())
+	require.Equal(t, "12.3456789", candle.Volume.String())
+	require.Equal(t, int64(230), candle.Trades)
+	require.Equal(t, pkg.PeriodInterval, candle.Resolution)
+	require.Equal(t, "2020-04-08T14:02:00Z", candle.End.Format(time.RFC3339))
+
+	var rates, rangeErr = coinLayer.Range(context.Background(), COIN, FIAT, someTime, someTimeLater, 1)
+	require.NoError(t, rangeErr)
+	require.Len(t, rates, 1)
+	require.Equal(t, candle.Rate(), rates[0])
+}
+
+func TestCoinAPI_Range_ValidateURLWithResolution(t *testing.T) {
+	var httpClient MockClient
+	var coinLayer = pkg.CoinAPI{
+		URL:        APIURI,
+		Token:      APIToken,
+		Client:     &httpClient,
+		Resolution: btclists.Resolution1Hour,
+		PairResolutions: map[btclists.Pair]btclists.Resolution{
+			{Coin: "ETH", Fiat: "EUR"}: btclists.Resolution1Min,
+		},
+	}
+
+	var requestedPeriod string
+	httpClient.DoFunc = func(req *http.Request) (response *http.Response, err error) {
+		requestedPeriod = req.URL.Query().Get("period_id")
+		return nil, errors.New("not concerned")
+	}
+
+	t.Logf("Should use default resolution of api")
+	{
+		_, _ = coinLayer.Range(context.Background(), COIN, FIAT, someTime, someTimeLater, 1)
+		require.Equal(t, "1HRS", requestedPeriod)
+	}
+
+	t.Logf("Should use resolution of pair")
+	{
+		_, _ = coinLayer.Range(context.Background(), "ETH", "EUR", someTime, someTimeLater, 1)
+		require.Equal(t, "1MIN", requestedPeriod)
+	}
+
+	t.Logf("Should use resolution of request")
+	{
+		var ctx = btclists.WithResolution(context.Background(), btclists.Resolution1Day)
+		_, _ = coinLayer.Range(ctx, "ETH", "EUR", someTime, someTimeLater, 1)
+		require.Equal(t, "1DAY", requestedPeriod)
+	}
+}
+
+// MockBudgetStore stands in as a btclists.BudgetStore keeping budgets in memory.
+type MockBudgetStore struct {
+	Budgets map[string]btclists.Budget
+}
+
+func (m *MockBudgetStore) UpdateBudget(ctx context.Context, budget btclists.Budget) error {
+	if m.Budgets == nil {
+		m.Budgets = map[string]btclists.Budget{}
+	}
+	m.Budgets[budget.Provider] = budget
+	return nil
+}
+
+func (m *MockBudgetStore) Budget(ctx context.Context, provider string) (btclists.Budget, error) {
+	var budget, ok = m.Budgets[provider]
+	if !ok {
+		return budget, errors.New("budget not found")
+	}
+	return budget, nil
+}
+
+func TestCoinAPI_Rate_RecordsBudget(t *testing.T) {
+	var httpClient MockClient
+	var budgets MockBudgetStore
+	var coinLayer = pkg.CoinAPI{
+		URL:     APIURI,
+		Token:   APIToken,
+		Client:  &httpClient,
+		Budgets: &budgets,
+	}
+
+	httpClient.DoFunc = func(req *http.Request) (response *http.Response, err error) {
+		var header = http.Header{}
+		header.Set("X-RateLimit-Limit", "100")
+		header.Set("X-RateLimit-Remaining", "42")
+		header.Set("X-RateLimit-Reset", "2020-04-09T00:00:00.0000000Z")
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     header,
+			Body: ioutil.NopCloser(strings.NewReader(`{
+				"time": "2020-04-08T14:00:00.0000000Z",
+				"asset_id_base": "BTC",
+				"asset_id_quote": "USD",
+				"rate": 7201.5
+			}`)),
+		}, nil
+	}
+
+	var _, err = coinLayer.Rate(context.Background(), COIN, FIAT, time.Time{})
+	require.NoError(t, err)
+
+	var budget, budgetErr = budgets.Budget(context.Background(), pkg.CoinAPIProvider)
+	require.NoError(t, budgetErr)
+	require.Equal(t, int64(100), budget.Limit)
+	require.Equal(t, int64(42), budget.Remaining)
+	require.Equal(t, "2020-04-09T00:00:00Z", budget.ResetAt.Format(time.RFC3339))
+}
+
+func TestCoinAPI_Guard(t *testing.T) {
+	var httpClient MockClient
+	var coinLayer = pkg.CoinAPI{
+		URL:    APIURI,
+		Token:  APIToken,
+		Client: &httpClient,
+		Guard: &pkg.CreditGuard{
+			Budgets: &MockBudgetStore{
+				Budgets: map[string]btclists.Budget{
+					pkg.CoinAPIProvider: {
+						Provider:  pkg.CoinAPIProvider,
+						Limit:     100,
+						Remaining: 5,
+						ResetAt:   time.Now().Add(time.Hour),
+					},
+				},
+			},
+			Provider: pkg.CoinAPIProvider,
+			Reserve:  10,
+		},
+	}
+
+	var requests int
+	httpClient.DoFunc = func(req *http.Request) (response *http.Response, err error) {
+		requests++
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body: ioutil.NopCloser(strings.NewReader(`{
+				"time": "2020-04-08T14:00:00.0000000Z",
+				"asset_id_base": "BTC",
+				"asset_id_quote": "USD",
+				"rate": 7201.5
+			}`)),
+		}, nil
+	}
+
+	t.Logf("Should refuse low priority requests below reserve")
+	{
+		var lowCtx = btclists.WithPriority(context.Background(), btclists.LowPriority)
+		var _, err = coinLayer.Rate(lowCtx, COIN, FIAT, time.Time{})
+		require.Equal(t, btclists.ErrBudgetExhausted, err)
+
+		_, err = coinLayer.Range(lowCtx, COIN, FIAT, someTime, time.Time{}, 1)
+		require.Equal(t, btclists.ErrBudgetExhausted, err)
+		require.Equal(t, 0, requests)
+	}
+
+	t.Logf("Should allow high priority requests below reserve")
+	{
+		var _, err = coinLayer.Rate(context.Background(), COIN, FIAT, time.Time{})
+		require.NoError(t, err)
+		require.Equal(t, 1, requests)
+	}
+}
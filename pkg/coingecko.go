@@ -0,0 +1,216 @@
+package pkg
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/influx6/btclists"
+)
+
+const (
+	CoinGeckoURL = "https://api.coingecko.com"
+
+	// coinGeckoAtWindow is the window after a requested time, within which
+	// a price is accepted as the rate for said time.
+	coinGeckoAtWindow = 1 * time.Hour
+)
+
+var (
+	_ CoinMarketAPI = (*CoinGecko)(nil)
+
+	// DefaultCoinGeckoIDs maps common crypto-currency symbols to their CoinGecko coin ids.
+	DefaultCoinGeckoIDs = map[string]string{
+		"BTC": "bitcoin",
+		"ETH": "ethereum",
+		"LTC": "litecoin",
+		"XRP": "ripple",
+		"BCH": "bitcoin-cash",
+	}
+)
+
+// CoinGecko implements CoinMarketAPI on top of the public CoinGecko API, which
+// requires no token and has generous rate limits compared to the CoinAPI free tier.
+//
+// CoinGecko identifies coins by ids (e.g bitcoin) rather than symbols, hence
+// CoinIDs is used to map symbols to ids, unknown symbols are used lower-cased as is.
+//
+// CoinGecko picks the granularity of history by the span requested, minutely data for spans
+// within a day, hourly for spans within 90 days and daily otherwise.
+type CoinGecko struct {
+	URL     string
+	Client  btclists.Client
+	CoinIDs map[string]string
+}
+
+func NewCoinGecko(url string, client btclists.Client) *CoinGecko {
+	return &CoinGecko{URL: url, Client: client, CoinIDs: DefaultCoinGeckoIDs}
+}
+
+// Rate retrieves rate for giving coin based on fiat currency for specific time,
+// if time is zero, then the current price is returned.
+func (c *CoinGecko) Rate(ctx context.Context, coin string, fiat string, at time.Time) (btclists.Rate, error) {
+	if !at.IsZero() {
+		var rates, err = c.Range(ctx, coin, fiat, at, at.Add(coinGeckoAtWindow), 1)
+		if err != nil {
+			return btclists.Rate{}, err
+		}
+		if len(rates) == 0 {
+			return btclists.Rate{}, btclists.ErrRateNotFound
+		}
+		return rates[0], nil
+	}
+
+	var id = c.coinID(coin)
+	var currency = strings.ToLower(fiat)
+
+	var query = url.Values{}
+	query.Set("ids", id)
+	query.Set("vs_currencies", currency)
+	query.Set("include_last_updated_at", "true")
+
+	var path = fmt.Sprintf("%s/api/v3/simple/price", c.URL)
+	var prices map[string]map[string]json.Number
+	if err := c.get(ctx, path, query, &prices); err != nil {
+		return btclists.Rate{}, err
+	}
+
+	var price, hasPrice = prices[id][currency]
+	if !hasPrice {
+		return btclists.Rate{}, btclists.ErrRateNotFound
+	}
+
+	var value, err = decimal.NewFromString(price.String())
+	if err != nil {
+		return btclists.Rate{}, err
+	}
+
+	var date = time.Now().UTC()
+	if updated, updateErr := prices[id]["last_updated_at"].Int64(); updateErr == nil {
+		date = time.Unix(updated, 0).UTC()
+	}
+
+	return btclists.Rate{
+		Date: date,
+		Rate: value,
+		Coin: coin,
+		Fiat: fiat,
+	}, nil
+}
+
+// RangeFrom returns all results from giving time till provided limit.
+func (c *CoinGecko) RangeFrom(ctx context.Context, coin string, fiat string, from time.Time, limit int) ([]btclists.Rate, error) {
+	return c.Range(ctx, coin, fiat, from, time.Time{}, limit)
+}
+
+// Range retrieves all prices for giving coin for giving fiat from provided time range
+// (if to is not provided, then till current time), returning at most limit rates.
+func (c *CoinGecko) Range(ctx context.Context, coin string, fiat string, from time.Time, to time.Time, limit int) ([]btclists.Rate, error) {
+	if from.IsZero() {
+		return nil, errors.New("invalid 'from' time range provided")
+	}
+	if to.IsZero() {
+		to = time.Now()
+	}
+
+	var query = url.Values{}
+	query.Set("vs_currency", strings.ToLower(fiat))
+	query.Set("from", fmt.Sprintf("%d", from.Unix()))
+	query.Set("to", fmt.Sprintf("%d", to.Unix()))
+
+	var path = fmt.Sprintf("%s/api/v3/coins/%s/market_chart/range", c.URL, c.coinID(coin))
+
+	var chart struct {
+		Prices [][]json.Number `json:"prices"`
+	}
+	if err := c.get(ctx, path, query, &chart); err != nil {
+		return nil, err
+	}
+
+	var rates = make([]btclists.Rate, 0, len(chart.Prices))
+	for _, point := range chart.Prices {
+		if limit > 0 && len(rates) == limit {
+			break
+		}
+		if len(point) != 2 {
+			return nil, errors.New("invalid price point received, expected [time, price]")
+		}
+
+		var millis, timeErr = point[0].Int64()
+		if timeErr != nil {
+			return nil, timeErr
+		}
+
+		var value, valueErr = decimal.NewFromString(point[1].String())
+		if valueErr != nil {
+			return nil, valueErr
+		}
+
+		rates = append(rates, btclists.Rate{
+			Date: time.Unix(0, millis*int64(time.Millisecond)).UTC(),
+			Rate: value,
+			Coin: coin,
+			Fiat: fiat,
+		})
+	}
+
+	return rates, nil
+}
+
+func (c *CoinGecko) coinID(coin string) string {
+	if id, ok := c.CoinIDs[strings.ToUpper(coin)]; ok {
+		return id
+	}
+	return strings.ToLower(coin)
+}
+
+func (c *CoinGecko) get(ctx context.Context, path string, query url.Values, target interface{}) error {
+	var req, err = http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s?%s", path, query.Encode()), nil)
+	if err != nil {
+		return err
+	}
+
+	var res, resErr = c.Client.Do(req)
+	if resErr != nil {
+		return resErr
+	}
+
+	defer res.Body.Close()
+
+	if statusErr := providerStatusError(res.StatusCode); statusErr != nil {
+		return statusErr
+	}
+
+	var decoder = json.NewDecoder(res.Body)
+	decoder.UseNumber()
+	return decoder.Decode(target)
+}
+
+// providerStatusError maps common http status codes of market data providers
+// into their equivalent errors.
+func providerStatusError(status int) error {
+	switch status {
+	case http.StatusBadRequest:
+		return ErrBadRequest
+	case http.StatusTooManyRequests:
+		return btclists.ErrLimitReached
+	case http.StatusUnauthorized:
+		return btclists.ErrInvalidToken
+	case http.StatusForbidden:
+		return btclists.ErrUnauthorized
+	case http.StatusNotFound:
+		return btclists.ErrRateNotFound
+	}
+
+	if status >= http.StatusBadRequest {
+		return fmt.Errorf("provider responded with status %d", status)
+	}
+	return nil
+}
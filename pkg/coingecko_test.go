@@ -0,0 +1,89 @@
+package pkg_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influx6/btclists"
+	"github.com/influx6/btclists/pkg"
+)
+
+// newCoinGeckoStandIn returns a httptest server standing in for the CoinGecko API.
+func newCoinGeckoStandIn(t *testing.T) *httptest.Server {
+	var mux = http.NewServeMux()
+	mux.HandleFunc("/api/v3/simple/price", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "bitcoin", r.URL.Query().Get("ids"))
+		require.Equal(t, "usd", r.URL.Query().Get("vs_currencies"))
+		_, _ = fmt.Fprint(w, `{"bitcoin":{"usd":7234.123456789,"last_updated_at":1586355408}}`)
+	})
+	mux.HandleFunc("/api/v3/coins/bitcoin/market_chart/range", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "usd", r.URL.Query().Get("vs_currency"))
+		require.NotEmpty(t, r.URL.Query().Get("from"))
+		require.NotEmpty(t, r.URL.Query().Get("to"))
+		_, _ = fmt.Fprint(w, `{
+			"prices": [[1586355360000, 7230.1], [1586355420000, 7231.25], [1586355480000, 7229.5]],
+			"market_caps": [],
+			"total_volumes": []
+		}`)
+	})
+	mux.HandleFunc("/api/v3/coins/dogecoin/market_chart/range", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+	return httptest.NewServer(mux)
+}
+
+func TestCoinGecko_Rate_Latest(t *testing.T) {
+	var server = newCoinGeckoStandIn(t)
+	defer server.Close()
+
+	var gecko = pkg.NewCoinGecko(server.URL, server.Client())
+
+	var rate, err = gecko.Rate(context.Background(), COIN, FIAT, time.Time{})
+	require.NoError(t, err)
+	require.Equal(t, "7234.123456789", rate.Rate.String())
+	require.Equal(t, COIN, rate.Coin)
+	require.Equal(t, FIAT, rate.Fiat)
+	require.Equal(t, time.Unix(1586355408, 0).UTC(), rate.Date)
+}
+
+func TestCoinGecko_Rate_At(t *testing.T) {
+	var server = newCoinGeckoStandIn(t)
+	defer server.Close()
+
+	var gecko = pkg.NewCoinGecko(server.URL, server.Client())
+
+	var rate, err = gecko.Rate(context.Background(), COIN, FIAT, time.Unix(1586355360, 0))
+	require.NoError(t, err)
+	require.Equal(t, "7230.1", rate.Rate.String())
+	require.Equal(t, time.Unix(1586355360, 0).UTC(), rate.Date)
+}
+
+func TestCoinGecko_Range(t *testing.T) {
+	var server = newCoinGeckoStandIn(t)
+	defer server.Close()
+
+	var gecko = pkg.NewCoinGecko(server.URL, server.Client())
+
+	var rates, err = gecko.Range(context.Background(), COIN, FIAT, time.Unix(1586355300, 0), time.Unix(1586355500, 0), 2)
+	require.NoError(t, err)
+	require.Len(t, rates, 2)
+	require.Equal(t, "7230.1", rates[0].Rate.String())
+	require.Equal(t, "7231.25", rates[1].Rate.String())
+	require.Equal(t, time.Unix(1586355420, 0).UTC(), rates[1].Date)
+}
+
+func TestCoinGecko_Range_LimitReached(t *testing.T) {
+	var server = newCoinGeckoStandIn(t)
+	defer server.Close()
+
+	var gecko = pkg.NewCoinGecko(server.URL, server.Client())
+
+	var _, err = gecko.Range(context.Background(), "DOGECOIN", FIAT, someTime, someTimeLater, 2)
+	require.Equal(t, btclists.ErrLimitReached, err)
+}
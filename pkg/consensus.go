@@ -0,0 +1,320 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/influx6/btclists"
+)
+
+const (
+	// DefaultMaxDeviation is the default relative deviation from the median price
+	// within which a source is considered to agree (i.e 2%).
+	DefaultMaxDeviation = 0.02
+
+	// DefaultConsensusBucket is the default time span rates of sources are aligned by
+	// when aggregating ranges requested without a resolution.
+	DefaultConsensusBucket = 1 * time.Minute
+)
+
+var (
+	ErrNoConsensus = errors.New("market sources failed to reach consensus")
+
+	_ CoinMarketAPI = (*ConsensusMarket)(nil)
+)
+
+// MarketSource names an underlying CoinMarketAPI used by composite market apis.
+type MarketSource struct {
+	Name string
+	API  CoinMarketAPI
+
+	// Weight sets the weight of source when aggregating with WeightedMean, a zero
+	// weight is treated as 1.
+	Weight float64
+}
+
+// ConsensusStrategy defines how prices of agreeing sources are aggregated.
+type ConsensusStrategy int
+
+const (
+	// Median returns the median price of all sources.
+	Median ConsensusStrategy = iota
+
+	// WeightedMean returns the weighted mean of sources within MaxDeviation
+	// of the median, rejecting the rest as outliers.
+	WeightedMean
+)
+
+// ConsensusMarket implements CoinMarketAPI by querying several market sources concurrently,
+// returning their consensus, so a single provider glitch does not flow into our records.
+//
+// A source agrees when it's price is within MaxDeviation of the median price of all sources,
+// agreeing sources are recorded on the Rate.Sources of returned rates. Where less than Quorum
+// sources agree, ErrNoConsensus is returned.
+//
+// Sources failing a request are ignored, only where all sources fail is an error returned.
+type ConsensusMarket struct {
+	Sources  []MarketSource
+	Strategy ConsensusStrategy
+
+	// Quorum sets the minimum number of sources which must agree, defaults to a
+	// majority of sources (i.e len(Sources)/2+1).
+	Quorum int
+
+	// MaxDeviation sets the relative deviation from the median, within which a source
+	// agrees (e.g 0.02 for 2%), defaults to DefaultMaxDeviation.
+	MaxDeviation float64
+
+	// Bucket sets the time span rates of sources are aligned by for ranges requested
+	// without a resolution (see btclists.WithResolution), defaults to DefaultConsensusBucket.
+	Bucket time.Duration
+}
+
+func NewConsensusMarket(strategy ConsensusStrategy, sources ...MarketSource) *ConsensusMarket {
+	return &ConsensusMarket{
+		Sources:      sources,
+		Strategy:     strategy,
+		Quorum:       len(sources)/2 + 1,
+		MaxDeviation: DefaultMaxDeviation,
+		Bucket:       DefaultConsensusBucket,
+	}
+}
+
+// sourceRate is a rate returned by a named source.
+type sourceRate struct {
+	source MarketSource
+	rate   btclists.Rate
+}
+
+// Rate returns the consensus rate of all sources for giving time.
+func (c *ConsensusMarket) Rate(ctx context.Context, coin string, fiat string, at time.Time) (btclists.Rate, error) {
+	var results = make([]btclists.Rate, len(c.Sources))
+	var errs = make([]error, len(c.Sources))
+
+	var waiter sync.WaitGroup
+	waiter.Add(len(c.Sources))
+	for index, source := range c.Sources {
+		go func(index int, source MarketSource) {
+			defer waiter.Done()
+			results[index], errs[index] = source.API.Rate(ctx, coin, fiat, at)
+		}(index, source)
+	}
+	waiter.Wait()
+
+	var quotes []sourceRate
+	for index, source := range c.Sources {
+		if errs[index] != nil {
+			log.Printf("[BTC Listings] | [ERROR] | [CONSENSUS] | Source failed to provide rate | %s | %s\n", source.Name, errs[index])
+			continue
+		}
+		quotes = append(quotes, sourceRate{source: source, rate: results[index]})
+	}
+
+	if len(quotes) == 0 {
+		return btclists.Rate{}, firstError(errs)
+	}
+
+	var rate, err = c.consensus(quotes)
+	if err != nil {
+		return rate, err
+	}
+
+	// date consensus by latest of agreeing sources.
+	for _, quote := range quotes {
+		if quote.rate.Date.After(rate.Date) && containsString(rate.Sources, quote.source.Name) {
+			rate.Date = quote.rate.Date
+		}
+	}
+
+	log.Printf("[BTC Listings] | [INFO] | [CONSENSUS] | Sources agreed on rate | %s | %v\n", rate.Rate, rate.Sources)
+	return rate, nil
+}
+
+// RangeFrom returns all results from giving time till provided limit.
+func (c *ConsensusMarket) RangeFrom(ctx context.Context, coin string, fiat string, from time.Time, limit int) ([]btclists.Rate, error) {
+	return c.Range(ctx, coin, fiat, from, time.Time{}, limit)
+}
+
+// Range returns the consensus rates of all sources within provided time range.
+//
+// Rates of sources are aligned into buckets of time by the resolution set on ctx, else by
+// Bucket (the last rate of a source within a bucket is used), where a bucket lacks consensus
+// it is left out of results.
+func (c *ConsensusMarket) Range(ctx context.Context, coin string, fiat string, from time.Time, to time.Time, limit int) ([]btclists.Rate, error) {
+	var results = make([][]btclists.Rate, len(c.Sources))
+	var errs = make([]error, len(c.Sources))
+
+	var waiter sync.WaitGroup
+	waiter.Add(len(c.Sources))
+	for index, source := range c.Sources {
+		go func(index int, source MarketSource) {
+			defer waiter.Done()
+			results[index], errs[index] = source.API.Range(ctx, coin, fiat, from, to, limit)
+		}(index, source)
+	}
+	waiter.Wait()
+
+	var bucket = c.Bucket
+	if resolution, ok := btclists.ResolutionFrom(ctx); ok && resolution.Duration() > 0 {
+		bucket = resolution.Duration()
+	}
+	if bucket <= 0 {
+		bucket = DefaultConsensusBucket
+	}
+
+	var failed int
+	var buckets = map[time.Time]map[string]sourceRate{}
+	for index, source := range c.Sources {
+		if errs[index] != nil {
+			failed++
+			log.Printf("[BTC Listings] | [ERROR] | [CONSENSUS] | Source failed to provide range | %s | %s\n", source.Name, errs[index])
+			continue
+		}
+
+		for _, rate := range results[index] {
+			var key = rate.Date.Truncate(bucket)
+			if buckets[key] == nil {
+				buckets[key] = map[string]sourceRate{}
+			}
+			if existing, ok := buckets[key][source.Name]; ok && existing.rate.Date.After(rate.Date) {
+				continue
+			}
+			buckets[key][source.Name] = sourceRate{source: source, rate: rate}
+		}
+	}
+
+	if failed == len(c.Sources) {
+		return nil, firstError(errs)
+	}
+
+	var keys = make([]time.Time, 0, len(buckets))
+	for key := range buckets {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].Before(keys[j])
+	})
+
+	var rates = make([]btclists.Rate, 0, len(keys))
+	for _, key := range keys {
+		if limit > 0 && len(rates) == limit {
+			break
+		}
+
+		// keep sources in configured order for deterministic results.
+		var quotes = make([]sourceRate, 0, len(buckets[key]))
+		for _, source := range c.Sources {
+			if quote, ok := buckets[key][source.Name]; ok {
+				quotes = append(quotes, quote)
+			}
+		}
+
+		var rate, err = c.consensus(quotes)
+		if err != nil {
+			log.Printf("[BTC Listings] | [ERROR] | [CONSENSUS] | No consensus for period | %s | %s\n", key, err)
+			continue
+		}
+
+		rate.Date = key
+		rates = append(rates, rate)
+	}
+
+	return rates, nil
+}
+
+// consensus returns the consensus rate of provided quotes.
+func (c *ConsensusMarket) consensus(quotes []sourceRate) (btclists.Rate, error) {
+	var values = make([]decimal.Decimal, 0, len(quotes))
+	for _, quote := range quotes {
+		values = append(values, quote.rate.Rate)
+	}
+
+	var median = medianOf(values)
+
+	var maxDeviation = c.MaxDeviation
+	if maxDeviation <= 0 {
+		maxDeviation = DefaultMaxDeviation
+	}
+	var allowed = median.Abs().Mul(decimal.NewFromFloat(maxDeviation))
+
+	var agreed []sourceRate
+	for _, quote := range quotes {
+		if quote.rate.Rate.Sub(median).Abs().LessThanOrEqual(allowed) {
+			agreed = append(agreed, quote)
+		}
+	}
+
+	var quorum = c.Quorum
+	if quorum <= 0 {
+		quorum = len(c.Sources)/2 + 1
+	}
+	if len(agreed) < quorum {
+		return btclists.Rate{}, ErrNoConsensus
+	}
+
+	var rate = agreed[0].rate
+	rate.Id = 0
+	rate.Rate = median
+	rate.Sources = make([]string, 0, len(agreed))
+	for _, quote := range agreed {
+		rate.Sources = append(rate.Sources, quote.source.Name)
+	}
+
+	if c.Strategy == WeightedMean {
+		var total, weights decimal.Decimal
+		for _, quote := range agreed {
+			var weight = decimal.NewFromFloat(quote.source.Weight)
+			if quote.source.Weight <= 0 {
+				weight = decimal.NewFromInt(1)
+			}
+			total = total.Add(quote.rate.Rate.Mul(weight))
+			weights = weights.Add(weight)
+		}
+		rate.Rate = total.DivRound(weights, 16)
+	}
+
+	return rate, nil
+}
+
+// medianOf returns the median of provided values.
+func medianOf(values []decimal.Decimal) decimal.Decimal {
+	if len(values) == 0 {
+		return decimal.Decimal{}
+	}
+
+	var sorted = make([]decimal.Decimal, len(values))
+	copy(sorted, values)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].LessThan(sorted[j])
+	})
+
+	var middle = len(sorted) / 2
+	if len(sorted)%2 == 1 {
+		return sorted[middle]
+	}
+	return sorted[middle-1].Add(sorted[middle]).Div(decimal.NewFromInt(2))
+}
+
+func firstError(errs []error) error {
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func containsString(list []string, target string) bool {
+	for _, item := range list {
+		if item == target {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,203 @@
+package pkg_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+
+	"github.com/influx6/btclists"
+	"github.com/influx6/btclists/pkg"
+)
+
+// newFixedMarket returns a MockCoinMarket always responding with provided rate and error.
+func newFixedMarket(value float64, err error) *MockCoinMarket {
+	var market = new(MockCoinMarket)
+	market.RateFunc = func(ctx context.Context, coin string, fiat string, at time.Time) (btclists.Rate, error) {
+		if err != nil {
+			return btclists.Rate{}, err
+		}
+		return btclists.Rate{Coin: coin, Fiat: fiat, Date: someTime, Rate: decimal.NewFromFloat(value)}, nil
+	}
+	return market
+}
+
+func TestConsensusMarket_Rate_Median(t *testing.T) {
+	var market = pkg.NewConsensusMarket(
+		pkg.Median,
+		pkg.MarketSource{Name: "coinapi", API: newFixedMarket(7000, nil)},
+		pkg.MarketSource{Name: "kraken", API: newFixedMarket(7010, nil)},
+		pkg.MarketSource{Name: "coingecko", API: newFixedMarket(9000, nil)},
+	)
+
+	var rate, err = market.Rate(context.Background(), COIN, FIAT, time.Time{})
+	require.NoError(t, err)
+	require.Equal(t, "7010", rate.Rate.String())
+	require.Equal(t, []string{"coinapi", "kraken"}, rate.Sources)
+	require.Equal(t, COIN, rate.Coin)
+	require.Equal(t, FIAT, rate.Fiat)
+}
+
+func TestConsensusMarket_Rate_WeightedMean(t *testing.T) {
+	var market = pkg.NewConsensusMarket(
+		pkg.WeightedMean,
+		pkg.MarketSource{Name: "coinapi", API: newFixedMarket(7000, nil), Weight: 3},
+		pkg.MarketSource{Name: "kraken", API: newFixedMarket(7040, nil), Weight: 1},
+		pkg.MarketSource{Name: "coingecko", API: newFixedMarket(9000, nil), Weight: 10},
+	)
+
+	var rate, err = market.Rate(context.Background(), COIN, FIAT, time.Time{})
+	require.NoError(t, err)
+	require.Equal(t, "7010", rate.Rate.String())
+	require.Equal(t, []string{"coinapi", "kraken"}, rate.Sources)
+}
+
+func TestConsensusMarket_Rate_SourceFailures(t *testing.T) {
+	var failure = errors.New("kaboom")
+
+	t.Logf("Should ignore failing sources")
+	{
+		var market = pkg.NewConsensusMarket(
+			pkg.Median,
+			pkg.MarketSource{Name: "coinapi", API: newFixedMarket(0, btclists.ErrLimitReached)},
+			pkg.MarketSource{Name: "kraken", API: newFixedMarket(7010, nil)},
+			pkg.MarketSource{Name: "coingecko", API: newFixedMarket(7012, nil)},
+		)
+
+		var rate, err = market.Rate(context.Background(), COIN, FIAT, time.Time{})
+		require.NoError(t, err)
+		require.Equal(t, "7011", rate.Rate.String())
+		require.Equal(t, []string{"kraken", "coingecko"}, rate.Sources)
+	}
+
+	t.Logf("Should require a majority of sources by default")
+	{
+		var market = pkg.NewConsensusMarket(
+			pkg.Median,
+			pkg.MarketSource{Name: "coinapi", API: newFixedMarket(0, btclists.ErrLimitReached)},
+			pkg.MarketSource{Name: "kraken", API: newFixedMarket(7010, nil)},
+		)
+		require.Equal(t, 2, market.Quorum)
+
+		var _, err = market.Rate(context.Background(), COIN, FIAT, time.Time{})
+		require.Equal(t, pkg.ErrNoConsensus, err)
+	}
+
+	t.Logf("Should fail when all sources fail")
+	{
+		var market = pkg.NewConsensusMarket(
+			pkg.Median,
+			pkg.MarketSource{Name: "coinapi", API: newFixedMarket(0, failure)},
+			pkg.MarketSource{Name: "kraken", API: newFixedMarket(0, failure)},
+		)
+
+		var _, err = market.Rate(context.Background(), COIN, FIAT, time.Time{})
+		require.Equal(t, failure, err)
+	}
+}
+
+func TestConsensusMarket_Rate_NoQuorum(t *testing.T) {
+	var market = pkg.NewConsensusMarket(
+		pkg.Median,
+		pkg.MarketSource{Name: "coinapi", API: newFixedMarket(7000, nil)},
+		pkg.MarketSource{Name: "kraken", API: newFixedMarket(8000, nil)},
+	)
+	market.Quorum = 2
+
+	var _, err = market.Rate(context.Background(), COIN, FIAT, time.Time{})
+	require.Equal(t, pkg.ErrNoConsensus, err)
+}
+
+func TestConsensusMarket_Range(t *testing.T) {
+	var start = time.Date(2020, 4, 8, 14, 0, 0, 0, time.UTC)
+
+	var rangeOf = func(values ...float64) *MockCoinMarket {
+		var market = new(MockCoinMarket)
+		market.RangeFunc = func(ctx context.Context, coin string, fiat string, from time.Time, to time.Time, limit int) ([]btclists.Rate, error) {
+			var rates []btclists.Rate
+			for index, value := range values {
+				rates = append(rates, btclists.Rate{
+					Coin: coin,
+					Fiat: fiat,
+					Date: start.Add(time.Duration(index)*time.Minute + time.Duration(index)*time.Second),
+					Rate: decimal.NewFromFloat(value),
+				})
+			}
+			return rates, nil
+		}
+		return market
+	}
+
+	var market = pkg.NewConsensusMarket(
+		pkg.Median,
+		pkg.MarketSource{Name: "coinapi", API: rangeOf(7000, 7100, 7200)},
+		pkg.MarketSource{Name: "kraken", API: rangeOf(7002, 7104)},
+		pkg.MarketSource{Name: "coingecko", API: rangeOf(7004, 9000, 7210)},
+	)
+	market.Quorum = 2
+
+	var rates, err = market.Range(context.Background(), COIN, FIAT, start, start.Add(time.Hour), 0)
+	require.NoError(t, err)
+	require.Len(t, rates, 3)
+
+	require.Equal(t, start, rates[0].Date)
+	require.Equal(t, "7002", rates[0].Rate.String())
+	require.Equal(t, []string{"coinapi", "kraken", "coingecko"}, rates[0].Sources)
+
+	require.Equal(t, start.Add(time.Minute), rates[1].Date)
+	require.Equal(t, "7104", rates[1].Rate.String())
+	require.Equal(t, []string{"coinapi", "kraken"}, rates[1].Sources)
+
+	require.Equal(t, start.Add(2*time.Minute), rates[2].Date)
+	require.Equal(t, "7205", rates[2].Rate.String())
+	require.Equal(t, []string{"coinapi", "coingecko"}, rates[2].Sources)
+}
+
+func TestConsensusMarket_Range_Resolution(t *testing.T) {
+	var start = time.Date(2020, 4, 8, 14, 0, 0, 0, time.UTC)
+
+	var rangeOf = func(offset time.Duration, values ...float64) *MockCoinMarket {
+		var market = new(MockCoinMarket)
+		market.RangeFunc = func(ctx context.Context, coin string, fiat string, from time.Time, to time.Time, limit int) ([]btclists.Rate, error) {
+			var rates []btclists.Rate
+			for index, value := range values {
+				rates = append(rates, btclists.Rate{
+					Coin: coin,
+					Fiat: fiat,
+					Date: start.Add(time.Duration(index)*time.Hour + offset),
+					Rate: decimal.NewFromFloat(value),
+				})
+			}
+			return rates, nil
+		}
+		return market
+	}
+
+	var market = pkg.NewConsensusMarket(
+		pkg.Median,
+		pkg.MarketSource{Name: "coinapi", API: rangeOf(0, 7000, 7100)},
+		pkg.MarketSource{Name: "kraken", API: rangeOf(20*time.Minute, 7002, 7102)},
+	)
+
+	t.Logf("Should align rates of sources by requested resolution")
+	{
+		var ctx = btclists.WithResolution(context.Background(), btclists.Resolution1Hour)
+		var rates, err = market.Range(ctx, COIN, FIAT, start, start.Add(2*time.Hour), 0)
+		require.NoError(t, err)
+		require.Len(t, rates, 2)
+		require.Equal(t, start, rates[0].Date)
+		require.Equal(t, "7001", rates[0].Rate.String())
+		require.Equal(t, start.Add(time.Hour), rates[1].Date)
+		require.Equal(t, "7101", rates[1].Rate.String())
+	}
+
+	t.Logf("Should align rates of sources by Bucket where no resolution is requested")
+	{
+		var rates, err = market.Range(context.Background(), COIN, FIAT, start, start.Add(2*time.Hour), 0)
+		require.NoError(t, err)
+		require.Empty(t, rates)
+	}
+}
@@ -0,0 +1,220 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/influx6/btclists"
+)
+
+const (
+	// DefaultBaseCurrency is the currency conversions are triangulated through
+	// where no direct pair is stored.
+	DefaultBaseCurrency = "USD"
+
+	// conversionPrecision is the number of decimal places kept when dividing by a rate.
+	conversionPrecision = 16
+)
+
+var (
+	ErrNoConversionPath = errors.New("no pair or cross-rate is available to convert between currencies")
+
+	_ ConversionService = (*Converter)(nil)
+)
+
+// ConversionLeg describes a rate used to convert between two currencies of a conversion.
+//
+// Where the stored pair is the reverse of the leg (e.g USD/BTC is converted with BTC/USD),
+// Inverted is true and the amount is divided rather than multiplied by Rate.
+type ConversionLeg struct {
+	From     string          `json:"from"`
+	To       string          `json:"to"`
+	Pair     string          `json:"pair"`
+	Rate     decimal.Decimal `json:"rate"`
+	Date     time.Time       `json:"date"`
+	Inverted bool            `json:"inverted"`
+}
+
+// Conversion describes the result of converting an amount between currencies.
+type Conversion struct {
+	Amount decimal.Decimal `json:"amount"`
+	From   string          `json:"from"`
+	To     string          `json:"to"`
+	Result decimal.Decimal `json:"result"`
+	At     *time.Time      `json:"at,omitempty"`
+	Path   []string        `json:"path"`
+	Legs   []ConversionLeg `json:"legs"`
+}
+
+// ConversionService defines a service able to convert amounts between currencies.
+type ConversionService interface {
+	Convert(ctx context.Context, amount decimal.Decimal, from string, to string, at time.Time) (Conversion, error)
+}
+
+// Converter implements ConversionService on top of a btclists.RateService, converting
+// amounts with the rate of a supported pair (or it's reverse), else triangulating through
+// Base (e.g ETH to BTC with ETH/USD and BTC/USD).
+//
+// Only pairs within Pairs are used, so conversions never burn API credits on pairs we
+// do not serve. Pairs added with AddStoredPair (e.g reference pairs) are only converted
+// with stored rates, as no market data provider serves them.
+type Converter struct {
+	Rates btclists.RateService
+	Pairs Pairs
+	Base  string
+
+	// Stored serves the rates of pairs added with AddStoredPair at a time.
+	Stored btclists.PriorRateService
+
+	mu     sync.RWMutex
+	maxAge map[btclists.Pair]time.Duration
+}
+
+func NewConverter(rates btclists.RateService, pairs Pairs) *Converter {
+	return &Converter{
+		Rates:  rates,
+		Pairs:  pairs,
+		Base:   DefaultBaseCurrency,
+		maxAge: map[btclists.Pair]time.Duration{},
+	}
+}
+
+// AddStoredPair adds a pair whose rates are only served from Stored, where a conversion
+// at a time uses the last rate of pair at or before it, dated no earlier than maxAge
+// before it (e.g 1DAY for daily reference rates).
+//
+// It is safe to add pairs while conversions are served.
+func (c *Converter) AddStoredPair(pair btclists.Pair, maxAge time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.maxAge == nil {
+		c.maxAge = map[btclists.Pair]time.Duration{}
+	}
+
+	pair = normalizePair(pair.Coin, pair.Fiat)
+	c.Pairs.Add(pair.Coin, pair.Fiat)
+	c.maxAge[pair] = maxAge
+}
+
+// Convert converts amount from a currency into another at provided time, if time is
+// zero, then the latest rates are used.
+func (c *Converter) Convert(ctx context.Context, amount decimal.Decimal, from string, to string, at time.Time) (Conversion, error) {
+	from = strings.ToUpper(strings.TrimSpace(from))
+	to = strings.ToUpper(strings.TrimSpace(to))
+
+	var conversion = Conversion{
+		Amount: amount,
+		From:   from,
+		To:     to,
+		Result: amount,
+		Path:   []string{from},
+		Legs:   []ConversionLeg{},
+	}
+
+	if !at.IsZero() {
+		conversion.At = &at
+	}
+
+	if from == to {
+		return conversion, nil
+	}
+
+	c.mu.RLock()
+	var path = c.path(from, to)
+	c.mu.RUnlock()
+
+	if path == nil {
+		return conversion, ErrNoConversionPath
+	}
+
+	for index := 1; index < len(path); index++ {
+		var leg, err = c.leg(ctx, path[index-1], path[index], at)
+		if err != nil {
+			return conversion, err
+		}
+
+		if leg.Inverted {
+			conversion.Result = conversion.Result.DivRound(leg.Rate, conversionPrecision)
+		} else {
+			conversion.Result = conversion.Result.Mul(leg.Rate)
+		}
+		conversion.Legs = append(conversion.Legs, leg)
+	}
+
+	conversion.Path = path
+	return conversion, nil
+}
+
+// path returns the currencies to convert through from a currency to another,
+// or nil if no path is available.
+func (c *Converter) path(from string, to string) []string {
+	if c.supports(from, to) {
+		return []string{from, to}
+	}
+
+	var base = strings.ToUpper(c.Base)
+	if base == "" || base == from || base == to {
+		return nil
+	}
+
+	if c.supports(from, base) && c.supports(base, to) {
+		return []string{from, base, to}
+	}
+	return nil
+}
+
+// supports returns true/false if pair or it's reverse is supported.
+func (c *Converter) supports(from string, to string) bool {
+	return c.Pairs.Has(from, to) || c.Pairs.Has(to, from)
+}
+
+// leg returns the rate used to convert from a currency into another.
+func (c *Converter) leg(ctx context.Context, from string, to string, at time.Time) (ConversionLeg, error) {
+	var leg = ConversionLeg{From: from, To: to}
+
+	c.mu.RLock()
+	var coin, fiat = from, to
+	if !c.Pairs.Has(from, to) {
+		coin, fiat = to, from
+		leg.Inverted = true
+	}
+	var maxAge, stored = c.maxAge[btclists.Pair{Coin: coin, Fiat: fiat}]
+	c.mu.RUnlock()
+
+	leg.Pair = btclists.Pair{Coin: coin, Fiat: fiat}.String()
+
+	var rate btclists.Rate
+	var err error
+	switch {
+	case stored && c.Stored != nil:
+		var when = at
+		if when.IsZero() {
+			when = time.Now().UTC()
+		}
+
+		if rate, err = c.Stored.Before(ctx, coin, fiat, when, maxAge); err != nil {
+			return leg, btclists.ErrRateNotFound
+		}
+	case at.IsZero():
+		rate, err = c.Rates.Latest(ctx, coin, fiat)
+	default:
+		rate, err = c.Rates.At(ctx, coin, fiat, at)
+	}
+	if err != nil && err != ErrDBError {
+		return leg, err
+	}
+
+	if rate.Rate.IsZero() {
+		return leg, btclists.ErrRateNotFound
+	}
+
+	leg.Rate = rate.Rate
+	leg.Date = rate.Date
+	return leg, nil
+}
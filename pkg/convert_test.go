@@ -0,0 +1,155 @@
+package pkg_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+
+	"github.com/influx6/btclists"
+	"github.com/influx6/btclists/pkg"
+)
+
+func newConversionRates() *RateServerMock {
+	var quotes = map[string]decimal.Decimal{
+		"BTC/USD": decimal.NewFromInt(8000),
+		"BTC/EUR": decimal.NewFromInt(7000),
+		"ETH/USD": decimal.NewFromInt(200),
+	}
+
+	var rates = new(RateServerMock)
+	rates.LatestFunc = func(ctx context.Context, coin string, fiat string) (btclists.Rate, error) {
+		var quote, ok = quotes[coin+"/"+fiat]
+		if !ok {
+			return btclists.Rate{}, btclists.ErrRateNotFound
+		}
+		return btclists.Rate{Coin: coin, Fiat: fiat, Rate: quote, Date: someTime}, nil
+	}
+	rates.AtFunc = func(ctx context.Context, coin string, fiat string, at time.Time) (btclists.Rate, error) {
+		return rates.LatestFunc(ctx, coin, fiat)
+	}
+	return rates
+}
+
+func TestConverter_Convert(t *testing.T) {
+	var pairs, err = pkg.ParsePairs("BTC/USD,BTC/EUR,ETH/USD")
+	require.NoError(t, err)
+
+	var converter = pkg.NewConverter(newConversionRates(), pairs)
+
+	t.Logf("Should convert with direct pair")
+	{
+		var conversion, err = converter.Convert(context.Background(), decimal.NewFromFloat(0.35), "btc", "eur", time.Time{})
+		require.NoError(t, err)
+		require.Equal(t, "2450", conversion.Result.String())
+		require.Equal(t, []string{"BTC", "EUR"}, conversion.Path)
+		require.Len(t, conversion.Legs, 1)
+		require.False(t, conversion.Legs[0].Inverted)
+	}
+
+	t.Logf("Should convert with reverse of pair")
+	{
+		var conversion, err = converter.Convert(context.Background(), decimal.NewFromInt(4000), "USD", "BTC", someTime)
+		require.NoError(t, err)
+		require.Equal(t, "0.5", conversion.Result.String())
+		require.Equal(t, "BTC/USD", conversion.Legs[0].Pair)
+		require.True(t, conversion.Legs[0].Inverted)
+	}
+
+	t.Logf("Should triangulate through base currency")
+	{
+		var conversion, err = converter.Convert(context.Background(), decimal.NewFromInt(2), "ETH", "BTC", time.Time{})
+		require.NoError(t, err)
+		require.Equal(t, "0.05", conversion.Result.String())
+		require.Equal(t, []string{"ETH", "USD", "BTC"}, conversion.Path)
+		require.Len(t, conversion.Legs, 2)
+		require.Equal(t, "200", conversion.Legs[0].Rate.String())
+		require.Equal(t, "8000", conversion.Legs[1].Rate.String())
+	}
+
+	t.Logf("Should fail without a path")
+	{
+		var _, err = converter.Convert(context.Background(), decimal.NewFromInt(2), "ETH", "EUR", time.Time{})
+		require.Equal(t, pkg.ErrNoConversionPath, err)
+	}
+}
+
+func TestConverter_Convert_StoredPairs(t *testing.T) {
+	var db = pkg.NewMemoryDB()
+	var midnight = time.Date(2020, 4, 8, 0, 0, 0, 0, time.UTC)
+	require.NoError(t, db.AddBatch(context.Background(), []btclists.Rate{
+		{Date: midnight, Coin: "EUR", Fiat: "GBP", Rate: decimal.NewFromFloat(0.88), Resolution: btclists.Resolution1Day},
+	}))
+
+	var rates = new(RateServerMock)
+	rates.AtFunc = func(ctx context.Context, coin string, fiat string, at time.Time) (btclists.Rate, error) {
+		t.Fatalf("rates of stored pair %s/%s requested from RateService", coin, fiat)
+		return btclists.Rate{}, nil
+	}
+	rates.LatestFunc = func(ctx context.Context, coin string, fiat string) (btclists.Rate, error) {
+		t.Fatalf("latest rate of stored pair %s/%s requested from RateService", coin, fiat)
+		return btclists.Rate{}, nil
+	}
+
+	var converter = pkg.NewConverter(rates, pkg.Pairs{})
+	converter.Stored = db
+	converter.AddStoredPair(btclists.Pair{Coin: "eur", Fiat: "gbp"}, 24*time.Hour)
+
+	t.Logf("Should convert with last stored rate at or before time")
+	{
+		var conversion, err = converter.Convert(context.Background(), decimal.NewFromInt(100), "EUR", "GBP", midnight.Add(15*time.Hour))
+		require.NoError(t, err)
+		require.Equal(t, "88", conversion.Result.String())
+		require.True(t, midnight.Equal(conversion.Legs[0].Date))
+	}
+
+	t.Logf("Should fail where last stored rate is older than max age")
+	{
+		var _, err = converter.Convert(context.Background(), decimal.NewFromInt(100), "GBP", "EUR", midnight.Add(25*time.Hour))
+		require.Equal(t, btclists.ErrRateNotFound, err)
+
+		_, err = converter.Convert(context.Background(), decimal.NewFromInt(100), "EUR", "GBP", midnight.Add(-time.Second))
+		require.Equal(t, btclists.ErrRateNotFound, err)
+
+		_, err = converter.Convert(context.Background(), decimal.NewFromInt(100), "EUR", "GBP", time.Time{})
+		require.Equal(t, btclists.ErrRateNotFound, err)
+	}
+}
+
+func TestConversionHandler(t *testing.T) {
+	var pairs, err = pkg.ParsePairs("BTC/USD,ETH/USD")
+	require.NoError(t, err)
+
+	var handler = pkg.GetConversion(pkg.NewConverter(newConversionRates(), pairs))
+
+	var values = url.Values{}
+	values.Add("amount", "0.35")
+	values.Add("from", "BTC")
+	values.Add("to", "ETH")
+	values.Add("t", someTime.Format(btclists.DateTimeFormat))
+
+	var response = httptest.NewRecorder()
+	handler(response, httptest.NewRequest("GET", fmt.Sprintf("/convert?%s", values.Encode()), nil))
+	require.Equal(t, http.StatusOK, response.Code)
+
+	var conversionResponse pkg.ConversionResponse
+	require.NoError(t, json.NewDecoder(response.Body).Decode(&conversionResponse))
+	require.Equal(t, "14", conversionResponse.Data.Result.String())
+	require.Equal(t, []string{"BTC", "USD", "ETH"}, conversionResponse.Data.Path)
+
+	t.Logf("Should reject invalid amount")
+	{
+		values.Set("amount", "lots")
+
+		var response = httptest.NewRecorder()
+		handler(response, httptest.NewRequest("GET", fmt.Sprintf("/convert?%s", values.Encode()), nil))
+		require.Equal(t, http.StatusBadRequest, response.Code)
+	}
+}
@@ -0,0 +1,192 @@
+package pkg
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/influx6/btclists"
+)
+
+const (
+	// ECBDailyURL serves the latest ECB euro foreign exchange reference rates.
+	ECBDailyURL = "https://www.ecb.europa.eu/stats/eurofxref/eurofxref-daily.xml"
+
+	// ECBHistoryURL serves the ECB euro foreign exchange reference rates of the last 90 days.
+	ECBHistoryURL = "https://www.ecb.europa.eu/stats/eurofxref/eurofxref-hist-90d.xml"
+
+	// ECBBaseCurrency is the currency all ECB reference rates are quoted against.
+	ECBBaseCurrency = "EUR"
+
+	// DefaultReferenceRateInterval is the default interval reference rates are refreshed
+	// at, the ECB publishes once every working day.
+	DefaultReferenceRateInterval = 6 * time.Hour
+
+	// ReferenceRateMaxAge is how old the last reference rate may be to convert with at a time,
+	// the ECB only publishes on working days, so it spans weekends and public holidays.
+	ReferenceRateMaxAge = 4 * 24 * time.Hour
+)
+
+var (
+	ErrNoReferenceRates = errors.New("no reference rates found in source")
+
+	_ ReferenceRateSource = (*ECB)(nil)
+)
+
+// ReferenceRateSource defines a source of fiat foreign exchange reference rates.
+type ReferenceRateSource interface {
+	Rates(ctx context.Context) ([]btclists.Rate, error)
+}
+
+// ECB implements ReferenceRateSource for the daily euro foreign exchange reference rates
+// published by the European Central Bank as XML.
+//
+// Source may be a http(s) URL (e.g ECBDailyURL or ECBHistoryURL) or the path of a
+// file in the same format.
+//
+// Rates are returned under EUR/{currency} pairs (e.g EUR/USD being how many USD one EUR buys),
+// dated at the start of their day in UTC with a resolution of 1DAY.
+type ECB struct {
+	Source string
+	Client btclists.Client
+}
+
+func NewECB(source string, client btclists.Client) *ECB {
+	return &ECB{Source: source, Client: client}
+}
+
+type ecbEnvelope struct {
+	Days []ecbDay `xml:"Cube>Cube"`
+}
+
+type ecbDay struct {
+	Time  string    `xml:"time,attr"`
+	Rates []ecbRate `xml:"Cube"`
+}
+
+type ecbRate struct {
+	Currency string `xml:"currency,attr"`
+	Rate     string `xml:"rate,attr"`
+}
+
+// Rates returns all reference rates found in source.
+func (e *ECB) Rates(ctx context.Context) ([]btclists.Rate, error) {
+	if !strings.HasPrefix(e.Source, "http://") && !strings.HasPrefix(e.Source, "https://") {
+		var file, err = os.Open(e.Source)
+		if err != nil {
+			return nil, err
+		}
+
+		defer file.Close()
+		return ParseECBRates(file)
+	}
+
+	var req, err = http.NewRequestWithContext(ctx, "GET", e.Source, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var res, resErr = e.Client.Do(req)
+	if resErr != nil {
+		return nil, resErr
+	}
+
+	defer res.Body.Close()
+
+	if statusErr := providerStatusError(res.StatusCode); statusErr != nil {
+		return nil, statusErr
+	}
+	return ParseECBRates(res.Body)
+}
+
+// ParseECBRates parses reference rates in the ECB euro foreign exchange reference
+// rates XML format.
+func ParseECBRates(reader io.Reader) ([]btclists.Rate, error) {
+	var envelope ecbEnvelope
+	if err := xml.NewDecoder(reader).Decode(&envelope); err != nil {
+		return nil, err
+	}
+
+	var rates []btclists.Rate
+	for _, day := range envelope.Days {
+		var date, err = time.Parse(btclists.DateFormat, day.Time)
+		if err != nil {
+			return nil, fmt.Errorf("invalid reference rate date %q: %s", day.Time, err)
+		}
+
+		for _, quote := range day.Rates {
+			var value, valueErr = decimal.NewFromString(quote.Rate)
+			if valueErr != nil {
+				return nil, fmt.Errorf("invalid reference rate %q for %s: %s", quote.Rate, quote.Currency, valueErr)
+			}
+
+			rates = append(rates, btclists.Rate{
+				Date:       date.UTC(),
+				Rate:       value,
+				Coin:       ECBBaseCurrency,
+				Fiat:       strings.ToUpper(quote.Currency),
+				Resolution: btclists.Resolution1Day,
+			})
+		}
+	}
+
+	if len(rates) == 0 {
+		return nil, ErrNoReferenceRates
+	}
+	return rates, nil
+}
+
+// ReferencePairs returns the pairs of provided reference rates.
+func ReferencePairs(rates []btclists.Rate) Pairs {
+	var pairs = Pairs{}
+	for _, rate := range rates {
+		pairs.Add(rate.Coin, rate.Fiat)
+	}
+	return pairs
+}
+
+// ImportReferenceRates pulls rates from source, storing them into db under their own pairs.
+func ImportReferenceRates(ctx context.Context, tdb btclists.RatesDB, source ReferenceRateSource) ([]btclists.Rate, error) {
+	var rates, err = source.Rates(ctx)
+	if err != nil {
+		log.Printf("[BTC Listings] | [ERROR] | Failed to retrieve reference rates | %s\n", err)
+		return nil, err
+	}
+
+	if dbErr := tdb.AddBatch(ctx, rates); dbErr != nil {
+		log.Printf("[BTC Listings] | [CRITICAL] | Failed to store reference rates | %s\n", dbErr)
+		return rates, dbErr
+	}
+
+	log.Printf("[BTC Listings] | [LOG] | updated reference rates | %d\n", len(rates))
+	return rates, nil
+}
+
+// PeriodicReferenceRateUpdate boots up a loop to periodically pull reference rates
+// from provided source, adding new records to provided db. Where updated is not nil,
+// it is called with the rates of each successful update.
+func PeriodicReferenceRateUpdate(ctx context.Context, tdb btclists.RatesDB, source ReferenceRateSource, interval time.Duration, updated func([]btclists.Rate)) {
+	var ticker = time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			var rates, err = ImportReferenceRates(ctx, tdb, source)
+			if err == nil && updated != nil {
+				updated(rates)
+			}
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package pkg_test
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+
+	"github.com/influx6/btclists"
+	"github.com/influx6/btclists/pkg"
+)
+
+func TestParseECBRates(t *testing.T) {
+	var file, err = os.Open("../fixtures/ecb/eurofxref-daily.xml")
+	require.NoError(t, err)
+	defer file.Close()
+
+	var rates, parseErr = pkg.ParseECBRates(file)
+	require.NoError(t, parseErr)
+	require.Len(t, rates, 4)
+
+	var rate = rates[0]
+	require.Equal(t, "EUR", rate.Coin)
+	require.Equal(t, "USD", rate.Fiat)
+	require.Equal(t, "1.0867", rate.Rate.String())
+	require.Equal(t, btclists.Resolution1Day, rate.Resolution)
+	require.Equal(t, time.Date(2020, 4, 8, 0, 0, 0, 0, time.UTC), rate.Date)
+
+	require.Equal(t, []btclists.Pair{
+		{Coin: "EUR", Fiat: "CHF"},
+		{Coin: "EUR", Fiat: "GBP"},
+		{Coin: "EUR", Fiat: "JPY"},
+		{Coin: "EUR", Fiat: "USD"},
+	}, pkg.ReferencePairs(rates).List())
+
+	t.Logf("Should fail on invalid rates")
+	{
+		var file, err = os.Open("../fixtures/ecb/eurofxref-invalid.xml")
+		require.NoError(t, err)
+		defer file.Close()
+
+		var _, parseErr = pkg.ParseECBRates(file)
+		require.Error(t, parseErr)
+	}
+}
+
+func TestECB_Rates_FromFile(t *testing.T) {
+	var source = pkg.NewECB("../fixtures/ecb/eurofxref-hist.xml", nil)
+
+	var rates, err = source.Rates(context.Background())
+	require.NoError(t, err)
+	require.Len(t, rates, 6)
+	require.Equal(t, time.Date(2020, 4, 6, 0, 0, 0, 0, time.UTC), rates[5].Date)
+	require.Equal(t, "0.88183", rates[5].Rate.String())
+}
+
+func TestECB_Rates_FromURL(t *testing.T) {
+	var httpClient MockClient
+	httpClient.DoFunc = func(req *http.Request) (*http.Response, error) {
+		require.Equal(t, pkg.ECBDailyURL, req.URL.String())
+
+		var file, err = os.Open("../fixtures/ecb/eurofxref-daily.xml")
+		require.NoError(t, err)
+		return &http.Response{StatusCode: http.StatusOK, Body: file}, nil
+	}
+
+	var source = pkg.NewECB(pkg.ECBDailyURL, &httpClient)
+
+	var rates, err = source.Rates(context.Background())
+	require.NoError(t, err)
+	require.Len(t, rates, 4)
+}
+
+func TestImportReferenceRates(t *testing.T) {
+	var db = new(MockRateDB)
+	var source = pkg.NewECB("../fixtures/ecb/eurofxref-daily.xml", nil)
+
+	var expected, err = source.Rates(context.Background())
+	require.NoError(t, err)
+
+	db.On("AddBatch", expected).Return(nil)
+
+	var rates, importErr = pkg.ImportReferenceRates(context.Background(), db, source)
+	require.NoError(t, importErr)
+	require.Equal(t, expected, rates)
+	db.AssertExpectations(t)
+}
+
+func TestConverter_Convert_WithReferenceRates(t *testing.T) {
+	var referenceRates, err = pkg.NewECB("../fixtures/ecb/eurofxref-daily.xml", nil).Rates(context.Background())
+	require.NoError(t, err)
+
+	var rates = new(RateServerMock)
+	rates.LatestFunc = func(ctx context.Context, coin string, fiat string) (btclists.Rate, error) {
+		if coin == "BTC" && fiat == "USD" {
+			return btclists.Rate{Coin: coin, Fiat: fiat, Rate: decimal.NewFromFloat(7201.5)}, nil
+		}
+		for _, rate := range referenceRates {
+			if rate.Coin == coin && rate.Fiat == fiat {
+				return rate, nil
+			}
+		}
+		return btclists.Rate{}, btclists.ErrRateNotFound
+	}
+
+	var pairs = pkg.ReferencePairs(referenceRates)
+	pairs.Add("BTC", "USD")
+
+	var converter = pkg.NewConverter(rates, pairs)
+
+	var conversion, convertErr = converter.Convert(context.Background(), decimal.NewFromInt(1), "BTC", "EUR", time.Time{})
+	require.NoError(t, convertErr)
+	require.Equal(t, []string{"BTC", "USD", "EUR"}, conversion.Path)
+	require.True(t, conversion.Legs[1].Inverted)
+	require.Equal(t, "EUR/USD", conversion.Legs[1].Pair)
+	require.Equal(t, "6626.9439587742707279", conversion.Result.String())
+}
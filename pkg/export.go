@@ -0,0 +1,250 @@
+package pkg
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xitongsys/parquet-go-source/writerfile"
+	"github.com/xitongsys/parquet-go/writer"
+
+	"github.com/influx6/btclists"
+)
+
+// ExportFormat defines the file format rates are exported in.
+type ExportFormat string
+
+const (
+	CSVFormat     ExportFormat = "csv"
+	ParquetFormat ExportFormat = "parquet"
+
+	// ExportChunk is the time window of rates retrieved at once while exporting,
+	// keeping exports of long time ranges from being held in memory whole.
+	ExportChunk = 24 * time.Hour
+)
+
+var (
+	ErrUnknownFormat = errors.New("format must be either csv or parquet")
+	ErrUnknownColumn = errors.New("columns must be a comma separated list of id, date, coin, fiat, rate or resolution")
+
+	// ExportColumns lists all exportable columns in their default order.
+	ExportColumns = []string{"id", "date", "coin", "fiat", "rate", "resolution"}
+)
+
+// parquetColumns defines the parquet schema of each exportable column, rates are
+// stored as UTF8 decimal strings as parquet decimals have a fixed scale.
+var parquetColumns = map[string]string{
+	"id":         "name=id, type=INT64",
+	"date":       "name=date, type=TIMESTAMP_MICROS",
+	"coin":       "name=coin, type=UTF8, encoding=PLAIN_DICTIONARY",
+	"fiat":       "name=fiat, type=UTF8, encoding=PLAIN_DICTIONARY",
+	"rate":       "name=rate, type=UTF8",
+	"resolution": "name=resolution, type=UTF8, encoding=PLAIN_DICTIONARY",
+}
+
+// ParseExportFormat parses giving value (case insensitive) into an ExportFormat,
+// returning ErrUnknownFormat if not a supported format.
+func ParseExportFormat(value string) (ExportFormat, error) {
+	var format = ExportFormat(strings.ToLower(strings.TrimSpace(value)))
+	switch format {
+	case CSVFormat, ParquetFormat:
+		return format, nil
+	default:
+		return "", ErrUnknownFormat
+	}
+}
+
+// ContentType returns the media type of files in format.
+func (f ExportFormat) ContentType() string {
+	if f == ParquetFormat {
+		return "application/vnd.apache.parquet"
+	}
+	return "text/csv"
+}
+
+// ParseExportColumns parses a comma separated list of columns (e.g "date,rate"),
+// returning ExportColumns where list is empty.
+func ParseExportColumns(list string) ([]string, error) {
+	if strings.TrimSpace(list) == "" {
+		return ExportColumns, nil
+	}
+
+	var columns []string
+	for _, column := range strings.Split(list, ",") {
+		column = strings.ToLower(strings.TrimSpace(column))
+		if _, ok := parquetColumns[column]; !ok {
+			return nil, ErrUnknownColumn
+		}
+		columns = append(columns, column)
+	}
+	return columns, nil
+}
+
+// RateWriter writes rates into a file format, Close must be called once
+// all rates are written.
+type RateWriter interface {
+	Write(rate btclists.Rate) error
+	Close() error
+}
+
+// NewRateWriter returns a RateWriter writing provided columns of rates into
+// writer in provided format.
+func NewRateWriter(w io.Writer, format ExportFormat, columns []string) (RateWriter, error) {
+	switch format {
+	case CSVFormat:
+		var cw = &csvRateWriter{writer: csv.NewWriter(w), columns: columns}
+		if err := cw.writer.Write(columns); err != nil {
+			return nil, err
+		}
+		return cw, nil
+	case ParquetFormat:
+		var schema = make([]string, 0, len(columns))
+		for _, column := range columns {
+			schema = append(schema, parquetColumns[column])
+		}
+
+		var pw, err = writer.NewCSVWriter(schema, writerfile.NewWriterFile(w), 1)
+		if err != nil {
+			return nil, err
+		}
+		return &parquetRateWriter{writer: pw, columns: columns}, nil
+	default:
+		return nil, ErrUnknownFormat
+	}
+}
+
+type csvRateWriter struct {
+	writer  *csv.Writer
+	columns []string
+}
+
+func (c *csvRateWriter) Write(rate btclists.Rate) error {
+	var record = make([]string, len(c.columns))
+	for index, column := range c.columns {
+		switch column {
+		case "id":
+			record[index] = strconv.Itoa(rate.Id)
+		case "date":
+			record[index] = rate.Date.UTC().Format(time.RFC3339Nano)
+		case "coin":
+			record[index] = rate.Coin
+		case "fiat":
+			record[index] = rate.Fiat
+		case "rate":
+			record[index] = rate.Rate.String()
+		case "resolution":
+			record[index] = string(rate.Resolution)
+		}
+	}
+	return c.writer.Write(record)
+}
+
+func (c *csvRateWriter) Close() error {
+	c.writer.Flush()
+	return c.writer.Error()
+}
+
+type parquetRateWriter struct {
+	writer  *writer.CSVWriter
+	columns []string
+}
+
+func (p *parquetRateWriter) Write(rate btclists.Rate) error {
+	var record = make([]interface{}, len(p.columns))
+	for index, column := range p.columns {
+		switch column {
+		case "id":
+			record[index] = int64(rate.Id)
+		case "date":
+			record[index] = rate.Date.UnixNano() / int64(time.Microsecond)
+		case "coin":
+			record[index] = rate.Coin
+		case "fiat":
+			record[index] = rate.Fiat
+		case "rate":
+			record[index] = rate.Rate.String()
+		case "resolution":
+			record[index] = string(rate.Resolution)
+		}
+	}
+	return p.writer.Write(record)
+}
+
+func (p *parquetRateWriter) Close() error {
+	return p.writer.WriteStop()
+}
+
+// ExportOptions defines the rates exported by Export.
+type ExportOptions struct {
+	Pairs   []btclists.Pair
+	From    time.Time
+	To      time.Time
+	Columns []string
+	Format  ExportFormat
+}
+
+// Export writes rates of all pairs within time range from provided RateService into w,
+// pair after pair ordered by date ascending, returning the number of rates written.
+//
+// Rates are retrieved in windows of ExportChunk, hence rates should be a RatesDB
+// and not a service pulling missing rates from a provider.
+func Export(ctx context.Context, rates btclists.RateService, w io.Writer, options ExportOptions) (int, error) {
+	var columns = options.Columns
+	if len(columns) == 0 {
+		columns = ExportColumns
+	}
+
+	var rw, err = NewRateWriter(w, options.Format, columns)
+	if err != nil {
+		return 0, err
+	}
+
+	// windows start at whole seconds, as time ranges are queried at second precision.
+	var from = options.From.UTC().Truncate(time.Second)
+	var to = options.To.UTC()
+
+	var written int
+	for _, pair := range options.Pairs {
+		for start := from; !start.After(to); start = start.Add(ExportChunk) {
+			var end = start.Add(ExportChunk)
+			var last = !end.Before(to)
+			if last {
+				end = to
+			}
+
+			var chunk, rangeErr = rates.Range(ctx, pair.Coin, pair.Fiat, start, end)
+			if rangeErr != nil {
+				return written, rangeErr
+			}
+
+			// Range returns rates ordered by date descending.
+			for index := len(chunk) - 1; index >= 0; index-- {
+				var rate = chunk[index]
+
+				// rates at the end of a window are left to the next window.
+				if !last && !rate.Date.Before(end) {
+					continue
+				}
+
+				if writeErr := rw.Write(rate); writeErr != nil {
+					return written, writeErr
+				}
+				written++
+			}
+
+			if last {
+				break
+			}
+		}
+	}
+
+	if closeErr := rw.Close(); closeErr != nil {
+		return written, fmt.Errorf("failed to complete export: %s", closeErr)
+	}
+	return written, nil
+}
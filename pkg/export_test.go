@@ -0,0 +1,161 @@
+package pkg_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+	"github.com/xitongsys/parquet-go-source/buffer"
+	"github.com/xitongsys/parquet-go/reader"
+
+	"github.com/influx6/btclists"
+	"github.com/influx6/btclists/pkg"
+)
+
+// newExportRates returns a RateServerMock serving provided rates through Range
+// like RatesDB does, inclusive of both ends and ordered by date descending.
+func newExportRates(stored []btclists.Rate) (*RateServerMock, *int) {
+	var calls int
+	var rates = new(RateServerMock)
+	rates.RangeFunc = func(ctx context.Context, coin string, fiat string, from, to time.Time) ([]btclists.Rate, error) {
+		calls++
+		var found []btclists.Rate
+		for index := len(stored) - 1; index >= 0; index-- {
+			var rate = stored[index]
+			if rate.Coin == coin && rate.Fiat == fiat && !rate.Date.Before(from) && !rate.Date.After(to) {
+				found = append(found, rate)
+			}
+		}
+		return found, nil
+	}
+	return rates, &calls
+}
+
+func TestExport_CSV(t *testing.T) {
+	var from = time.Date(2020, 4, 8, 0, 0, 0, 0, time.UTC)
+	var stored = []btclists.Rate{
+		{Id: 1, Date: from, Coin: "BTC", Fiat: "USD", Rate: decimal.RequireFromString("7200.123456789012345678"), Resolution: btclists.Resolution1Min},
+		{Id: 2, Date: from.Add(pkg.ExportChunk), Coin: "BTC", Fiat: "USD", Rate: decimal.RequireFromString("7201.5")},
+		{Id: 3, Date: from.Add(pkg.ExportChunk + time.Hour), Coin: "BTC", Fiat: "USD", Rate: decimal.RequireFromString("7202.5")},
+		{Id: 4, Date: from.Add(time.Hour), Coin: "ETH", Fiat: "USD", Rate: decimal.RequireFromString("170.5")},
+		{Id: 5, Date: from.Add(time.Hour), Coin: "BTC", Fiat: "EUR", Rate: decimal.RequireFromString("6600.5")},
+	}
+
+	var rates, calls = newExportRates(stored)
+
+	var out bytes.Buffer
+	var written, err = pkg.Export(context.Background(), rates, &out, pkg.ExportOptions{
+		Pairs:  []btclists.Pair{{Coin: "BTC", Fiat: "USD"}, {Coin: "ETH", Fiat: "USD"}},
+		From:   from,
+		To:     from.Add(pkg.ExportChunk + 2*time.Hour),
+		Format: pkg.CSVFormat,
+	})
+	require.NoError(t, err)
+	require.Equal(t, 4, written)
+	require.Equal(t, 4, *calls)
+
+	require.Equal(t, "id,date,coin,fiat,rate,resolution\n"+
+		"1,2020-04-08T00:00:00Z,BTC,USD,7200.123456789012345678,1MIN\n"+
+		"2,2020-04-09T00:00:00Z,BTC,USD,7201.5,\n"+
+		"3,2020-04-09T01:00:00Z,BTC,USD,7202.5,\n"+
+		"4,2020-04-08T01:00:00Z,ETH,USD,170.5,\n", out.String())
+}
+
+func TestExport_CSV_Columns(t *testing.T) {
+	var from = time.Date(2020, 4, 8, 0, 0, 0, 0, time.UTC)
+	var rates, _ = newExportRates([]btclists.Rate{
+		{Id: 1, Date: from, Coin: "BTC", Fiat: "USD", Rate: decimal.RequireFromString("7200.5")},
+	})
+
+	var columns, err = pkg.ParseExportColumns("Date, rate")
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	_, err = pkg.Export(context.Background(), rates, &out, pkg.ExportOptions{
+		Pairs:   []btclists.Pair{{Coin: "BTC", Fiat: "USD"}},
+		From:    from,
+		To:      from.Add(time.Hour),
+		Columns: columns,
+		Format:  pkg.CSVFormat,
+	})
+	require.NoError(t, err)
+	require.Equal(t, "date,rate\n2020-04-08T00:00:00Z,7200.5\n", out.String())
+
+	_, err = pkg.ParseExportColumns("date,price")
+	require.Equal(t, pkg.ErrUnknownColumn, err)
+}
+
+func TestExport_Parquet(t *testing.T) {
+	var from = time.Date(2020, 4, 8, 0, 0, 0, 0, time.UTC)
+	var rates, _ = newExportRates([]btclists.Rate{
+		{Id: 1, Date: from, Coin: "BTC", Fiat: "USD", Rate: decimal.RequireFromString("7200.123456789012345678")},
+		{Id: 2, Date: from.Add(time.Minute), Coin: "BTC", Fiat: "USD", Rate: decimal.RequireFromString("7201.5")},
+	})
+
+	var out bytes.Buffer
+	var written, err = pkg.Export(context.Background(), rates, &out, pkg.ExportOptions{
+		Pairs:   []btclists.Pair{{Coin: "BTC", Fiat: "USD"}},
+		From:    from,
+		To:      from.Add(time.Hour),
+		Columns: []string{"date", "rate"},
+		Format:  pkg.ParquetFormat,
+	})
+	require.NoError(t, err)
+	require.Equal(t, 2, written)
+
+	var file, fileErr = buffer.NewBufferFile(out.Bytes())
+	require.NoError(t, fileErr)
+
+	var pr, readerErr = reader.NewParquetColumnReader(file, 1)
+	require.NoError(t, readerErr)
+	defer pr.ReadStop()
+
+	require.Equal(t, int64(2), pr.GetNumRows())
+
+	var values, _, _, valuesErr = pr.ReadColumnByIndex(1, 2)
+	require.NoError(t, valuesErr)
+	require.Equal(t, []interface{}{"7200.123456789012345678", "7201.5"}, values)
+
+	var dates, _, _, datesErr = pr.ReadColumnByIndex(0, 2)
+	require.NoError(t, datesErr)
+	require.Equal(t, []interface{}{from.UnixNano() / 1000, from.Add(time.Minute).UnixNano() / 1000}, dates)
+}
+
+func TestGetExport(t *testing.T) {
+	var from = time.Date(2020, 4, 8, 0, 0, 0, 0, time.UTC)
+	var rates, _ = newExportRates([]btclists.Rate{
+		{Id: 1, Date: from, Coin: "BTC", Fiat: "USD", Rate: decimal.RequireFromString("7200.5")},
+		{Id: 2, Date: from, Coin: "ETH", Fiat: "USD", Rate: decimal.RequireFromString("170.5")},
+	})
+
+	var pairs = pkg.NewPairs(
+		btclists.Pair{Coin: "BTC", Fiat: "USD"},
+		btclists.Pair{Coin: "ETH", Fiat: "USD"},
+	)
+
+	var req = httptest.NewRequest("GET", "/v1/export?pairs=ETH/USD&columns=coin,rate&from=2020-04-08T00:00:00Z&to=2020-04-08T01:00:00Z", nil)
+	var res = httptest.NewRecorder()
+	pkg.GetExport(rates, pairs)(res, req)
+
+	require.Equal(t, http.StatusOK, res.Code)
+	require.Equal(t, "text/csv", res.Header().Get("Content-Type"))
+	require.Equal(t, "coin,rate\nETH,170.5\n", res.Body.String())
+
+	req = httptest.NewRequest("GET", "/v1/export?format=xml&from=2020-04-08T00:00:00Z&to=2020-04-08T01:00:00Z", nil)
+	res = httptest.NewRecorder()
+	pkg.GetExport(rates, pairs)(res, req)
+
+	require.Equal(t, http.StatusBadRequest, res.Code)
+	require.Contains(t, res.Body.String(), pkg.ErrUnknownFormat.Error())
+
+	req = httptest.NewRequest("GET", "/v1/export?pairs=LTC/USD&from=2020-04-08T00:00:00Z&to=2020-04-08T01:00:00Z", nil)
+	res = httptest.NewRecorder()
+	pkg.GetExport(rates, pairs)(res, req)
+
+	require.Equal(t, http.StatusNotFound, res.Code)
+}
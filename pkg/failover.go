@@ -0,0 +1,275 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/influx6/btclists"
+)
+
+const (
+	// DefaultCoolDown is the default time a tripped provider is left alone
+	// before being probed again.
+	DefaultCoolDown = 5 * time.Minute
+)
+
+var (
+	ErrProvidersUnavailable = errors.New("no market data provider is available at the moment")
+	ErrCandlesUnsupported   = errors.New("no market data provider is able to provide candles")
+
+	_ CoinMarketAPI   = (*FailoverMarket)(nil)
+	_ CandleMarketAPI = (*FailoverMarket)(nil)
+)
+
+// BreakerState defines the state of a provider's circuit breaker.
+type BreakerState string
+
+const (
+	// BreakerClosed means provider is healthy and receives requests.
+	BreakerClosed BreakerState = "closed"
+
+	// BreakerOpen means provider has tripped and receives no requests till cool-down ends.
+	BreakerOpen BreakerState = "open"
+
+	// BreakerHalfOpen means provider's cool-down has ended and the next request probes it,
+	// other requests skip the provider till the probe completes.
+	BreakerHalfOpen BreakerState = "half-open"
+)
+
+// ProviderStatus describes the circuit breaker state of a provider.
+type ProviderStatus struct {
+	Name      string       `json:"name"`
+	State     BreakerState `json:"state"`
+	Serving   bool         `json:"serving"`
+	LastError string       `json:"last_error,omitempty"`
+	OpenedAt  *time.Time   `json:"opened_at,omitempty"`
+	RetryAt   *time.Time   `json:"retry_at,omitempty"`
+}
+
+// ProviderStatusReporter defines a type able to report the state of it's providers.
+type ProviderStatusReporter interface {
+	Status() []ProviderStatus
+}
+
+type breaker struct {
+	open      bool
+	probing   bool
+	lastError error
+	openedAt  time.Time
+	retryAt   time.Time
+}
+
+// FailoverMarket implements CoinMarketAPI by trying market sources in priority order
+// (i.e order of Sources), returning the result of the first source to succeed.
+//
+// Each source has a circuit breaker which trips when the source responds with a quota or
+// auth error (i.e btclists.ErrLimitReached, btclists.ErrInvalidToken or btclists.ErrUnauthorized),
+// a tripped source is skipped till it's cool-down ends, after which it is probed again by a
+// single request. Other errors do not trip the breaker but the next source is still tried.
+//
+// FailoverMarket implements CandleMarketAPI, only sources able to provide candles are tried
+// for candles.
+type FailoverMarket struct {
+	Sources  []MarketSource
+	CoolDown time.Duration
+
+	mu       sync.Mutex
+	serving  string
+	breakers map[string]*breaker
+}
+
+func NewFailoverMarket(coolDown time.Duration, sources ...MarketSource) *FailoverMarket {
+	return &FailoverMarket{
+		Sources:  sources,
+		CoolDown: coolDown,
+	}
+}
+
+// Rate returns rate for giving time from the first available source to succeed.
+func (f *FailoverMarket) Rate(ctx context.Context, coin string, fiat string, at time.Time) (btclists.Rate, error) {
+	var rate btclists.Rate
+	var err = f.do(f.Sources, func(source MarketSource) error {
+		var sourceErr error
+		rate, sourceErr = source.API.Rate(ctx, coin, fiat, at)
+		return sourceErr
+	})
+	return rate, err
+}
+
+// RangeFrom returns all results from giving time till provided limit from the first
+// available source to succeed.
+func (f *FailoverMarket) RangeFrom(ctx context.Context, coin string, fiat string, from time.Time, limit int) ([]btclists.Rate, error) {
+	var rates []btclists.Rate
+	var err = f.do(f.Sources, func(source MarketSource) error {
+		var sourceErr error
+		rates, sourceErr = source.API.RangeFrom(ctx, coin, fiat, from, limit)
+		return sourceErr
+	})
+	return rates, err
+}
+
+// Range returns rates within time range from the first available source to succeed.
+func (f *FailoverMarket) Range(ctx context.Context, coin string, fiat string, from time.Time, to time.Time, limit int) ([]btclists.Rate, error) {
+	var rates []btclists.Rate
+	var err = f.do(f.Sources, func(source MarketSource) error {
+		var sourceErr error
+		rates, sourceErr = source.API.Range(ctx, coin, fiat, from, to, limit)
+		return sourceErr
+	})
+	return rates, err
+}
+
+// Candles returns candles within time range from the first available source able to provide
+// candles to succeed, returning ErrCandlesUnsupported if none of the sources is.
+func (f *FailoverMarket) Candles(ctx context.Context, coin string, fiat string, from time.Time, to time.Time, limit int) ([]btclists.Candle, error) {
+	var sources = make([]MarketSource, 0, len(f.Sources))
+	for _, source := range f.Sources {
+		if _, ok := source.API.(CandleMarketAPI); ok {
+			sources = append(sources, source)
+		}
+	}
+
+	if len(sources) == 0 {
+		return nil, ErrCandlesUnsupported
+	}
+
+	var candles []btclists.Candle
+	var err = f.do(sources, func(source MarketSource) error {
+		var sourceErr error
+		candles, sourceErr = source.API.(CandleMarketAPI).Candles(ctx, coin, fiat, from, to, limit)
+		return sourceErr
+	})
+	return candles, err
+}
+
+// Status returns the circuit breaker state of all sources in priority order.
+func (f *FailoverMarket) Status() []ProviderStatus {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var now = time.Now()
+	var statuses = make([]ProviderStatus, 0, len(f.Sources))
+	for _, source := range f.Sources {
+		var status = ProviderStatus{
+			Name:    source.Name,
+			State:   BreakerClosed,
+			Serving: source.Name == f.serving,
+		}
+
+		if state, ok := f.breakers[source.Name]; ok {
+			if state.lastError != nil {
+				status.LastError = state.lastError.Error()
+			}
+			if state.open {
+				var openedAt, retryAt = state.openedAt, state.retryAt
+				status.OpenedAt = &openedAt
+				status.RetryAt = &retryAt
+				status.State = BreakerOpen
+				if !now.Before(state.retryAt) {
+					status.State = BreakerHalfOpen
+				}
+			}
+		}
+
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// do calls fn with each available source of sources in priority order till one succeeds.
+func (f *FailoverMarket) do(sources []MarketSource, fn func(source MarketSource) error) error {
+	var lastErr error
+	for _, source := range sources {
+		if !f.acquire(source.Name) {
+			continue
+		}
+
+		var err = fn(source)
+		f.record(source.Name, err)
+		if err == nil {
+			return nil
+		}
+
+		log.Printf("[BTC Listings] | [ERROR] | [FAILOVER] | Provider failed, trying next | %s | %s\n", source.Name, err)
+		lastErr = err
+	}
+
+	if lastErr == nil {
+		return ErrProvidersUnavailable
+	}
+	return lastErr
+}
+
+// acquire returns true/false if source may be called, that is if it's breaker is closed or
+// it's cool-down has ended, in which case only the first caller is let through to probe it
+// till the outcome is recorded.
+func (f *FailoverMarket) acquire(name string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var state, ok = f.breakers[name]
+	if !ok || !state.open {
+		return true
+	}
+	if time.Now().Before(state.retryAt) || state.probing {
+		return false
+	}
+
+	state.probing = true
+	return true
+}
+
+// record records the outcome of a request to source, tripping it's breaker on
+// quota or auth errors and closing it on success.
+func (f *FailoverMarket) record(name string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.breakers == nil {
+		f.breakers = map[string]*breaker{}
+	}
+
+	var state, ok = f.breakers[name]
+	if !ok {
+		state = &breaker{}
+		f.breakers[name] = state
+	}
+	state.probing = false
+
+	if err == nil {
+		if state.open {
+			log.Printf("[BTC Listings] | [INFO] | [FAILOVER] | Provider recovered | %s\n", name)
+		}
+		state.open = false
+		state.lastError = nil
+		f.serving = name
+		return
+	}
+
+	state.lastError = err
+	if !tripsBreaker(err) {
+		return
+	}
+
+	var coolDown = f.CoolDown
+	if coolDown <= 0 {
+		coolDown = DefaultCoolDown
+	}
+
+	state.open = true
+	state.openedAt = time.Now()
+	state.retryAt = state.openedAt.Add(coolDown)
+	log.Printf("[BTC Listings] | [ERROR] | [FAILOVER] | Provider tripped till %s | %s | %s\n", state.retryAt, name, err)
+}
+
+// tripsBreaker returns true/false if error is a quota or auth error.
+func tripsBreaker(err error) bool {
+	switch err {
+	case btclists.ErrLimitReached, btclists.ErrInvalidToken, btclists.ErrUnauthorized:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,217 @@
+package pkg_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+
+	"github.com/influx6/btclists"
+	"github.com/influx6/btclists/pkg"
+)
+
+// newCountingMarket returns a MockCoinMarket responding with provided rate and
+// the error returned by errFn, counting calls made.
+func newCountingMarket(value float64, calls *int, errFn func() error) *MockCoinMarket {
+	var market = newFixedMarket(value, nil)
+	var rateFunc = market.RateFunc
+	market.RateFunc = func(ctx context.Context, coin string, fiat string, at time.Time) (btclists.Rate, error) {
+		*calls++
+		if err := errFn(); err != nil {
+			return btclists.Rate{}, err
+		}
+		return rateFunc(ctx, coin, fiat, at)
+	}
+	return market
+}
+
+func TestFailoverMarket_Rate_TripsOnQuota(t *testing.T) {
+	var primaryErr = btclists.ErrLimitReached
+	var primaryCalls, secondaryCalls int
+
+	var market = pkg.NewFailoverMarket(
+		50*time.Millisecond,
+		pkg.MarketSource{Name: "coinapi", API: newCountingMarket(7000, &primaryCalls, func() error { return primaryErr })},
+		pkg.MarketSource{Name: "kraken", API: newCountingMarket(7010, &secondaryCalls, func() error { return nil })},
+	)
+
+	t.Logf("Should failover to next provider and trip primary")
+	{
+		var rate, err = market.Rate(context.Background(), COIN, FIAT, time.Time{})
+		require.NoError(t, err)
+		require.Equal(t, "7010", rate.Rate.String())
+		require.Equal(t, 1, primaryCalls)
+
+		var status = market.Status()
+		require.Len(t, status, 2)
+		require.Equal(t, pkg.BreakerOpen, status[0].State)
+		require.False(t, status[0].Serving)
+		require.Equal(t, btclists.ErrLimitReached.Error(), status[0].LastError)
+		require.NotNil(t, status[0].RetryAt)
+		require.Equal(t, pkg.BreakerClosed, status[1].State)
+		require.True(t, status[1].Serving)
+	}
+
+	t.Logf("Should skip tripped provider during cool-down")
+	{
+		var _, err = market.Rate(context.Background(), COIN, FIAT, time.Time{})
+		require.NoError(t, err)
+		require.Equal(t, 1, primaryCalls)
+		require.Equal(t, 2, secondaryCalls)
+	}
+
+	t.Logf("Should probe and recover provider after cool-down")
+	{
+		time.Sleep(60 * time.Millisecond)
+		require.Equal(t, pkg.BreakerHalfOpen, market.Status()[0].State)
+
+		primaryErr = nil
+
+		var rate, err = market.Rate(context.Background(), COIN, FIAT, time.Time{})
+		require.NoError(t, err)
+		require.Equal(t, "7000", rate.Rate.String())
+		require.Equal(t, 2, primaryCalls)
+
+		var status = market.Status()
+		require.Equal(t, pkg.BreakerClosed, status[0].State)
+		require.True(t, status[0].Serving)
+		require.Nil(t, status[0].RetryAt)
+	}
+}
+
+func TestFailoverMarket_Rate_OtherErrorsDoNotTrip(t *testing.T) {
+	var failure = errors.New("kaboom")
+	var primaryCalls, secondaryCalls int
+
+	var market = pkg.NewFailoverMarket(
+		time.Hour,
+		pkg.MarketSource{Name: "coinapi", API: newCountingMarket(7000, &primaryCalls, func() error { return failure })},
+		pkg.MarketSource{Name: "kraken", API: newCountingMarket(7010, &secondaryCalls, func() error { return failure })},
+	)
+
+	var _, err = market.Rate(context.Background(), COIN, FIAT, time.Time{})
+	require.Equal(t, failure, err)
+
+	_, err = market.Rate(context.Background(), COIN, FIAT, time.Time{})
+	require.Equal(t, failure, err)
+
+	require.Equal(t, 2, primaryCalls)
+	require.Equal(t, 2, secondaryCalls)
+	require.Equal(t, pkg.BreakerClosed, market.Status()[0].State)
+}
+
+func TestFailoverMarket_Rate_AllTripped(t *testing.T) {
+	var calls int
+	var market = pkg.NewFailoverMarket(
+		time.Hour,
+		pkg.MarketSource{Name: "coinapi", API: newCountingMarket(7000, &calls, func() error { return btclists.ErrInvalidToken })},
+	)
+
+	var _, err = market.Rate(context.Background(), COIN, FIAT, time.Time{})
+	require.Equal(t, btclists.ErrInvalidToken, err)
+
+	_, err = market.Rate(context.Background(), COIN, FIAT, time.Time{})
+	require.Equal(t, pkg.ErrProvidersUnavailable, err)
+	require.Equal(t, 1, calls)
+}
+
+func TestFailoverMarket_Candles(t *testing.T) {
+	var candle = btclists.Candle{Coin: COIN, Fiat: FIAT, Resolution: btclists.Resolution1Min, Start: someTime, End: someTime.Add(time.Minute)}
+
+	var primaryCalls int
+	var primary = new(MockCandleMarket)
+	primary.CandlesFunc = func(ctx context.Context, coin string, fiat string, from time.Time, to time.Time, limit int) ([]btclists.Candle, error) {
+		primaryCalls++
+		return nil, btclists.ErrLimitReached
+	}
+
+	var secondary = new(MockCandleMarket)
+	secondary.CandlesFunc = func(ctx context.Context, coin string, fiat string, from time.Time, to time.Time, limit int) ([]btclists.Candle, error) {
+		return []btclists.Candle{candle}, nil
+	}
+
+	t.Logf("Should pass candles through to the first available provider able to provide them")
+	{
+		var market = pkg.NewFailoverMarket(
+			time.Hour,
+			pkg.MarketSource{Name: "coingecko", API: newFixedMarket(7000, nil)},
+			pkg.MarketSource{Name: "coinapi", API: primary},
+			pkg.MarketSource{Name: "kraken", API: secondary},
+		)
+
+		var candles, err = market.Candles(context.Background(), COIN, FIAT, someTime, someTimeLater, 10)
+		require.NoError(t, err)
+		require.Equal(t, []btclists.Candle{candle}, candles)
+
+		var status = market.Status()
+		require.Equal(t, pkg.BreakerClosed, status[0].State)
+		require.Equal(t, pkg.BreakerOpen, status[1].State)
+		require.True(t, status[2].Serving)
+
+		_, err = market.Candles(context.Background(), COIN, FIAT, someTime, someTimeLater, 10)
+		require.NoError(t, err)
+		require.Equal(t, 1, primaryCalls)
+	}
+
+	t.Logf("Should fail where no provider is able to provide candles")
+	{
+		var market = pkg.NewFailoverMarket(time.Hour, pkg.MarketSource{Name: "coingecko", API: newFixedMarket(7000, nil)})
+
+		var _, err = market.Candles(context.Background(), COIN, FIAT, someTime, someTimeLater, 10)
+		require.Equal(t, pkg.ErrCandlesUnsupported, err)
+	}
+}
+
+func TestFailoverMarket_HalfOpen_SingleProbe(t *testing.T) {
+	var probing = make(chan struct{})
+	var release = make(chan struct{})
+
+	var primaryCalls int
+	var primaryErr = btclists.ErrLimitReached
+	var primary = newFixedMarket(7000, nil)
+	primary.RateFunc = func(ctx context.Context, coin string, fiat string, at time.Time) (btclists.Rate, error) {
+		primaryCalls++
+		if primaryErr != nil {
+			return btclists.Rate{}, primaryErr
+		}
+
+		close(probing)
+		<-release
+		return btclists.Rate{Coin: coin, Fiat: fiat, Date: someTime, Rate: decimal.NewFromFloat(7000)}, nil
+	}
+
+	var market = pkg.NewFailoverMarket(
+		10*time.Millisecond,
+		pkg.MarketSource{Name: "coinapi", API: primary},
+		pkg.MarketSource{Name: "kraken", API: newFixedMarket(7010, nil)},
+	)
+
+	var _, err = market.Rate(context.Background(), COIN, FIAT, time.Time{})
+	require.NoError(t, err)
+	require.Equal(t, pkg.BreakerOpen, market.Status()[0].State)
+
+	time.Sleep(20 * time.Millisecond)
+	primaryErr = nil
+
+	var probed = make(chan btclists.Rate)
+	go func() {
+		var rate, _ = market.Rate(context.Background(), COIN, FIAT, time.Time{})
+		probed <- rate
+	}()
+	<-probing
+
+	t.Logf("Should skip half-open provider while it is probed")
+	{
+		var rate, err = market.Rate(context.Background(), COIN, FIAT, time.Time{})
+		require.NoError(t, err)
+		require.Equal(t, "7010", rate.Rate.String())
+	}
+
+	close(release)
+	require.Equal(t, "7000", (<-probed).Rate.String())
+	require.Equal(t, 2, primaryCalls)
+	require.Equal(t, pkg.BreakerClosed, market.Status()[0].State)
+}
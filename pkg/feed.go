@@ -0,0 +1,312 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/influx6/btclists"
+)
+
+const (
+	// DefaultFeedBuffer is the default number of rates buffered for each subscriber
+	// of a RateHub before older rates are dropped.
+	DefaultFeedBuffer = 64
+
+	// MaxStreamReplay is the maximum number of missed rates replayed to a resuming
+	// stream client at once.
+	MaxStreamReplay = 1000
+)
+
+var (
+	_ btclists.RatesDB = (*PublishingRatesDB)(nil)
+)
+
+// Subscription receives rates published to a RateHub for it's pairs.
+type Subscription struct {
+	// Rates delivers published rates, it is closed once subscription is
+	// unsubscribed or the hub is closed.
+	Rates <-chan btclists.Rate
+
+	rates   chan btclists.Rate
+	pairs   Pairs
+	dropped uint64
+}
+
+// Dropped returns the number of rates dropped for subscription for not
+// being received fast enough.
+func (s *Subscription) Dropped() uint64 {
+	return atomic.LoadUint64(&s.dropped)
+}
+
+func (s *Subscription) wants(rate btclists.Rate) bool {
+	return len(s.pairs) == 0 || s.pairs.Has(rate.Coin, rate.Fiat)
+}
+
+// RateHub broadcasts published rates to all subscribers of their pair.
+//
+// Publishing never blocks on subscribers, each subscriber has a buffer of rates and
+// where a subscriber falls behind and it's buffer is full, it's oldest buffered rate
+// is dropped for the new one, so slow consumers always catch up to the latest rates
+// without holding back ingestion.
+type RateHub struct {
+	// Buffer sets the number of rates buffered for each subscriber, defaults
+	// to DefaultFeedBuffer.
+	Buffer int
+
+	mu          sync.RWMutex
+	closed      bool
+	subscribers map[*Subscription]struct{}
+}
+
+func NewRateHub() *RateHub {
+	return &RateHub{
+		Buffer:      DefaultFeedBuffer,
+		subscribers: map[*Subscription]struct{}{},
+	}
+}
+
+// Subscribe returns a new Subscription for rates of provided pairs, where pairs
+// is empty, rates of all pairs are received.
+func (h *RateHub) Subscribe(pairs Pairs) *Subscription {
+	var buffer = h.Buffer
+	if buffer <= 0 {
+		buffer = DefaultFeedBuffer
+	}
+
+	var rates = make(chan btclists.Rate, buffer)
+	var sub = &Subscription{Rates: rates, rates: rates, pairs: pairs}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		close(rates)
+		return sub
+	}
+
+	if h.subscribers == nil {
+		h.subscribers = map[*Subscription]struct{}{}
+	}
+	h.subscribers[sub] = struct{}{}
+	return sub
+}
+
+// Unsubscribe removes provided subscription from hub, closing it's Rates channel.
+func (h *RateHub) Unsubscribe(sub *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.subscribers[sub]; !ok {
+		return
+	}
+
+	delete(h.subscribers, sub)
+	close(sub.rates)
+}
+
+// Subscribers returns the number of current subscribers.
+func (h *RateHub) Subscribers() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.subscribers)
+}
+
+// Publish delivers rate to all subscribers of it's pair without blocking.
+func (h *RateHub) Publish(rate btclists.Rate) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for sub := range h.subscribers {
+		if !sub.wants(rate) {
+			continue
+		}
+
+		select {
+		case sub.rates <- rate:
+			continue
+		default:
+		}
+
+		// buffer is full, drop oldest rate to make room for latest.
+		select {
+		case <-sub.rates:
+			atomic.AddUint64(&sub.dropped, 1)
+		default:
+		}
+
+		select {
+		case sub.rates <- rate:
+		default:
+			atomic.AddUint64(&sub.dropped, 1)
+		}
+	}
+}
+
+// Close unsubscribes all subscribers, subscriptions made after are closed immediately.
+func (h *RateHub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	for sub := range h.subscribers {
+		delete(h.subscribers, sub)
+		close(sub.rates)
+	}
+}
+
+// PublishingRatesDB wraps a btclists.RatesDB, publishing every rate successfully stored
+// with Add (i.e by PeriodicRatingUpdate) to Hub.
+//
+// Rates stored with AddBatch are not published, as those are historical rates
+// (e.g backfills and imports) and not live updates.
+type PublishingRatesDB struct {
+	btclists.RatesDB
+	Hub *RateHub
+}
+
+func NewPublishingRatesDB(db btclists.RatesDB, hub *RateHub) *PublishingRatesDB {
+	return &PublishingRatesDB{RatesDB: db, Hub: hub}
+}
+
+// Add stores rate into underline db, publishing it once stored.
+func (p *PublishingRatesDB) Add(ctx context.Context, rate btclists.Rate) error {
+	if err := p.RatesDB.Add(ctx, rate); err != nil {
+		return err
+	}
+
+	p.Hub.Publish(rate)
+	return nil
+}
+
+// streamCursor holds the date of the last rate sent to a stream client for each pair.
+//
+// Rates of different pairs share dates (e.g rates aggregated for the same period) and may
+// arrive out of order, hence each pair is resumed after it's own last rate rather than
+// after the last rate sent of any pair.
+type streamCursor map[btclists.Pair]time.Time
+
+// parseStreamCursor parses a cursor in the format {coin}/{fiat}={date},... (e.g
+// BTC/USD=2020-04-08T14:00:00Z,ETH/USD=2020-04-08T13:59:00Z) as sent as ID of stream
+// events, a lone date is taken as the cursor of all pairs.
+//
+// Provided pairs missing from cursor had no rate sent yet, they are resumed after the
+// earliest date of cursor.
+func parseStreamCursor(id string, pairs Pairs) (streamCursor, error) {
+	var cursor = streamCursor{}
+
+	if !strings.Contains(id, "=") {
+		var date, err = time.Parse(time.RFC3339Nano, id)
+		if err != nil {
+			return nil, err
+		}
+		for pair := range pairs {
+			cursor[pair] = date.UTC()
+		}
+		return cursor, nil
+	}
+
+	var earliest time.Time
+	for _, item := range strings.Split(id, ",") {
+		var parts = strings.SplitN(item, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid cursor %q, expected format {coin}/{fiat}={date}", item)
+		}
+
+		var pairParts = strings.Split(parts[0], "/")
+		if len(pairParts) != 2 {
+			return nil, fmt.Errorf("invalid pair %q, expected format {coin}/{fiat}", parts[0])
+		}
+
+		var date, err = time.Parse(time.RFC3339Nano, parts[1])
+		if err != nil {
+			return nil, err
+		}
+
+		var pair = normalizePair(pairParts[0], pairParts[1])
+		if !pairs.Has(pair.Coin, pair.Fiat) {
+			continue
+		}
+
+		cursor[pair] = date.UTC()
+		if earliest.IsZero() || date.Before(earliest) {
+			earliest = date.UTC()
+		}
+	}
+
+	if earliest.IsZero() {
+		return nil, errors.New("cursor holds none of the requested pairs")
+	}
+
+	for pair := range pairs {
+		if _, ok := cursor[pair]; !ok {
+			cursor[pair] = earliest
+		}
+	}
+	return cursor, nil
+}
+
+// Sent returns true if rate is not after the last rate sent for it's pair.
+func (c streamCursor) Sent(rate btclists.Rate) bool {
+	var last, ok = c[normalizePair(rate.Coin, rate.Fiat)]
+	return ok && !rate.Date.After(last)
+}
+
+// Advance records rate as the last rate sent for it's pair.
+func (c streamCursor) Advance(rate btclists.Rate) {
+	c[normalizePair(rate.Coin, rate.Fiat)] = rate.Date.UTC()
+}
+
+// String returns cursor in the format parsed by parseStreamCursor, ordered by pair.
+func (c streamCursor) String() string {
+	var items = make([]string, 0, len(c))
+	for pair, date := range c {
+		items = append(items, pair.String()+"="+date.Format(time.RFC3339Nano))
+	}
+
+	sort.Strings(items)
+	return strings.Join(items, ",")
+}
+
+// replayRates returns stored rates of cursor's pairs dated after the cursor of their
+// pair up to now, ordered by date ascending (then by pair) and limited to limit rates.
+// The returned bool reports whether more rates remain after those returned.
+func replayRates(ctx context.Context, pager btclists.RatePager, cursor streamCursor, limit int) ([]btclists.Rate, bool, error) {
+	var now = time.Now().UTC()
+
+	// each pair's page holds it's earliest rates, so the earliest rates of
+	// all pairs are within their union.
+	var rates []btclists.Rate
+	var more bool
+	for pair, after := range cursor {
+		var page = btclists.Page{Limit: limit, After: after, Order: btclists.Ascending}
+		var pairRates, err = pager.RangePage(ctx, pair.Coin, pair.Fiat, after, now, page)
+		if err != nil {
+			return nil, false, err
+		}
+
+		if len(pairRates) >= limit {
+			more = true
+		}
+		rates = append(rates, pairRates...)
+	}
+
+	sort.SliceStable(rates, func(i, j int) bool {
+		if !rates[i].Date.Equal(rates[j].Date) {
+			return rates[i].Date.Before(rates[j].Date)
+		}
+		return normalizePair(rates[i].Coin, rates[i].Fiat).String() < normalizePair(rates[j].Coin, rates[j].Fiat).String()
+	})
+
+	// rates cut off are after the cursor of their pair, so are replayed on resume.
+	if len(rates) > limit {
+		rates = rates[:limit]
+		more = true
+	}
+	return rates, more, nil
+}
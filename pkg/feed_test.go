@@ -0,0 +1,310 @@
+package pkg_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+
+	"github.com/influx6/btclists"
+	"github.com/influx6/btclists/pkg"
+)
+
+func TestRateHub_Publish(t *testing.T) {
+	var hub = pkg.NewRateHub()
+
+	var all = hub.Subscribe(nil)
+	var btc = hub.Subscribe(pkg.NewPairs(btclists.Pair{Coin: "BTC", Fiat: "USD"}))
+	require.Equal(t, 2, hub.Subscribers())
+
+	var btcRate = btclists.Rate{Coin: "BTC", Fiat: "USD", Rate: decimal.NewFromFloat(7200.5)}
+	var ethRate = btclists.Rate{Coin: "ETH", Fiat: "USD", Rate: decimal.NewFromFloat(170.5)}
+	hub.Publish(btcRate)
+	hub.Publish(ethRate)
+
+	require.Equal(t, btcRate, <-all.Rates)
+	require.Equal(t, ethRate, <-all.Rates)
+	require.Equal(t, btcRate, <-btc.Rates)
+	require.Len(t, btc.Rates, 0)
+
+	hub.Unsubscribe(btc)
+	require.Equal(t, 1, hub.Subscribers())
+
+	var _, open = <-btc.Rates
+	require.False(t, open)
+
+	hub.Close()
+	_, open = <-all.Rates
+	require.False(t, open)
+
+	_, open = <-hub.Subscribe(nil).Rates
+	require.False(t, open)
+}
+
+func TestRateHub_Publish_SlowSubscriber(t *testing.T) {
+	var hub = pkg.NewRateHub()
+	hub.Buffer = 2
+
+	var sub = hub.Subscribe(nil)
+
+	var published = make(chan struct{})
+	go func() {
+		defer close(published)
+		for i := 1; i <= 5; i++ {
+			hub.Publish(btclists.Rate{Coin: COIN, Fiat: FIAT, Rate: decimal.NewFromInt(int64(i))})
+		}
+	}()
+
+	select {
+	case <-published:
+	case <-time.After(time.Second):
+		t.Fatal("publish blocked on slow subscriber")
+	}
+
+	require.Equal(t, uint64(3), sub.Dropped())
+	require.Equal(t, "4", (<-sub.Rates).Rate.String())
+	require.Equal(t, "5", (<-sub.Rates).Rate.String())
+}
+
+func TestPublishingRatesDB_Add(t *testing.T) {
+	var hub = pkg.NewRateHub()
+	var sub = hub.Subscribe(nil)
+
+	var stored = btclists.Rate{Coin: COIN, Fiat: FIAT, Rate: decimal.NewFromFloat(7200.5)}
+	var failed = btclists.Rate{Coin: COIN, Fiat: FIAT, Rate: decimal.NewFromFloat(7201.5)}
+
+	var db = new(MockRateDB)
+	db.On("Add", stored).Return(nil)
+	db.On("Add", failed).Return(errors.New("bad db"))
+
+	var publisher = pkg.NewPublishingRatesDB(db, hub)
+	require.NoError(t, publisher.Add(context.Background(), stored))
+	require.Error(t, publisher.Add(context.Background(), failed))
+
+	require.Equal(t, stored, <-sub.Rates)
+	require.Len(t, sub.Rates, 0)
+	db.AssertExpectations(t)
+}
+
+func TestGetRateFeed(t *testing.T) {
+	var hub = pkg.NewRateHub()
+	var pairs = pkg.NewPairs(
+		btclists.Pair{Coin: "BTC", Fiat: "USD"},
+		btclists.Pair{Coin: "ETH", Fiat: "USD"},
+	)
+
+	var server = httptest.NewServer(pkg.GetRateFeed(hub, pairs))
+	defer server.Close()
+
+	var url = "ws" + strings.TrimPrefix(server.URL, "http")
+
+	var _, res, dialErr = websocket.DefaultDialer.Dial(url+"?pairs=LTC/USD", nil)
+	require.Error(t, dialErr)
+	require.Equal(t, http.StatusNotFound, res.StatusCode)
+
+	var conn, _, err = websocket.DefaultDialer.Dial(url+"?pairs=eth/usd", nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	require.Eventually(t, func() bool {
+		return hub.Subscribers() == 1
+	}, time.Second, 10*time.Millisecond)
+
+	var date = time.Date(2020, 4, 8, 14, 0, 0, 0, time.UTC)
+	hub.Publish(btclists.Rate{Date: date, Coin: "BTC", Fiat: "USD", Rate: decimal.NewFromFloat(7200.5)})
+	hub.Publish(btclists.Rate{Date: date, Coin: "ETH", Fiat: "USD", Rate: decimal.NewFromFloat(170.5)})
+
+	var message pkg.FeedMessage
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+	require.NoError(t, conn.ReadJSON(&message))
+	require.Equal(t, "ETH", message.Data.Coin)
+	require.Equal(t, "170.5", message.Data.Rate.String())
+	require.True(t, date.Equal(message.Data.Date))
+
+	hub.Close()
+	var _, _, readErr = conn.ReadMessage()
+	require.True(t, websocket.IsCloseError(readErr, websocket.CloseGoingAway))
+}
+
+func TestGetRateStream(t *testing.T) {
+	var hub = pkg.NewRateHub()
+	var pairs = pkg.NewPairs(btclists.Pair{Coin: COIN, Fiat: FIAT})
+
+	var last = time.Date(2020, 4, 8, 14, 0, 0, 0, time.UTC)
+	var missed = []btclists.Rate{
+		{Date: last.Add(time.Minute), Coin: COIN, Fiat: FIAT, Rate: decimal.NewFromFloat(7201.5)},
+		{Date: last.Add(2 * time.Minute), Coin: COIN, Fiat: FIAT, Rate: decimal.NewFromFloat(7202.5)},
+	}
+
+	var rates = new(RateServerMock)
+	rates.RangePageFunc = func(ctx context.Context, coin string, fiat string, from, to time.Time, page btclists.Page) ([]btclists.Rate, error) {
+		require.Equal(t, COIN, coin)
+		require.Equal(t, FIAT, fiat)
+		require.True(t, last.Equal(page.After))
+		require.Equal(t, btclists.Ascending, page.Order)
+		return missed, nil
+	}
+
+	var server = httptest.NewServer(pkg.GetRateStream(hub, rates, pairs))
+	defer server.Close()
+
+	var ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+
+	var req, reqErr = http.NewRequestWithContext(ctx, "GET", server.URL, nil)
+	require.NoError(t, reqErr)
+	req.Header.Set("Last-Event-ID", last.Format(time.RFC3339Nano))
+
+	var res, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	require.Equal(t, "text/event-stream", res.Header.Get("Content-Type"))
+
+	var reader = bufio.NewReader(res.Body)
+	for _, rate := range missed {
+		var id, message = readEvent(t, reader)
+		require.Equal(t, "BTC/USD="+rate.Date.Format(time.RFC3339Nano), id)
+		require.Equal(t, rate.Rate.String(), message.Data.Rate.String())
+	}
+
+	require.Eventually(t, func() bool {
+		return hub.Subscribers() == 1
+	}, time.Second, 10*time.Millisecond)
+
+	// already replayed rates are not sent twice.
+	hub.Publish(missed[1])
+
+	var live = btclists.Rate{Date: last.Add(3 * time.Minute), Coin: COIN, Fiat: FIAT, Rate: decimal.NewFromFloat(7203.5)}
+	hub.Publish(live)
+
+	var id, message = readEvent(t, reader)
+	require.Equal(t, "BTC/USD="+live.Date.Format(time.RFC3339Nano), id)
+	require.Equal(t, "7203.5", message.Data.Rate.String())
+}
+
+// readEvent reads the next event of a rate stream.
+func readEvent(t *testing.T, reader *bufio.Reader) (string, pkg.FeedMessage) {
+	var id string
+	var message pkg.FeedMessage
+	for {
+		var line, lineErr = reader.ReadString('\n')
+		require.NoError(t, lineErr)
+
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case line == "":
+			if id != "" {
+				return id, message
+			}
+		case strings.HasPrefix(line, "id: "):
+			id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "data: "):
+			require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &message))
+		}
+	}
+}
+
+func TestGetRateStream_Pairs(t *testing.T) {
+	var hub = pkg.NewRateHub()
+	var pairs = pkg.NewPairs(
+		btclists.Pair{Coin: "BTC", Fiat: "USD"},
+		btclists.Pair{Coin: "ETH", Fiat: "USD"},
+	)
+
+	var db = pkg.NewMemoryDB()
+	var publisher = pkg.NewPublishingRatesDB(db, hub)
+
+	var start = time.Date(2020, 4, 8, 14, 0, 0, 0, time.UTC)
+	var at = func(minutes int) time.Time {
+		return start.Add(time.Duration(minutes) * time.Minute)
+	}
+	var rate = func(coin string, minutes int) btclists.Rate {
+		return btclists.Rate{Date: at(minutes), Coin: coin, Fiat: "USD", Rate: decimal.NewFromInt(int64(minutes))}
+	}
+
+	require.NoError(t, db.AddBatch(context.Background(), []btclists.Rate{
+		rate("BTC", 1), rate("ETH", 1), rate("BTC", 2), rate("ETH", 2),
+	}))
+
+	var server = httptest.NewServer(pkg.GetRateStream(hub, db, pairs))
+	defer server.Close()
+
+	var connect = func(ctx context.Context, lastEventID string) *bufio.Reader {
+		var req, reqErr = http.NewRequestWithContext(ctx, "GET", server.URL+"?pairs=BTC/USD,ETH/USD", nil)
+		require.NoError(t, reqErr)
+		req.Header.Set("Last-Event-ID", lastEventID)
+
+		var res, err = http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		return bufio.NewReader(res.Body)
+	}
+
+	var expectEvent = func(reader *bufio.Reader, coin string, minutes int, id string) {
+		var eventID, message = readEvent(t, reader)
+		require.Equal(t, coin, message.Data.Coin)
+		require.True(t, at(minutes).Equal(message.Data.Date))
+		require.Equal(t, id, eventID)
+	}
+
+	t.Logf("Should resume each pair after it's own last rate and deliver all pairs sharing a date")
+	{
+		var ctx, cancel = context.WithCancel(context.Background())
+
+		var reader = connect(ctx, "BTC/USD="+at(2).Format(time.RFC3339)+",ETH/USD="+at(1).Format(time.RFC3339))
+		expectEvent(reader, "ETH", 2, "BTC/USD=2020-04-08T14:02:00Z,ETH/USD=2020-04-08T14:02:00Z")
+
+		require.Eventually(t, func() bool {
+			return hub.Subscribers() == 1
+		}, time.Second, 10*time.Millisecond)
+
+		var ctxBg = context.Background()
+		require.NoError(t, publisher.Add(ctxBg, rate("BTC", 3)))
+		require.NoError(t, publisher.Add(ctxBg, rate("ETH", 3)))
+		hub.Publish(rate("BTC", 2))
+		require.NoError(t, publisher.Add(ctxBg, rate("ETH", 5)))
+		require.NoError(t, publisher.Add(ctxBg, rate("BTC", 4)))
+
+		expectEvent(reader, "BTC", 3, "BTC/USD=2020-04-08T14:03:00Z,ETH/USD=2020-04-08T14:02:00Z")
+		expectEvent(reader, "ETH", 3, "BTC/USD=2020-04-08T14:03:00Z,ETH/USD=2020-04-08T14:03:00Z")
+		expectEvent(reader, "ETH", 5, "BTC/USD=2020-04-08T14:03:00Z,ETH/USD=2020-04-08T14:05:00Z")
+		expectEvent(reader, "BTC", 4, "BTC/USD=2020-04-08T14:04:00Z,ETH/USD=2020-04-08T14:05:00Z")
+		cancel()
+	}
+
+	t.Logf("Should replay rates of each pair missed since it's last rate")
+	{
+		var ctx, cancel = context.WithCancel(context.Background())
+		defer cancel()
+
+		var reader = connect(ctx, "BTC/USD=2020-04-08T14:03:00Z,ETH/USD=2020-04-08T14:03:00Z")
+		expectEvent(reader, "BTC", 4, "BTC/USD=2020-04-08T14:04:00Z,ETH/USD=2020-04-08T14:03:00Z")
+		expectEvent(reader, "ETH", 5, "BTC/USD=2020-04-08T14:04:00Z,ETH/USD=2020-04-08T14:05:00Z")
+	}
+}
+
+func TestGetRateStream_InvalidLastEventID(t *testing.T) {
+	var hub = pkg.NewRateHub()
+	var pairs = pkg.NewPairs(btclists.Pair{Coin: COIN, Fiat: FIAT})
+
+	var req = httptest.NewRequest("GET", "/v1/stream", nil)
+	req.Header.Set("Last-Event-ID", "yesterday")
+
+	var res = httptest.NewRecorder()
+	pkg.GetRateStream(hub, new(RateServerMock), pairs)(res, req)
+
+	require.Equal(t, http.StatusBadRequest, res.Code)
+	require.Contains(t, res.Body.String(), pkg.ErrInvalidEventID.Error())
+	require.Equal(t, 0, hub.Subscribers())
+}
@@ -0,0 +1,194 @@
+package pkg
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/influx6/btclists"
+)
+
+const (
+	// DefaultCadence is the expected time between stored rates, matching
+	// the interval of PeriodicRatingUpdate.
+	DefaultCadence = 1 * time.Minute
+
+	// MaxGapRequests is the maximum number of gaps fetched individually when serving
+	// a request, beyond which gaps are fetched as a single window.
+	MaxGapRequests = 10
+)
+
+// Gap is a time range of a pair missing rates, From and To are the dates of the
+// stored rates surrounding the gap or the bounds of the scanned range.
+type Gap struct {
+	Coin string    `json:"coin"`
+	Fiat string    `json:"fiat"`
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+}
+
+// RangeScan summarises the stored rates of a pair within a time range, as needed to
+// decide which parts of the range to pull from the API.
+type RangeScan struct {
+	// Count is the number of stored rates within time range.
+	Count int
+
+	// Gaps are the gaps within time range where stored rates are further apart than
+	// twice the cadence (see FindGaps), coalesced into a single gap beyond MaxGapRequests.
+	Gaps []Gap
+
+	// Coverage is the ratio (0 to 1) of time range covered by stored rates (see CoverageOf).
+	Coverage float64
+}
+
+// RangeScanner is implemented by stores able to scan a time range for gaps and coverage
+// without returning the rates of the range.
+type RangeScanner interface {
+	ScanRange(ctx context.Context, coin string, fiat string, from time.Time, to time.Time, cadence time.Duration) (RangeScan, error)
+}
+
+// ScanRates returns the RangeScan of provided rates, as RangeScanner implementations do
+// for the rates they store.
+func ScanRates(rates []btclists.Rate, from time.Time, to time.Time, cadence time.Duration) RangeScan {
+	return RangeScan{
+		Count:    len(rates),
+		Gaps:     coalesceGaps(FindGaps(rates, from, to, cadence, cadence), MaxGapRequests),
+		Coverage: CoverageOf(rates, from, to, cadence),
+	}
+}
+
+// FindGaps returns the gaps within provided time range where consecutive rates (or the
+// range bounds and their nearest rate) are further apart than cadence plus tolerance.
+//
+// Rates may be provided in any order.
+func FindGaps(rates []btclists.Rate, from time.Time, to time.Time, cadence time.Duration, tolerance time.Duration) []Gap {
+	var dates = make([]time.Time, 0, len(rates)+2)
+	dates = append(dates, from)
+	for _, rate := range rates {
+		dates = append(dates, rate.Date)
+	}
+	dates = append(dates, to)
+
+	sort.Slice(dates, func(i, j int) bool {
+		return dates[i].Before(dates[j])
+	})
+
+	var gaps []Gap
+	for index := 1; index < len(dates); index++ {
+		var previous, next = dates[index-1], dates[index]
+		if next.Sub(previous) > cadence+tolerance {
+			gaps = append(gaps, Gap{From: previous, To: next})
+		}
+	}
+	return gaps
+}
+
+// CoverageOf returns the ratio (0 to 1) of provided time range covered by rates, where
+// each rate covers the period of it's resolution (or cadence for spot rates) after it's date.
+func CoverageOf(rates []btclists.Rate, from time.Time, to time.Time, cadence time.Duration) float64 {
+	var total = to.Sub(from)
+	if total <= 0 {
+		if len(rates) == 0 {
+			return 0
+		}
+		return 1
+	}
+
+	var sorted = make([]btclists.Rate, len(rates))
+	copy(sorted, rates)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Date.Before(sorted[j].Date)
+	})
+
+	var covered time.Duration
+	var coveredTill = from
+	for _, rate := range sorted {
+		var span = rate.Resolution.Duration()
+		if span < cadence {
+			span = cadence
+		}
+
+		var start, end = rate.Date, rate.Date.Add(span)
+		if start.Before(coveredTill) {
+			start = coveredTill
+		}
+		if end.After(to) {
+			end = to
+		}
+		if end.After(start) {
+			covered += end.Sub(start)
+			coveredTill = end
+		}
+	}
+
+	return float64(covered) / float64(total)
+}
+
+// coalesceGaps returns gaps as is if within max, else a single gap spanning all of them.
+func coalesceGaps(gaps []Gap, max int) []Gap {
+	if len(gaps) <= max {
+		return gaps
+	}
+
+	var gap = gaps[0]
+	gap.To = gaps[len(gaps)-1].To
+	return []Gap{gap}
+}
+
+// candleGaps returns the gaps within provided time range where consecutive candles start
+// further apart than twice their resolution, coalesced beyond MaxGapRequests.
+func candleGaps(candles []btclists.Candle, from time.Time, to time.Time, resolution time.Duration) []Gap {
+	var starts = make([]btclists.Rate, 0, len(candles))
+	for _, candle := range candles {
+		starts = append(starts, btclists.Rate{Date: candle.Start})
+	}
+	return coalesceGaps(FindGaps(starts, from, to, resolution, resolution), MaxGapRequests)
+}
+
+// mergeCandles returns stored candles with fetched candles of resolution not already
+// known by start, ordered by start of period.
+func mergeCandles(stored []btclists.Candle, fetched []btclists.Candle, resolution btclists.Resolution) []btclists.Candle {
+	var known = make(map[int64]struct{}, len(stored))
+	var merged = make([]btclists.Candle, 0, len(stored)+len(fetched))
+	for _, candle := range stored {
+		known[candle.Start.UnixNano()] = struct{}{}
+		merged = append(merged, candle)
+	}
+
+	for _, candle := range fetched {
+		if _, ok := known[candle.Start.UnixNano()]; ok || candle.Resolution != resolution {
+			continue
+		}
+		known[candle.Start.UnixNano()] = struct{}{}
+		merged = append(merged, candle)
+	}
+
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].Start.Before(merged[j].Start)
+	})
+	return merged
+}
+
+// mergeRates returns stored rates with fetched rates not already known by date,
+// ordered by date in descending order.
+func mergeRates(stored []btclists.Rate, fetched []btclists.Rate) []btclists.Rate {
+	var known = make(map[int64]struct{}, len(stored))
+	var merged = make([]btclists.Rate, 0, len(stored)+len(fetched))
+	for _, rate := range stored {
+		known[rate.Date.UnixNano()] = struct{}{}
+		merged = append(merged, rate)
+	}
+
+	for _, rate := range fetched {
+		if _, ok := known[rate.Date.UnixNano()]; ok {
+			continue
+		}
+		known[rate.Date.UnixNano()] = struct{}{}
+		merged = append(merged, rate)
+	}
+
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].Date.After(merged[j].Date)
+	})
+	return merged
+}
@@ -0,0 +1,84 @@
+package pkg_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influx6/btclists"
+	"github.com/influx6/btclists/pkg"
+)
+
+func TestFindGaps(t *testing.T) {
+	var from = time.Date(2020, 4, 8, 14, 0, 0, 0, time.UTC)
+	var to = from.Add(10 * time.Minute)
+
+	var rates = []btclists.Rate{
+		{Date: from.Add(5 * time.Minute)},
+		{Date: from},
+		{Date: from.Add(time.Minute)},
+		{Date: from.Add(6 * time.Minute)},
+	}
+
+	var gaps = pkg.FindGaps(rates, from, to, time.Minute, time.Minute)
+	require.Len(t, gaps, 2)
+	require.Equal(t, from.Add(time.Minute), gaps[0].From)
+	require.Equal(t, from.Add(5*time.Minute), gaps[0].To)
+	require.Equal(t, from.Add(6*time.Minute), gaps[1].From)
+	require.Equal(t, to, gaps[1].To)
+
+	t.Logf("Should treat an empty range as a single gap")
+	{
+		var gaps = pkg.FindGaps(nil, from, to, time.Minute, time.Minute)
+		require.Equal(t, []pkg.Gap{{From: from, To: to}}, gaps)
+	}
+}
+
+func TestCoverageOf(t *testing.T) {
+	var from = time.Date(2020, 4, 8, 14, 0, 0, 0, time.UTC)
+	var to = from.Add(10 * time.Minute)
+
+	t.Logf("Should use cadence for spot rates")
+	{
+		var rates = []btclists.Rate{{Date: from}, {Date: from.Add(time.Minute)}}
+		require.Equal(t, 0.2, pkg.CoverageOf(rates, from, to, time.Minute))
+	}
+
+	t.Logf("Should use resolution of rates")
+	{
+		var rates = []btclists.Rate{{Date: from, Resolution: btclists.Resolution2Min}}
+		require.Equal(t, 0.2, pkg.CoverageOf(rates, from, to, time.Minute))
+	}
+
+	t.Logf("Should not count overlapping rates twice")
+	{
+		var rates = []btclists.Rate{{Date: from, Resolution: btclists.Resolution2Min}, {Date: from.Add(time.Minute)}}
+		require.Equal(t, 0.2, pkg.CoverageOf(rates, from, to, time.Minute))
+	}
+
+	t.Logf("Should cap coverage at end of range")
+	{
+		var rates = []btclists.Rate{{Date: to.Add(-time.Minute), Resolution: btclists.Resolution1Hour}}
+		require.Equal(t, 0.1, pkg.CoverageOf(rates, from, to, time.Minute))
+	}
+}
+
+func TestScanRates(t *testing.T) {
+	var from = time.Date(2020, 4, 8, 14, 0, 0, 0, time.UTC)
+	var to = from.Add(time.Hour)
+
+	var rates []btclists.Rate
+	for index := 0; index < 60; index += 5 {
+		rates = append(rates, btclists.Rate{Date: from.Add(time.Duration(index) * time.Minute)})
+	}
+
+	var scan = pkg.ScanRates(rates, from, to, time.Minute)
+	require.Equal(t, 12, scan.Count)
+	require.Equal(t, 0.2, scan.Coverage)
+
+	t.Logf("Should coalesce gaps beyond MaxGapRequests")
+	{
+		require.Equal(t, []pkg.Gap{{From: from, To: to}}, scan.Gaps)
+	}
+}
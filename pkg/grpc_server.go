@@ -0,0 +1,226 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/influx6/btclists"
+	"github.com/influx6/btclists/rpc"
+)
+
+var (
+	ErrNoTimeRange = errors.New("from and to timestamps are required")
+
+	_ rpc.RateServiceServer = (*RateServer)(nil)
+)
+
+// RateServer implements rpc.RateServiceServer, serving the gRPC equivalent of the
+// http API handlers for pairs within it's Pairs allow-list.
+//
+// Rates are streamed by Subscribe as they are published to Hub (see PublishingRatesDB).
+type RateServer struct {
+	Rates    btclists.RateService
+	Averages btclists.RatingsAverageService
+	Hub      *RateHub
+	Pairs    Pairs
+}
+
+func NewRateServer(rates btclists.RateService, averages btclists.RatingsAverageService, hub *RateHub, pairs Pairs) *RateServer {
+	return &RateServer{
+		Rates:    rates,
+		Averages: averages,
+		Hub:      hub,
+		Pairs:    pairs,
+	}
+}
+
+// Latest returns latest rate of requested pair.
+func (s *RateServer) Latest(ctx context.Context, req *rpc.LatestRequest) (*rpc.Rate, error) {
+	var pair, err = s.pair(req.Coin, req.Fiat)
+	if err != nil {
+		return nil, err
+	}
+
+	var latest, rateErr = s.Rates.Latest(ctx, pair.Coin, pair.Fiat)
+	if rateErr != nil {
+		return nil, rpcError(rateErr)
+	}
+	return rateToProto(latest)
+}
+
+// At returns rate of requested pair around requested time.
+func (s *RateServer) At(ctx context.Context, req *rpc.AtRequest) (*rpc.Rate, error) {
+	var pair, err = s.pair(req.Coin, req.Fiat)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.At == nil {
+		return nil, status.Error(codes.InvalidArgument, ErrNoTimestamp.Error())
+	}
+
+	var at, atErr = ptypes.Timestamp(req.At)
+	if atErr != nil {
+		return nil, status.Error(codes.InvalidArgument, ErrInvalidTimestamp.Error())
+	}
+
+	var rate, rateErr = s.Rates.At(ctx, pair.Coin, pair.Fiat, at)
+	if rateErr != nil {
+		return nil, rpcError(rateErr)
+	}
+	return rateToProto(rate)
+}
+
+// Range returns all known rates of requested pair within requested time range.
+func (s *RateServer) Range(ctx context.Context, req *rpc.RangeRequest) (*rpc.RangeResponse, error) {
+	var pair, err = s.pair(req.Coin, req.Fiat)
+	if err != nil {
+		return nil, err
+	}
+
+	var from, to, rangeErr = timeRange(req.From, req.To)
+	if rangeErr != nil {
+		return nil, rangeErr
+	}
+
+	var rates, ratesErr = s.Rates.Range(ctx, pair.Coin, pair.Fiat, from, to)
+	if ratesErr != nil {
+		return nil, rpcError(ratesErr)
+	}
+
+	var res = &rpc.RangeResponse{Rates: make([]*rpc.Rate, 0, len(rates))}
+	for _, rate := range rates {
+		var message, messageErr = rateToProto(rate)
+		if messageErr != nil {
+			return nil, messageErr
+		}
+		res.Rates = append(res.Rates, message)
+	}
+	return res, nil
+}
+
+// AverageForRange returns average rate of requested pair within requested time range.
+func (s *RateServer) AverageForRange(ctx context.Context, req *rpc.AverageForRangeRequest) (*rpc.AverageForRangeResponse, error) {
+	var pair, err = s.pair(req.Coin, req.Fiat)
+	if err != nil {
+		return nil, err
+	}
+
+	var from, to, rangeErr = timeRange(req.From, req.To)
+	if rangeErr != nil {
+		return nil, rangeErr
+	}
+
+	var average, averageErr = s.Averages.AverageForRange(ctx, pair.Coin, pair.Fiat, from, to)
+	if averageErr != nil {
+		return nil, rpcError(averageErr)
+	}
+	return &rpc.AverageForRangeResponse{Average: average.String()}, nil
+}
+
+// Subscribe streams rates of requested pairs as they are published, till client goes away
+// or Hub is closed. All pairs in allow-list are streamed where none are requested.
+//
+// Clients falling behind miss older rates in favour of latest ones, see RateHub.
+func (s *RateServer) Subscribe(req *rpc.SubscribeRequest, stream rpc.RateService_SubscribeServer) error {
+	var subscribed = s.Pairs
+	if len(req.Pairs) != 0 {
+		subscribed = Pairs{}
+		for _, requested := range req.Pairs {
+			var pair, err = s.pair(requested.Coin, requested.Fiat)
+			if err != nil {
+				return err
+			}
+			subscribed.Add(pair.Coin, pair.Fiat)
+		}
+	}
+
+	var sub = s.Hub.Subscribe(subscribed)
+	defer s.Hub.Unsubscribe(sub)
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case rate, ok := <-sub.Rates:
+			if !ok {
+				return status.Error(codes.Unavailable, "rate feed closed")
+			}
+
+			var message, err = rateToProto(rate)
+			if err != nil {
+				return err
+			}
+
+			if sendErr := stream.Send(message); sendErr != nil {
+				log.Printf("[BTC Listings] | [ERROR] | [GRPC] | Failed to send rate | %s\n", sendErr)
+				return sendErr
+			}
+		}
+	}
+}
+
+// pair normalizes and validates provided pair against allow-list.
+func (s *RateServer) pair(coin string, fiat string) (btclists.Pair, error) {
+	var pair = normalizePair(coin, fiat)
+	if !s.Pairs.Has(pair.Coin, pair.Fiat) {
+		return pair, status.Error(codes.NotFound, ErrUnsupportedPair.Error())
+	}
+	return pair, nil
+}
+
+// timeRange validates and converts requested time range.
+func timeRange(fromTs *timestamp.Timestamp, toTs *timestamp.Timestamp) (time.Time, time.Time, error) {
+	if fromTs == nil || toTs == nil {
+		return time.Time{}, time.Time{}, status.Error(codes.InvalidArgument, ErrNoTimeRange.Error())
+	}
+
+	var from, fromErr = ptypes.Timestamp(fromTs)
+	if fromErr != nil {
+		return time.Time{}, time.Time{}, status.Error(codes.InvalidArgument, "from timestamp value is invalid")
+	}
+
+	var to, toErr = ptypes.Timestamp(toTs)
+	if toErr != nil {
+		return time.Time{}, time.Time{}, status.Error(codes.InvalidArgument, "to timestamp value is invalid")
+	}
+
+	return from, to, nil
+}
+
+// rpcError maps service errors to gRPC status errors as the http API handlers
+// map them to status codes.
+func rpcError(err error) error {
+	switch err {
+	case btclists.ErrRateNotFound:
+		return status.Error(codes.NotFound, err.Error())
+	case btclists.ErrBudgetExhausted:
+		return status.Error(codes.ResourceExhausted, err.Error())
+	default:
+		return status.Error(codes.Internal, ErrUnableToService.Error())
+	}
+}
+
+func rateToProto(rate btclists.Rate) (*rpc.Rate, error) {
+	var date, err = ptypes.TimestampProto(rate.Date)
+	if err != nil {
+		return nil, status.Error(codes.Internal, ErrUnableToService.Error())
+	}
+
+	return &rpc.Rate{
+		Id:         int64(rate.Id),
+		Date:       date,
+		Rate:       rate.Rate.String(),
+		Coin:       rate.Coin,
+		Fiat:       rate.Fiat,
+		Resolution: string(rate.Resolution),
+		Sources:    rate.Sources,
+	}, nil
+}
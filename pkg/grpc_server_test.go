@@ -0,0 +1,152 @@
+package pkg_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/influx6/btclists"
+	"github.com/influx6/btclists/pkg"
+	"github.com/influx6/btclists/rpc"
+)
+
+func newRateClient(t *testing.T, server *pkg.RateServer) (rpc.RateServiceClient, func()) {
+	var listener = bufconn.Listen(1024 * 1024)
+
+	var grpcServer = grpc.NewServer()
+	rpc.RegisterRateServiceServer(grpcServer, server)
+	go func() {
+		_ = grpcServer.Serve(listener)
+	}()
+
+	var conn, err = grpc.Dial(
+		"bufnet",
+		grpc.WithInsecure(),
+		grpc.WithContextDialer(func(ctx context.Context, s string) (net.Conn, error) {
+			return listener.Dial()
+		}),
+	)
+	require.NoError(t, err)
+
+	return rpc.NewRateServiceClient(conn), func() {
+		_ = conn.Close()
+		grpcServer.Stop()
+	}
+}
+
+func TestRateServer_Latest(t *testing.T) {
+	var rates = new(RateServerMock)
+	rates.LatestFunc = func(ctx context.Context, coin string, fiat string) (btclists.Rate, error) {
+		require.Equal(t, COIN, coin)
+		require.Equal(t, FIAT, fiat)
+		return someRate, nil
+	}
+
+	var pairs = pkg.NewPairs(btclists.Pair{Coin: COIN, Fiat: FIAT})
+	var client, closer = newRateClient(t, pkg.NewRateServer(rates, rates, pkg.NewRateHub(), pairs))
+	defer closer()
+
+	var rate, err = client.Latest(context.Background(), &rpc.LatestRequest{Coin: "btc", Fiat: "usd"})
+	require.NoError(t, err)
+	require.Equal(t, someRate.Rate.String(), rate.Rate)
+	require.Equal(t, COIN, rate.Coin)
+
+	var date, dateErr = ptypes.Timestamp(rate.Date)
+	require.NoError(t, dateErr)
+	require.True(t, someRate.Date.Equal(date))
+
+	_, err = client.Latest(context.Background(), &rpc.LatestRequest{Coin: "ETH", Fiat: "USD"})
+	require.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestRateServer_Latest_NotFound(t *testing.T) {
+	var rates = new(RateServerMock)
+	rates.LatestFunc = func(ctx context.Context, coin string, fiat string) (btclists.Rate, error) {
+		return btclists.Rate{}, btclists.ErrRateNotFound
+	}
+
+	var pairs = pkg.NewPairs(btclists.Pair{Coin: COIN, Fiat: FIAT})
+	var client, closer = newRateClient(t, pkg.NewRateServer(rates, rates, pkg.NewRateHub(), pairs))
+	defer closer()
+
+	var _, err = client.Latest(context.Background(), &rpc.LatestRequest{Coin: COIN, Fiat: FIAT})
+	require.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestRateServer_RangeAndAverage(t *testing.T) {
+	var from = time.Date(2020, 4, 8, 14, 0, 0, 0, time.UTC)
+	var to = from.Add(time.Hour)
+
+	var rates = new(RateServerMock)
+	rates.RangeFunc = func(ctx context.Context, coin string, fiat string, start, end time.Time) ([]btclists.Rate, error) {
+		require.True(t, from.Equal(start))
+		require.True(t, to.Equal(end))
+		return []btclists.Rate{someRate, someRate}, nil
+	}
+	rates.AverageForRangeFunc = func(ctx context.Context, coin string, fiat string, start, end time.Time) (decimal.Decimal, error) {
+		require.True(t, from.Equal(start))
+		require.True(t, to.Equal(end))
+		return decimal.NewFromFloat(7200.25), nil
+	}
+
+	var pairs = pkg.NewPairs(btclists.Pair{Coin: COIN, Fiat: FIAT})
+	var client, closer = newRateClient(t, pkg.NewRateServer(rates, rates, pkg.NewRateHub(), pairs))
+	defer closer()
+
+	var fromTs, _ = ptypes.TimestampProto(from)
+	var toTs, _ = ptypes.TimestampProto(to)
+
+	var ranged, err = client.Range(context.Background(), &rpc.RangeRequest{Coin: COIN, Fiat: FIAT, From: fromTs, To: toTs})
+	require.NoError(t, err)
+	require.Len(t, ranged.Rates, 2)
+
+	var average, averageErr = client.AverageForRange(context.Background(), &rpc.AverageForRangeRequest{Coin: COIN, Fiat: FIAT, From: fromTs, To: toTs})
+	require.NoError(t, averageErr)
+	require.Equal(t, "7200.25", average.Average)
+
+	_, err = client.Range(context.Background(), &rpc.RangeRequest{Coin: COIN, Fiat: FIAT, From: fromTs})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestRateServer_Subscribe(t *testing.T) {
+	var hub = pkg.NewRateHub()
+	var pairs = pkg.NewPairs(
+		btclists.Pair{Coin: "BTC", Fiat: "USD"},
+		btclists.Pair{Coin: "ETH", Fiat: "USD"},
+	)
+
+	var client, closer = newRateClient(t, pkg.NewRateServer(new(RateServerMock), new(RateServerMock), hub, pairs))
+	defer closer()
+
+	var ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+
+	var stream, err = client.Subscribe(ctx, &rpc.SubscribeRequest{Pairs: []*rpc.Pair{{Coin: "ETH", Fiat: "USD"}}})
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		return hub.Subscribers() == 1
+	}, time.Second, 10*time.Millisecond)
+
+	hub.Publish(btclists.Rate{Date: someTime, Coin: "BTC", Fiat: "USD", Rate: decimal.NewFromFloat(7200.5)})
+	hub.Publish(btclists.Rate{Date: someTime, Coin: "ETH", Fiat: "USD", Rate: decimal.NewFromFloat(170.5)})
+
+	var rate, recvErr = stream.Recv()
+	require.NoError(t, recvErr)
+	require.Equal(t, "ETH", rate.Coin)
+	require.Equal(t, "170.5", rate.Rate)
+
+	cancel()
+	require.Eventually(t, func() bool {
+		return hub.Subscribers() == 0
+	}, time.Second, 10*time.Millisecond)
+}
@@ -1,25 +1,94 @@
 package pkg
 
 import (
+	"context"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"log"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/go-chi/chi"
+	"github.com/gorilla/websocket"
 	"github.com/shopspring/decimal"
 
 	"github.com/influx6/btclists"
 )
 
+const (
+	DefaultPageLimit = 100
+	MaxPageLimit     = 1000
+
+	feedWriteWait    = 10 * time.Second
+	feedPongWait     = 60 * time.Second
+	feedPingInterval = (feedPongWait * 9) / 10
+
+	streamRetry             = 1 * time.Second
+	streamHeartbeatInterval = 15 * time.Second
+)
+
 var (
-	ErrInvalidTimestamp = errors.New("timestamp is not valid")
-	ErrNoTimestamp      = errors.New("no timestamp provided, use t query")
-	ErrUnableToService  = errors.New("unable to service request at the moment")
+	ErrInvalidLimit      = errors.New("limit must be a number between 1 and 1000")
+	ErrInvalidCursor     = errors.New("cursor is not valid")
+	ErrInvalidOrder      = errors.New("order must be either asc or desc")
+	ErrInvalidTimestamp  = errors.New("timestamp is not valid")
+	ErrInvalidAmount     = errors.New("amount must be a decimal number")
+	ErrNoCurrencies      = errors.New("no currencies provided, use from and to query")
+	ErrInvalidPercentile = errors.New("percentiles must be a comma separated list of numbers between 0 and 100")
+	ErrInvalidEventID    = errors.New("last event id is not valid")
+	ErrNoTimestamp       = errors.New("no timestamp provided, use t query")
+	ErrUnableToService   = errors.New("unable to service request at the moment")
 )
 
 type RateResponse struct {
-	Data string `json:"data"`
+	Data     string   `json:"data"`
+	Coverage *float64 `json:"coverage,omitempty"`
+}
+
+type RangeResponse struct {
+	Data     []btclists.Rate `json:"data"`
+	Next     string          `json:"next,omitempty"`
+	Coverage *float64        `json:"coverage,omitempty"`
+}
+
+type CandlesResponse struct {
+	Data []btclists.Candle `json:"data"`
+}
+
+type ConversionResponse struct {
+	Data Conversion `json:"data"`
+}
+
+type StatsResponse struct {
+	Data btclists.Stats `json:"data"`
+}
+
+type BackfillResponse struct {
+	Data BackfillReport `json:"data"`
+}
+
+// FeedMessage is the message sent for each rate over a rate feed.
+type FeedMessage struct {
+	Data btclists.Rate `json:"data"`
+}
+
+type ProviderStatusResponse struct {
+	Data []ProviderStatus `json:"data"`
+}
+
+// feedUpgrader accepts connections from any origin, as rates are public and
+// dashboards are served from elsewhere.
+var feedUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	CheckOrigin: func(r *http.Request) bool {
+		return true
+	},
 }
 
 type RateError struct {
@@ -27,8 +96,9 @@ type RateError struct {
 }
 
 // NOTE: All http API handlers are written to support specified crypto-currency to
-// specific fiat currency. We have no need for supporting multiple coins and fiats,
-// as this is to be a simple implementation.
+// specific fiat currency. Where multiple pairs are to be served from a single
+// deployment, use the *ForPair variants which resolve the pair from the route
+// (i.e /{version}/{coin}/{fiat}/{route}) against a Pairs allow-list.
 
 // GetLatest uses provided RateService for specific fiat and coin to return last known
 // and available rate for giving pair from provided RateService.
@@ -123,9 +193,15 @@ func validateTimestampString(t string) (time.Time, error) {
 // GetAverageFor uses provided RateService returning price of for specific time range.
 // Timestamps are expected to be ISO 8601 format strings encoded properly (URL Encoded).
 //
-// Route: /{version}/{route}?from={timestamp}&to={timestamp} e.g /v1/average?from={timestamp}&to={timestamp}
+// Where averageService is a btclists.CoverageService, the ratio of time range covered by
+// known rates is returned as 'coverage'.
+//
+// A candle resolution (e.g 1MIN, 1HRS, 1DAY) may be provided with the 'resolution' query, which
+// is used where history needs to be pulled from the API.
+//
+// Route: /{version}/{route}?from={timestamp}&to={timestamp}&resolution={resolution} e.g /v1/average?from={timestamp}&to={timestamp}
 // Response Format: application/json
-// Response: { data: {price} } where 'price' is a float64 type.
+// Response: { data: {price}, coverage: {ratio} } where 'price' is a float64 type.
 // Error Response: { error: {error text} } with status code in range 400-500.
 //
 func GetAverageFor(averageService btclists.RatingsAverageService, ratingService btclists.RateService, fiat string, coin string) http.HandlerFunc {
@@ -137,6 +213,13 @@ func GetAverageFor(averageService btclists.RatingsAverageService, ratingService
 			return
 		}
 
+		request, err = withRequestedResolution(request)
+		if err != nil {
+			writer.WriteHeader(http.StatusBadRequest)
+			respondWithError(writer, err)
+			return
+		}
+
 		// if we are giving same time, just divert to at call
 		if from.Equal(to) {
 			var atRating, atErr = ratingService.At(request.Context(), coin, fiat, from)
@@ -169,7 +252,940 @@ func GetAverageFor(averageService btclists.RatingsAverageService, ratingService
 			return
 		}
 
-		respondWithRate(writer, average)
+		respondWithJSON(writer, RateResponse{
+			Data:     average.String(),
+			Coverage: coverageFor(request, averageService, coin, fiat, from, to),
+		})
+	}
+}
+
+// GetTimeWeightedAverage uses provided WeightedAverageService returning the time-weighted
+// average price (TWAP) for specific time range, where each rate is weighted by how long it held.
+// Timestamps are expected to be ISO 8601 format strings encoded properly (URL Encoded).
+//
+// Where averages is a btclists.CoverageService, the ratio of time range covered by
+// known rates is returned as 'coverage'.
+//
+// Route: /{version}/{route}?from={timestamp}&to={timestamp}&resolution={resolution} e.g /v1/twap?from={timestamp}&to={timestamp}
+// Response Format: application/json
+// Response: { data: {price}, coverage: {ratio} } where 'price' is a float64 type.
+// Error Response: { error: {error text} } with status code in range 400-500.
+//
+func GetTimeWeightedAverage(averages btclists.WeightedAverageService, fiat string, coin string) http.HandlerFunc {
+	return weightedAverage(averages.TimeWeightedAverage, averages, fiat, coin)
+}
+
+// GetVolumeWeightedAverage uses provided WeightedAverageService returning the volume-weighted
+// average price (VWAP) for specific time range, where each candle is weighted by it's traded volume.
+// Timestamps are expected to be ISO 8601 format strings encoded properly (URL Encoded).
+//
+// Route: /{version}/{route}?from={timestamp}&to={timestamp}&resolution={resolution} e.g /v1/vwap?from={timestamp}&to={timestamp}
+// Response Format: application/json
+// Response: { data: {price} } where 'price' is a float64 type.
+// Error Response: { error: {error text} } with status code in range 400-500.
+//
+func GetVolumeWeightedAverage(averages btclists.WeightedAverageService, fiat string, coin string) http.HandlerFunc {
+	return weightedAverage(averages.VolumeWeightedAverage, nil, fiat, coin)
+}
+
+type averageFunc func(ctx context.Context, coin string, fiat string, from time.Time, to time.Time) (decimal.Decimal, error)
+
+// weightedAverage returns a handler serving average returned by provided function for
+// requested time range, with coverage of time range if coverage is a btclists.CoverageService.
+func weightedAverage(average averageFunc, coverage interface{}, fiat string, coin string) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		var from, to, err = validateAndRetrieveStartAndEndTimestamps(request)
+		if err != nil {
+			writer.WriteHeader(http.StatusBadRequest)
+			respondWithError(writer, err)
+			return
+		}
+
+		request, err = withRequestedResolution(request)
+		if err != nil {
+			writer.WriteHeader(http.StatusBadRequest)
+			respondWithError(writer, err)
+			return
+		}
+
+		var result, avgErr = average(request.Context(), coin, fiat, from, to)
+		if avgErr != nil {
+			if avgErr == btclists.ErrRateNotFound || avgErr == ErrNoVolume {
+				writer.WriteHeader(http.StatusNotFound)
+				respondWithError(writer, avgErr)
+				return
+			}
+
+			writer.WriteHeader(http.StatusInternalServerError)
+			respondWithError(writer, ErrUnableToService)
+			return
+		}
+
+		writer.WriteHeader(http.StatusOK)
+		respondWithJSON(writer, RateResponse{
+			Data:     result.String(),
+			Coverage: coverageFor(request, coverage, coin, fiat, from, to),
+		})
+	}
+}
+
+// GetStats uses provided StatsService returning summary statistics (count, min, max, first,
+// last, mean, median, standard deviation and requested percentiles) for specific time range.
+// Timestamps are expected to be ISO 8601 format strings encoded properly (URL Encoded).
+//
+// Percentiles are requested with the 'percentiles' query as a comma separated list of
+// numbers between 0 and 100 (e.g 5,95,99.9).
+//
+// Route: /{version}/{route}?from={timestamp}&to={timestamp}&percentiles={percentiles} e.g /v1/stats?from={timestamp}&to={timestamp}&percentiles=25,75
+// Response Format: application/json
+// Response: { data: {coin, fiat, from, to, count, min, max, first, last, mean, median, stddev, percentiles: [{percent, value}]} }
+// Error Response: { error: {error text} } with status code in range 400-500.
+//
+func GetStats(stats btclists.StatsService, fiat string, coin string) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		var from, to, err = validateAndRetrieveStartAndEndTimestamps(request)
+		if err != nil {
+			writer.WriteHeader(http.StatusBadRequest)
+			respondWithError(writer, err)
+			return
+		}
+
+		var percentiles, percentilesErr = validateAndRetrievePercentiles(request)
+		if percentilesErr != nil {
+			writer.WriteHeader(http.StatusBadRequest)
+			respondWithError(writer, percentilesErr)
+			return
+		}
+
+		request, err = withRequestedResolution(request)
+		if err != nil {
+			writer.WriteHeader(http.StatusBadRequest)
+			respondWithError(writer, err)
+			return
+		}
+
+		var result, statsErr = stats.StatsForRange(request.Context(), coin, fiat, from, to, percentiles)
+		if statsErr != nil {
+			if statsErr == btclists.ErrRateNotFound {
+				writer.WriteHeader(http.StatusNotFound)
+				respondWithError(writer, statsErr)
+				return
+			}
+
+			writer.WriteHeader(http.StatusInternalServerError)
+			respondWithError(writer, ErrUnableToService)
+			return
+		}
+
+		writer.WriteHeader(http.StatusOK)
+		respondWithJSON(writer, StatsResponse{Data: result})
+	}
+}
+
+// validateAndRetrievePercentiles embodies validation logic necessary to
+// retrieve requested percentiles for giving request.
+func validateAndRetrievePercentiles(r *http.Request) ([]float64, error) {
+	var list = r.URL.Query().Get("percentiles")
+	if list == "" {
+		return nil, nil
+	}
+
+	var percentiles []float64
+	for _, item := range strings.Split(list, ",") {
+		var percent, err = strconv.ParseFloat(strings.TrimSpace(item), 64)
+		if err != nil || math.IsNaN(percent) || math.IsInf(percent, 0) || percent < 0 || percent > 100 {
+			return nil, ErrInvalidPercentile
+		}
+		percentiles = append(percentiles, percent)
+	}
+	return percentiles, nil
+}
+
+// GetRange uses provided RatePager returning a page of rates with their dates for
+// specific time range. Timestamps are expected to be ISO 8601 format strings encoded
+// properly (URL Encoded).
+//
+// Pages default to 100 rates in descending order, the 'next' cursor in a response
+// should be provided as 'cursor' to retrieve the next page, it is omitted on the last page.
+//
+// Where rates is a btclists.CoverageService, the ratio of time range covered by
+// known rates is returned as 'coverage'.
+//
+// Route: /{version}/{route}?from={timestamp}&to={timestamp}&limit={limit}&cursor={cursor}&order={asc|desc}&resolution={resolution}
+// Response Format: application/json
+// Response: { data: [{rate}], next: {cursor}, coverage: {ratio} }
+// Error Response: { error: {error text} } with status code in range 400-500.
+//
+func GetRange(rates btclists.RatePager, fiat string, coin string) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		var from, to, err = validateAndRetrieveStartAndEndTimestamps(request)
+		if err != nil {
+			writer.WriteHeader(http.StatusBadRequest)
+			respondWithError(writer, err)
+			return
+		}
+
+		var page, pageErr = validateAndRetrievePage(request)
+		if pageErr != nil {
+			writer.WriteHeader(http.StatusBadRequest)
+			respondWithError(writer, pageErr)
+			return
+		}
+
+		request, err = withRequestedResolution(request)
+		if err != nil {
+			writer.WriteHeader(http.StatusBadRequest)
+			respondWithError(writer, err)
+			return
+		}
+
+		var results, rangeErr = rates.RangePage(request.Context(), coin, fiat, from, to, page)
+		if rangeErr != nil {
+			if rangeErr == btclists.ErrRateNotFound {
+				writer.WriteHeader(http.StatusNotFound)
+				respondWithError(writer, rangeErr)
+				return
+			}
+
+			writer.WriteHeader(http.StatusInternalServerError)
+			respondWithError(writer, ErrUnableToService)
+			return
+		}
+
+		var response = RangeResponse{
+			Data:     results,
+			Coverage: coverageFor(request, rates, coin, fiat, from, to),
+		}
+		if response.Data == nil {
+			response.Data = []btclists.Rate{}
+		}
+		if len(results) == page.Limit {
+			response.Next = encodeCursor(results[len(results)-1].Date)
+		}
+
+		writer.WriteHeader(http.StatusOK)
+		respondWithJSON(writer, response)
+	}
+}
+
+// GetCandles uses provided CandleService returning OHLCV candles for specific time range.
+// Timestamps are expected to be ISO 8601 format strings encoded properly (URL Encoded).
+//
+// Route: /{version}/{route}?from={timestamp}&to={timestamp}&resolution={resolution} e.g /v1/ohlc?from={timestamp}&to={timestamp}
+// Response Format: application/json
+// Response: { data: [{candle}] } where 'candle' holds open, high, low, close, volume and trades of a period.
+// Error Response: { error: {error text} } with status code in range 400-500.
+//
+func GetCandles(candles btclists.CandleService, fiat string, coin string) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		var from, to, err = validateAndRetrieveStartAndEndTimestamps(request)
+		if err != nil {
+			writer.WriteHeader(http.StatusBadRequest)
+			respondWithError(writer, err)
+			return
+		}
+
+		request, err = withRequestedResolution(request)
+		if err != nil {
+			writer.WriteHeader(http.StatusBadRequest)
+			respondWithError(writer, err)
+			return
+		}
+
+		var results, candlesErr = candles.Candles(request.Context(), coin, fiat, from, to)
+		if candlesErr != nil {
+			if candlesErr == btclists.ErrRateNotFound {
+				writer.WriteHeader(http.StatusNotFound)
+				respondWithError(writer, candlesErr)
+				return
+			}
+
+			writer.WriteHeader(http.StatusInternalServerError)
+			respondWithError(writer, ErrUnableToService)
+			return
+		}
+
+		if results == nil {
+			results = []btclists.Candle{}
+		}
+
+		writer.WriteHeader(http.StatusOK)
+		respondWithJSON(writer, CandlesResponse{Data: results})
+	}
+}
+
+// coverageFor returns the ratio of time range covered by known rates of service,
+// if service is a btclists.CoverageService.
+func coverageFor(r *http.Request, service interface{}, coin string, fiat string, from time.Time, to time.Time) *float64 {
+	var coverageService, ok = service.(btclists.CoverageService)
+	if !ok {
+		return nil
+	}
+
+	var coverage, err = coverageService.CoverageForRange(r.Context(), coin, fiat, from, to)
+	if err != nil {
+		log.Printf("[BTC Listings] | [ERROR] | Failed to retrieve coverage | %s\n", err)
+		return nil
+	}
+	return &coverage
+}
+
+// withRequestedResolution returns request with the candle resolution provided
+// with the 'resolution' query attached to it's context, if any.
+func withRequestedResolution(r *http.Request) (*http.Request, error) {
+	var value = r.URL.Query().Get("resolution")
+	if value == "" {
+		return r, nil
+	}
+
+	var resolution, err = btclists.ParseResolution(value)
+	if err != nil {
+		return r, err
+	}
+	return r.WithContext(btclists.WithResolution(r.Context(), resolution)), nil
+}
+
+// validateAndRetrievePage embodies validation logic necessary to
+// retrieve pagination options for giving request.
+func validateAndRetrievePage(r *http.Request) (btclists.Page, error) {
+	var page = btclists.Page{Limit: DefaultPageLimit, Order: btclists.Descending}
+	var query = r.URL.Query()
+
+	if limit := query.Get("limit"); limit != "" {
+		var value, err = strconv.Atoi(limit)
+		if err != nil || value < 1 || value > MaxPageLimit {
+			return page, ErrInvalidLimit
+		}
+		page.Limit = value
+	}
+
+	switch btclists.Order(strings.ToLower(query.Get("order"))) {
+	case "", btclists.Descending:
+		page.Order = btclists.Descending
+	case btclists.Ascending:
+		page.Order = btclists.Ascending
+	default:
+		return page, ErrInvalidOrder
+	}
+
+	if cursor := query.Get("cursor"); cursor != "" {
+		var after, err = decodeCursor(cursor)
+		if err != nil {
+			return page, ErrInvalidCursor
+		}
+		page.After = after
+	}
+
+	return page, nil
+}
+
+// encodeCursor returns an opaque cursor for giving date.
+func encodeCursor(date time.Time) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(date.UTC().Format(time.RFC3339Nano)))
+}
+
+func decodeCursor(cursor string) (time.Time, error) {
+	var decoded, err = base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Parse(time.RFC3339Nano, string(decoded))
+}
+
+// GetConversion uses provided ConversionService to convert an amount between currencies
+// at provided timestamp, using latest rates if no timestamp is provided.
+// Timestamps are expected to be ISO 8601 format strings encoded properly (URL Encoded).
+//
+// The response holds the currencies converted through (i.e path) and the rates used
+// for each step (i.e legs).
+//
+// Route: /{version}/{route}?amount={amount}&from={currency}&to={currency}&t={timestamp} e.g /v1/convert?amount=0.35&from=BTC&to=EUR
+// Response Format: application/json
+// Response: { data: {amount, from, to, result, at, path: [{currency}], legs: [{from, to, pair, rate, date, inverted}]} }
+// Error Response: { error: {error text} } with status code in range 400-500.
+//
+func GetConversion(converter ConversionService) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		var query = request.URL.Query()
+
+		var amount, amountErr = decimal.NewFromString(query.Get("amount"))
+		if amountErr != nil {
+			writer.WriteHeader(http.StatusBadRequest)
+			respondWithError(writer, ErrInvalidAmount)
+			return
+		}
+
+		var from, to = query.Get("from"), query.Get("to")
+		if from == "" || to == "" {
+			writer.WriteHeader(http.StatusBadRequest)
+			respondWithError(writer, ErrNoCurrencies)
+			return
+		}
+
+		var at time.Time
+		if query.Get("t") != "" {
+			var err error
+			if at, err = validateAndRetrieveAtTimestamp(request); err != nil {
+				writer.WriteHeader(http.StatusBadRequest)
+				respondWithError(writer, err)
+				return
+			}
+		}
+
+		var conversion, err = converter.Convert(request.Context(), amount, from, to, at)
+		if err != nil {
+			if err == btclists.ErrRateNotFound || err == ErrNoConversionPath {
+				writer.WriteHeader(http.StatusNotFound)
+				respondWithError(writer, err)
+				return
+			}
+
+			writer.WriteHeader(http.StatusInternalServerError)
+			respondWithError(writer, ErrUnableToService)
+			return
+		}
+
+		writer.WriteHeader(http.StatusOK)
+		respondWithJSON(writer, ConversionResponse{Data: conversion})
+	}
+}
+
+// GetProviderStatus returns the circuit breaker state of market data providers from
+// provided reporter, letting operations see which provider is serving.
+//
+// Route: /{version}/{route} e.g /v1/status/providers
+// Response Format: application/json
+// Response: { data: [{name, state, serving, last_error, opened_at, retry_at}] }
+//
+func GetProviderStatus(reporter ProviderStatusReporter) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		writer.WriteHeader(http.StatusOK)
+		respondWithJSON(writer, ProviderStatusResponse{Data: reporter.Status()})
+	}
+}
+
+// GetRateFeed upgrades request into a WebSocket connection, pushing each rate published
+// to provided hub as it is stored (see PublishingRatesDB) for the pairs requested with
+// the pairs query (e.g BTC/USD,ETH/EUR), all pairs in allow-list are sent where none
+// are requested.
+//
+// Clients falling behind miss older rates in favour of latest ones, see RateHub.
+//
+// Route: /{version}/{route}?pairs={coin}/{fiat},... e.g /v1/feed?pairs=BTC/USD
+// Response Format: WebSocket, a text message per rate
+// Response: { data: {id, date, rate, coin, fiat, resolution} }
+// Error Response: { error: {error text} } with status code in range 400-500, before upgrade.
+//
+func GetRateFeed(hub *RateHub, pairs Pairs) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		var subscribed, err = validateAndRetrieveSubscribedPairs(request, pairs)
+		if err != nil {
+			if err == ErrUnsupportedPair {
+				writer.WriteHeader(http.StatusNotFound)
+				respondWithError(writer, err)
+				return
+			}
+
+			writer.WriteHeader(http.StatusBadRequest)
+			respondWithError(writer, err)
+			return
+		}
+
+		serveRateFeed(writer, request, hub, subscribed)
+	}
+}
+
+// validateAndRetrieveSubscribedPairs returns pairs requested with the pairs query, all of
+// which must be in allow-list, returning allow-list itself where none are requested.
+func validateAndRetrieveSubscribedPairs(r *http.Request, pairs Pairs) (Pairs, error) {
+	var list = r.URL.Query().Get("pairs")
+	if list == "" {
+		return pairs, nil
+	}
+
+	var requested, err = ParsePairs(list)
+	if err != nil {
+		return nil, err
+	}
+
+	for pair := range requested {
+		if !pairs.Has(pair.Coin, pair.Fiat) {
+			return nil, ErrUnsupportedPair
+		}
+	}
+	return requested, nil
+}
+
+// serveRateFeed upgrades request and writes rates of subscribed pairs till
+// either client goes away or hub is closed.
+func serveRateFeed(writer http.ResponseWriter, request *http.Request, hub *RateHub, subscribed Pairs) {
+	var conn, err = feedUpgrader.Upgrade(writer, request, nil)
+	if err != nil {
+		// upgrader has already responded with an error.
+		log.Printf("[BTC Listings] | [ERROR] | [FEED] | Failed to upgrade connection | %s\n", err)
+		return
+	}
+
+	defer conn.Close()
+
+	var sub = hub.Subscribe(subscribed)
+	defer hub.Unsubscribe(sub)
+
+	// clients are not expected to send anything, but reading is needed for
+	// control messages (i.e pongs and close) to be processed.
+	var gone = make(chan struct{})
+	go func() {
+		defer close(gone)
+
+		_ = conn.SetReadDeadline(time.Now().Add(feedPongWait))
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(time.Now().Add(feedPongWait))
+		})
+
+		for {
+			if _, _, readErr := conn.NextReader(); readErr != nil {
+				return
+			}
+		}
+	}()
+
+	var pings = time.NewTicker(feedPingInterval)
+	defer pings.Stop()
+
+	for {
+		select {
+		case <-gone:
+			return
+		case rate, ok := <-sub.Rates:
+			_ = conn.SetWriteDeadline(time.Now().Add(feedWriteWait))
+			if !ok {
+				_ = conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseGoingAway, ""))
+				return
+			}
+
+			if writeErr := conn.WriteJSON(FeedMessage{Data: rate}); writeErr != nil {
+				log.Printf("[BTC Listings] | [ERROR] | [FEED] | Failed to write rate | %s\n", writeErr)
+				return
+			}
+		case <-pings.C:
+			_ = conn.SetWriteDeadline(time.Now().Add(feedWriteWait))
+			if pingErr := conn.WriteMessage(websocket.PingMessage, nil); pingErr != nil {
+				return
+			}
+		}
+	}
+}
+
+// GetRateStream streams each rate published to provided hub as it is stored (see PublishingRatesDB)
+// as Server-Sent Events, for the pairs requested with the pairs query (e.g BTC/USD,ETH/EUR),
+// all pairs in allow-list are sent where none are requested.
+//
+// Each event carries as ID the date of the last rate sent for each pair (e.g
+// BTC/USD=2020-04-08T14:00:00Z,ETH/USD=2020-04-08T14:00:00Z), where a client reconnects with
+// the Last-Event-ID header, stored rates of each pair after it's date are replayed from provided
+// RatePager before live rates, up to MaxStreamReplay at once, after which the stream ends for the
+// client to reconnect and resume. Streams also end before the server's write timeout is reached.
+//
+// Route: /{version}/{route}?pairs={coin}/{fiat},... e.g /v1/stream?pairs=BTC/USD
+// Response Format: text/event-stream
+// Response: id: {coin}/{fiat}={date},...\ndata: { data: {id, date, rate, coin, fiat, resolution} }
+// Error Response: { error: {error text} } with status code in range 400-500.
+//
+func GetRateStream(hub *RateHub, rates btclists.RatePager, pairs Pairs) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		var subscribed, err = validateAndRetrieveSubscribedPairs(request, pairs)
+		if err != nil {
+			if err == ErrUnsupportedPair {
+				writer.WriteHeader(http.StatusNotFound)
+				respondWithError(writer, err)
+				return
+			}
+
+			writer.WriteHeader(http.StatusBadRequest)
+			respondWithError(writer, err)
+			return
+		}
+
+		serveRateStream(writer, request, hub, rates, subscribed)
+	}
+}
+
+// serveRateStream replays rates missed since Last-Event-ID and writes rates of subscribed
+// pairs as events till either client goes away, hub is closed or server's write
+// timeout nears.
+func serveRateStream(writer http.ResponseWriter, request *http.Request, hub *RateHub, rates btclists.RatePager, subscribed Pairs) {
+	var flusher, ok = writer.(http.Flusher)
+	if !ok {
+		writer.WriteHeader(http.StatusInternalServerError)
+		respondWithError(writer, ErrUnableToService)
+		return
+	}
+
+	var cursor = streamCursor{}
+	if id := request.Header.Get("Last-Event-ID"); id != "" {
+		var err error
+		if cursor, err = parseStreamCursor(id, subscribed); err != nil {
+			writer.WriteHeader(http.StatusBadRequest)
+			respondWithError(writer, ErrInvalidEventID)
+			return
+		}
+	}
+
+	// subscribe before replaying, so rates stored meanwhile are not missed.
+	var sub = hub.Subscribe(subscribed)
+	defer hub.Unsubscribe(sub)
+
+	var missed []btclists.Rate
+	var more bool
+	if len(cursor) > 0 {
+		var err error
+		if missed, more, err = replayRates(request.Context(), rates, cursor, MaxStreamReplay); err != nil {
+			log.Printf("[BTC Listings] | [ERROR] | [STREAM] | Failed to replay rates | %s\n", err)
+			writer.WriteHeader(http.StatusInternalServerError)
+			respondWithError(writer, ErrUnableToService)
+			return
+		}
+	}
+
+	writer.Header().Set("Content-Type", "text/event-stream")
+	writer.Header().Set("Cache-Control", "no-cache")
+	writer.Header().Set("Connection", "keep-alive")
+	writer.Header().Set("X-Accel-Buffering", "no")
+	writer.WriteHeader(http.StatusOK)
+
+	if _, err := fmt.Fprintf(writer, "retry: %d\n\n", streamRetry.Milliseconds()); err != nil {
+		return
+	}
+
+	for _, rate := range missed {
+		if err := writeRateEvent(writer, rate, cursor); err != nil {
+			return
+		}
+	}
+	flusher.Flush()
+
+	// client resumes replay on reconnect.
+	if more {
+		return
+	}
+
+	var expired <-chan time.Time
+	if server, ok := request.Context().Value(http.ServerContextKey).(*http.Server); ok && server.WriteTimeout > 0 {
+		var timer = time.NewTimer((server.WriteTimeout * 9) / 10)
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	var heartbeats = time.NewTicker(streamHeartbeatInterval)
+	defer heartbeats.Stop()
+
+	for {
+		select {
+		case <-request.Context().Done():
+			return
+		case <-expired:
+			return
+		case rate, ok := <-sub.Rates:
+			if !ok {
+				return
+			}
+
+			// skip rates already replayed.
+			if cursor.Sent(rate) {
+				continue
+			}
+
+			if err := writeRateEvent(writer, rate, cursor); err != nil {
+				log.Printf("[BTC Listings] | [ERROR] | [STREAM] | Failed to write rate | %s\n", err)
+				return
+			}
+			flusher.Flush()
+		case <-heartbeats.C:
+			// comments keep proxies from timing out idle streams.
+			if _, err := fmt.Fprint(writer, ": heartbeat\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// writeRateEvent writes rate as an event, advancing cursor with it and using
+// the advanced cursor as event ID.
+func writeRateEvent(writer http.ResponseWriter, rate btclists.Rate, cursor streamCursor) error {
+	var data, err = json.Marshal(FeedMessage{Data: rate})
+	if err != nil {
+		return err
+	}
+
+	cursor.Advance(rate)
+	_, err = fmt.Fprintf(writer, "id: %s\ndata: %s\n\n", cursor, data)
+	return err
+}
+
+// GetExport streams rates from provided RateService of pairs requested with the pairs query
+// (e.g BTC/USD,ETH/EUR) within provided time range as a csv (default) or parquet file, all
+// pairs in allow-list are exported where none are requested.
+// Timestamps are expected to be ISO 8601 format strings encoded properly (URL Encoded).
+//
+// Columns may be selected with the columns query (e.g date,rate), from id, date, coin, fiat,
+// rate and resolution, all of which are exported by default. Rates are written as decimal
+// strings, losing no precision.
+//
+// Route: /{version}/{route}?from={timestamp}&to={timestamp}&pairs={coin}/{fiat},...&columns={columns}&format={csv|parquet} e.g /v1/export?from={timestamp}&to={timestamp}&format=parquet
+// Response Format: text/csv or application/vnd.apache.parquet
+// Response: {column},...\n{value},...\n
+// Error Response: { error: {error text} } with status code in range 400-500.
+//
+func GetExport(rates btclists.RateService, pairs Pairs) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		var subscribed, err = validateAndRetrieveSubscribedPairs(request, pairs)
+		if err != nil {
+			if err == ErrUnsupportedPair {
+				writer.WriteHeader(http.StatusNotFound)
+				respondWithError(writer, err)
+				return
+			}
+
+			writer.WriteHeader(http.StatusBadRequest)
+			respondWithError(writer, err)
+			return
+		}
+
+		serveExport(writer, request, rates, subscribed)
+	}
+}
+
+// serveExport validates export options of request, streaming export of provided pairs.
+func serveExport(writer http.ResponseWriter, request *http.Request, rates btclists.RateService, pairs Pairs) {
+	var from, to, err = validateAndRetrieveStartAndEndTimestamps(request)
+	if err != nil {
+		writer.WriteHeader(http.StatusBadRequest)
+		respondWithError(writer, err)
+		return
+	}
+
+	var query = request.URL.Query()
+
+	var format = CSVFormat
+	if query.Get("format") != "" {
+		if format, err = ParseExportFormat(query.Get("format")); err != nil {
+			writer.WriteHeader(http.StatusBadRequest)
+			respondWithError(writer, err)
+			return
+		}
+	}
+
+	var columns, columnsErr = ParseExportColumns(query.Get("columns"))
+	if columnsErr != nil {
+		writer.WriteHeader(http.StatusBadRequest)
+		respondWithError(writer, columnsErr)
+		return
+	}
+
+	writer.Header().Set("Content-Type", format.ContentType())
+	writer.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"rates.%s\"", format))
+	writer.WriteHeader(http.StatusOK)
+
+	var written, exportErr = Export(request.Context(), rates, writer, ExportOptions{
+		Pairs:   pairs.List(),
+		From:    from,
+		To:      to,
+		Columns: columns,
+		Format:  format,
+	})
+	if exportErr != nil {
+		// response has begun, so all that is left is to cut it short.
+		log.Printf("[BTC Listings] | [ERROR] | [EXPORT] | Export failed after %d rates | %s\n", written, exportErr)
+	}
+}
+
+// PostBackfill uses provided BackfillService to fill gaps in stored rates of specific
+// fiat and crypto-coin within provided time range on demand.
+// Timestamps are expected to be ISO 8601 format strings encoded properly (URL Encoded).
+//
+// Route: /{version}/{route}?from={timestamp}&to={timestamp} e.g /v1/backfill?from={timestamp}&to={timestamp}
+// Response Format: application/json
+// Response: { data: {coin, fiat, from, to, gaps, fetched, failed} }
+// Error Response: { error: {error text} } with status code in range 400-500.
+//
+func PostBackfill(backfiller BackfillService, fiat string, coin string) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		var from, to, err = validateAndRetrieveStartAndEndTimestamps(request)
+		if err != nil {
+			writer.WriteHeader(http.StatusBadRequest)
+			respondWithError(writer, err)
+			return
+		}
+
+		var report, backfillErr = backfiller.Backfill(request.Context(), coin, fiat, from, to)
+		if backfillErr != nil {
+			if backfillErr == btclists.ErrBudgetExhausted {
+				writer.WriteHeader(http.StatusTooManyRequests)
+				respondWithError(writer, backfillErr)
+				return
+			}
+
+			writer.WriteHeader(http.StatusInternalServerError)
+			respondWithError(writer, ErrUnableToService)
+			return
+		}
+
+		writer.WriteHeader(http.StatusOK)
+		respondWithJSON(writer, BackfillResponse{Data: report})
+	}
+}
+
+// GetLatestForPair serves GetLatest for the crypto-currency and fiat-currency pair
+// provided in the route, responding with a 404 if pair is not in allow-list.
+//
+// Route: /{version}/{coin}/{fiat}/{route} e.g /v1/BTC/USD/latest
+//
+func GetLatestForPair(rates btclists.RateService, pairs Pairs) http.HandlerFunc {
+	return forPair(pairs, func(coin string, fiat string) http.HandlerFunc {
+		return GetLatest(rates, fiat, coin)
+	})
+}
+
+// GetLatestAtForPair serves GetLatestAt for the crypto-currency and fiat-currency pair
+// provided in the route, responding with a 404 if pair is not in allow-list.
+//
+// Route: /{version}/{coin}/{fiat}/{route}?t={timestamp} e.g /v1/BTC/USD/at?t={timestamp}
+//
+func GetLatestAtForPair(rates btclists.RateService, pairs Pairs) http.HandlerFunc {
+	return forPair(pairs, func(coin string, fiat string) http.HandlerFunc {
+		return GetLatestAt(rates, fiat, coin)
+	})
+}
+
+// GetAverageForPair serves GetAverageFor for the crypto-currency and fiat-currency pair
+// provided in the route, responding with a 404 if pair is not in allow-list.
+//
+// Route: /{version}/{coin}/{fiat}/{route}?from={timestamp}&to={timestamp} e.g /v1/BTC/USD/avg?from={timestamp}&to={timestamp}
+//
+func GetAverageForPair(averageService btclists.RatingsAverageService, ratingService btclists.RateService, pairs Pairs) http.HandlerFunc {
+	return forPair(pairs, func(coin string, fiat string) http.HandlerFunc {
+		return GetAverageFor(averageService, ratingService, fiat, coin)
+	})
+}
+
+// GetTimeWeightedAverageForPair serves GetTimeWeightedAverage for the crypto-currency and fiat-currency
+// pair provided in the route, responding with a 404 if pair is not in allow-list.
+//
+// Route: /{version}/{coin}/{fiat}/{route}?from={timestamp}&to={timestamp} e.g /v1/BTC/USD/twap?from={timestamp}&to={timestamp}
+//
+func GetTimeWeightedAverageForPair(averages btclists.WeightedAverageService, pairs Pairs) http.HandlerFunc {
+	return forPair(pairs, func(coin string, fiat string) http.HandlerFunc {
+		return GetTimeWeightedAverage(averages, fiat, coin)
+	})
+}
+
+// GetVolumeWeightedAverageForPair serves GetVolumeWeightedAverage for the crypto-currency and fiat-currency
+// pair provided in the route, responding with a 404 if pair is not in allow-list.
+//
+// Route: /{version}/{coin}/{fiat}/{route}?from={timestamp}&to={timestamp} e.g /v1/BTC/USD/vwap?from={timestamp}&to={timestamp}
+//
+func GetVolumeWeightedAverageForPair(averages btclists.WeightedAverageService, pairs Pairs) http.HandlerFunc {
+	return forPair(pairs, func(coin string, fiat string) http.HandlerFunc {
+		return GetVolumeWeightedAverage(averages, fiat, coin)
+	})
+}
+
+// GetStatsForPair serves GetStats for the crypto-currency and fiat-currency pair
+// provided in the route, responding with a 404 if pair is not in allow-list.
+//
+// Route: /{version}/{coin}/{fiat}/{route}?from={timestamp}&to={timestamp} e.g /v1/BTC/USD/stats?from={timestamp}&to={timestamp}
+//
+func GetStatsForPair(stats btclists.StatsService, pairs Pairs) http.HandlerFunc {
+	return forPair(pairs, func(coin string, fiat string) http.HandlerFunc {
+		return GetStats(stats, fiat, coin)
+	})
+}
+
+// GetRangeForPair serves GetRange for the crypto-currency and fiat-currency pair
+// provided in the route, responding with a 404 if pair is not in allow-list.
+//
+// Route: /{version}/{coin}/{fiat}/{route}?from={timestamp}&to={timestamp} e.g /v1/BTC/USD/range?from={timestamp}&to={timestamp}
+//
+func GetRangeForPair(rates btclists.RatePager, pairs Pairs) http.HandlerFunc {
+	return forPair(pairs, func(coin string, fiat string) http.HandlerFunc {
+		return GetRange(rates, fiat, coin)
+	})
+}
+
+// GetCandlesForPair serves GetCandles for the crypto-currency and fiat-currency pair
+// provided in the route, responding with a 404 if pair is not in allow-list.
+//
+// Route: /{version}/{coin}/{fiat}/{route}?from={timestamp}&to={timestamp} e.g /v1/BTC/USD/ohlc?from={timestamp}&to={timestamp}
+//
+func GetCandlesForPair(candles btclists.CandleService, pairs Pairs) http.HandlerFunc {
+	return forPair(pairs, func(coin string, fiat string) http.HandlerFunc {
+		return GetCandles(candles, fiat, coin)
+	})
+}
+
+// GetRateFeedForPair serves GetRateFeed for only the crypto-currency and fiat-currency pair
+// provided in the route, responding with a 404 if pair is not in allow-list.
+//
+// Route: /{version}/{coin}/{fiat}/{route} e.g /v1/BTC/USD/feed
+//
+func GetRateFeedForPair(hub *RateHub, pairs Pairs) http.HandlerFunc {
+	return forPair(pairs, func(coin string, fiat string) http.HandlerFunc {
+		return func(writer http.ResponseWriter, request *http.Request) {
+			serveRateFeed(writer, request, hub, NewPairs(btclists.Pair{Coin: coin, Fiat: fiat}))
+		}
+	})
+}
+
+// GetRateStreamForPair serves GetRateStream for only the crypto-currency and fiat-currency pair
+// provided in the route, responding with a 404 if pair is not in allow-list.
+//
+// Route: /{version}/{coin}/{fiat}/{route} e.g /v1/BTC/USD/stream
+//
+func GetRateStreamForPair(hub *RateHub, rates btclists.RatePager, pairs Pairs) http.HandlerFunc {
+	return forPair(pairs, func(coin string, fiat string) http.HandlerFunc {
+		return func(writer http.ResponseWriter, request *http.Request) {
+			serveRateStream(writer, request, hub, rates, NewPairs(btclists.Pair{Coin: coin, Fiat: fiat}))
+		}
+	})
+}
+
+// GetExportForPair serves GetExport for only the crypto-currency and fiat-currency pair
+// provided in the route, responding with a 404 if pair is not in allow-list.
+//
+// Route: /{version}/{coin}/{fiat}/{route}?from={timestamp}&to={timestamp}&columns={columns}&format={csv|parquet} e.g /v1/BTC/USD/export?from={timestamp}&to={timestamp}
+//
+func GetExportForPair(rates btclists.RateService, pairs Pairs) http.HandlerFunc {
+	return forPair(pairs, func(coin string, fiat string) http.HandlerFunc {
+		return func(writer http.ResponseWriter, request *http.Request) {
+			serveExport(writer, request, rates, NewPairs(btclists.Pair{Coin: coin, Fiat: fiat}))
+		}
+	})
+}
+
+// PostBackfillForPair serves PostBackfill for the crypto-currency and fiat-currency pair
+// provided in the route, responding with a 404 if pair is not in allow-list.
+//
+// Route: /{version}/{coin}/{fiat}/{route}?from={timestamp}&to={timestamp} e.g /v1/BTC/USD/backfill?from={timestamp}&to={timestamp}
+//
+func PostBackfillForPair(backfiller BackfillService, pairs Pairs) http.HandlerFunc {
+	return forPair(pairs, func(coin string, fiat string) http.HandlerFunc {
+		return PostBackfill(backfiller, fiat, coin)
+	})
+}
+
+// forPair resolves the coin and fiat route parameters of a request, validating them
+// against provided allow-list before handing request over to handler returned by maker.
+func forPair(pairs Pairs, maker func(coin string, fiat string) http.HandlerFunc) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		var pair = normalizePair(chi.URLParam(request, "coin"), chi.URLParam(request, "fiat"))
+		if !pairs.Has(pair.Coin, pair.Fiat) {
+			writer.WriteHeader(http.StatusNotFound)
+			respondWithError(writer, ErrUnsupportedPair)
+			return
+		}
+
+		maker(pair.Coin, pair.Fiat)(writer, request)
 	}
 }
 
@@ -197,6 +1213,12 @@ func respondWithRate(writer http.ResponseWriter, rate decimal.Decimal) {
 	}
 }
 
+func respondWithJSON(writer http.ResponseWriter, value interface{}) {
+	if err := json.NewEncoder(writer).Encode(value); err != nil {
+		log.Printf("[ALERT] JSON encoding just exploded, that is bad: %+s", err)
+	}
+}
+
 func respondWithError(writer http.ResponseWriter, err error) {
 	if err := json.NewEncoder(writer).Encode(RateError{Error: err.Error()}); err != nil {
 		log.Printf("[ALERT] JSON encoding just exploded, that is bad: %+s", err)
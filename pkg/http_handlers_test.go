@@ -11,6 +11,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/go-chi/chi"
 	"github.com/shopspring/decimal"
 
 	"github.com/influx6/btclists/pkg"
@@ -22,12 +23,14 @@ import (
 
 var _ btclists.RateService = (*RateServerMock)(nil)
 var _ btclists.RatingsAverageService = (*RateServerMock)(nil)
+var _ btclists.RatePager = (*RateServerMock)(nil)
 
 // RateServerMock implements btclists.RateServer.
 type RateServerMock struct {
 	LatestFunc          func(ctx context.Context, COIN string, FIAT string) (btclists.Rate, error)
 	AtFunc              func(ctx context.Context, COIN string, FIAT string, at time.Time) (btclists.Rate, error)
 	RangeFunc           func(ctx context.Context, COIN string, FIAT string, from, to time.Time) ([]btclists.Rate, error)
+	RangePageFunc       func(ctx context.Context, COIN string, FIAT string, from, to time.Time, page btclists.Page) ([]btclists.Rate, error)
 	AverageForRangeFunc func(ctx context.Context, COIN string, FIAT string, from, to time.Time) (decimal.Decimal, error)
 }
 
@@ -47,6 +50,14 @@ func (rs RateServerMock) Range(ctx context.Context, COIN string, FIAT string, fr
 	return rs.RangeFunc(ctx, COIN, FIAT, from, to)
 }
 
+// RangePage implements btclists.RatePager interface.
+//
+// We test the functions using this implementation and not this
+// implementation.
+func (rs RateServerMock) RangePage(ctx context.Context, COIN string, FIAT string, from, to time.Time, page btclists.Page) ([]btclists.Rate, error) {
+	return rs.RangePageFunc(ctx, COIN, FIAT, from, to, page)
+}
+
 // At implements btclists.RateServer interface.
 //
 // We test the functions using this implementation and not this
@@ -134,6 +145,170 @@ func TestLatestHandlerSuccess(t *testing.T) {
 	require.Equal(t, someRate.Rate.String(), rateResponse.Data)
 }
 
+// CoverageServerMock extends RateServerMock with btclists.CoverageService.
+type CoverageServerMock struct {
+	RateServerMock
+	CoverageForRangeFunc func(ctx context.Context, COIN string, FIAT string, from, to time.Time) (float64, error)
+}
+
+func (cs CoverageServerMock) CoverageForRange(ctx context.Context, COIN string, FIAT string, from, to time.Time) (float64, error) {
+	return cs.CoverageForRangeFunc(ctx, COIN, FIAT, from, to)
+}
+
+func TestAverageHandlerSuccess_WithCoverage(t *testing.T) {
+	var rates = new(CoverageServerMock)
+	rates.AverageForRangeFunc = func(ctx context.Context, cn string, ft string, from time.Time, to time.Time) (decimal.Decimal, error) {
+		return someRate.Rate, nil
+	}
+	rates.CoverageForRangeFunc = func(ctx context.Context, cn string, ft string, from time.Time, to time.Time) (float64, error) {
+		return 0.75, nil
+	}
+
+	var httpFunc = pkg.GetAverageFor(rates, rates, FIAT, COIN)
+	var response = httptest.NewRecorder()
+
+	var values = url.Values{}
+	values.Add("from", someTime.Format(btclists.DateTimeFormat))
+	values.Add("to", someTimeLater.Format(btclists.DateTimeFormat))
+
+	var request = httptest.NewRequest(
+		"GET",
+		fmt.Sprintf("/avg?%s", values.Encode()),
+		nil,
+	)
+
+	httpFunc(response, request)
+
+	require.Equal(t, http.StatusOK, response.Code)
+
+	var rateResponse pkg.RateResponse
+	var err = json.NewDecoder(response.Body).Decode(&rateResponse)
+	require.Nil(t, err)
+	require.Equal(t, someRate.Rate.String(), rateResponse.Data)
+	require.NotNil(t, rateResponse.Coverage)
+	require.Equal(t, 0.75, *rateResponse.Coverage)
+}
+
+// WeightedAverageMock implements btclists.WeightedAverageService.
+type WeightedAverageMock struct {
+	TimeWeightedAverageFunc   func(ctx context.Context, COIN string, FIAT string, from, to time.Time) (decimal.Decimal, error)
+	VolumeWeightedAverageFunc func(ctx context.Context, COIN string, FIAT string, from, to time.Time) (decimal.Decimal, error)
+}
+
+func (wm WeightedAverageMock) TimeWeightedAverage(ctx context.Context, COIN string, FIAT string, from, to time.Time) (decimal.Decimal, error) {
+	return wm.TimeWeightedAverageFunc(ctx, COIN, FIAT, from, to)
+}
+
+func (wm WeightedAverageMock) VolumeWeightedAverage(ctx context.Context, COIN string, FIAT string, from, to time.Time) (decimal.Decimal, error) {
+	return wm.VolumeWeightedAverageFunc(ctx, COIN, FIAT, from, to)
+}
+
+func TestWeightedAverageHandlers(t *testing.T) {
+	var averages = new(WeightedAverageMock)
+	averages.TimeWeightedAverageFunc = func(ctx context.Context, cn string, ft string, from time.Time, to time.Time) (decimal.Decimal, error) {
+		return decimal.NewFromFloat(7201.5), nil
+	}
+	averages.VolumeWeightedAverageFunc = func(ctx context.Context, cn string, ft string, from time.Time, to time.Time) (decimal.Decimal, error) {
+		return decimal.Decimal{}, pkg.ErrNoVolume
+	}
+
+	var values = url.Values{}
+	values.Add("from", someTime.Format(btclists.DateTimeFormat))
+	values.Add("to", someTimeLater.Format(btclists.DateTimeFormat))
+
+	t.Logf("Should serve time-weighted average")
+	{
+		var response = httptest.NewRecorder()
+		var request = httptest.NewRequest("GET", fmt.Sprintf("/twap?%s", values.Encode()), nil)
+		pkg.GetTimeWeightedAverage(averages, FIAT, COIN)(response, request)
+
+		require.Equal(t, http.StatusOK, response.Code)
+
+		var rateResponse pkg.RateResponse
+		require.NoError(t, json.NewDecoder(response.Body).Decode(&rateResponse))
+		require.Equal(t, "7201.5", rateResponse.Data)
+	}
+
+	t.Logf("Should respond with 404 when volume is unknown")
+	{
+		var response = httptest.NewRecorder()
+		var request = httptest.NewRequest("GET", fmt.Sprintf("/vwap?%s", values.Encode()), nil)
+		pkg.GetVolumeWeightedAverage(averages, FIAT, COIN)(response, request)
+
+		require.Equal(t, http.StatusNotFound, response.Code)
+	}
+}
+
+// StatsServiceMock implements btclists.StatsService.
+type StatsServiceMock struct {
+	StatsForRangeFunc func(ctx context.Context, COIN string, FIAT string, from, to time.Time, percentiles []float64) (btclists.Stats, error)
+}
+
+func (sm StatsServiceMock) StatsForRange(ctx context.Context, COIN string, FIAT string, from, to time.Time, percentiles []float64) (btclists.Stats, error) {
+	return sm.StatsForRangeFunc(ctx, COIN, FIAT, from, to, percentiles)
+}
+
+func TestStatsHandler(t *testing.T) {
+	var stats = new(StatsServiceMock)
+	stats.StatsForRangeFunc = func(ctx context.Context, cn string, ft string, from time.Time, to time.Time, percentiles []float64) (btclists.Stats, error) {
+		require.Equal(t, []float64{5, 99.9}, percentiles)
+		return btclists.Stats{
+			Coin:   cn,
+			Fiat:   ft,
+			Count:  2,
+			Min:    decimal.NewFromFloat(7201.5),
+			Max:    decimal.NewFromFloat(7203.5),
+			Median: decimal.NewFromFloat(7202.5),
+			Percentiles: []btclists.Percentile{
+				{Percent: 5, Value: decimal.NewFromFloat(7201.6)},
+				{Percent: 99.9, Value: decimal.NewFromFloat(7203.4)},
+			},
+		}, nil
+	}
+
+	var values = url.Values{}
+	values.Add("from", someTime.Format(btclists.DateTimeFormat))
+	values.Add("to", someTimeLater.Format(btclists.DateTimeFormat))
+	values.Add("percentiles", "5,99.9")
+
+	var response = httptest.NewRecorder()
+	var request = httptest.NewRequest("GET", fmt.Sprintf("/stats?%s", values.Encode()), nil)
+	pkg.GetStats(stats, FIAT, COIN)(response, request)
+
+	require.Equal(t, http.StatusOK, response.Code)
+
+	var statsResponse pkg.StatsResponse
+	require.NoError(t, json.NewDecoder(response.Body).Decode(&statsResponse))
+	require.Equal(t, 2, statsResponse.Data.Count)
+	require.Equal(t, "7202.5", statsResponse.Data.Median.String())
+	require.Len(t, statsResponse.Data.Percentiles, 2)
+
+	t.Logf("Should reject invalid percentiles")
+	{
+		values.Set("percentiles", "5,101")
+
+		var response = httptest.NewRecorder()
+		var request = httptest.NewRequest("GET", fmt.Sprintf("/stats?%s", values.Encode()), nil)
+		pkg.GetStats(stats, FIAT, COIN)(response, request)
+
+		require.Equal(t, http.StatusBadRequest, response.Code)
+	}
+
+	t.Logf("Should reject percentiles which are not numbers")
+	{
+		for _, percentile := range []string{"NaN", "Inf", "-Inf"} {
+			values.Set("percentiles", percentile)
+
+			var response = httptest.NewRecorder()
+			var request = httptest.NewRequest("GET", fmt.Sprintf("/stats?%s", values.Encode()), nil)
+			pkg.GetStats(stats, FIAT, COIN)(response, request)
+
+			require.Equal(t, http.StatusBadRequest, response.Code, percentile)
+			require.Contains(t, response.Body.String(), pkg.ErrInvalidPercentile.Error(), percentile)
+		}
+	}
+}
+
 func TestAverageHandlerFailure_ServerIssues(t *testing.T) {
 	var rates = new(RateServerMock)
 	rates.AverageForRangeFunc = func(ctx context.Context, cn string, ft string, from time.Time, to time.Time) (decimal.Decimal, error) {
@@ -524,3 +699,287 @@ func TestAverageHandlerValidation(t *testing.T) {
 		require.Equal(t, test.status, response.Code, "Failed for test %d", index)
 	}
 }
+
+func TestLatestForPairHandlerSuccess(t *testing.T) {
+	var rates = new(RateServerMock)
+	rates.LatestFunc = func(ctx context.Context, cn string, ft string) (rate btclists.Rate, err error) {
+		require.Equal(t, "ETH", cn)
+		require.Equal(t, "EUR", ft)
+
+		rate = someRate
+		return
+	}
+
+	var router = chi.NewRouter()
+	router.Get("/v1/{coin}/{fiat}/latest", pkg.GetLatestForPair(rates, pkg.NewPairs(btclists.Pair{Coin: "ETH", Fiat: "EUR"})))
+
+	var response = httptest.NewRecorder()
+	var request = httptest.NewRequest("GET", "/v1/eth/eur/latest", nil)
+
+	router.ServeHTTP(response, request)
+
+	require.Equal(t, http.StatusOK, response.Code)
+
+	var rateResponse pkg.RateResponse
+	var err = json.NewDecoder(response.Body).Decode(&rateResponse)
+	require.Nil(t, err)
+	require.Equal(t, someRate.Rate.String(), rateResponse.Data)
+}
+
+func TestLatestForPairHandlerFailure_UnsupportedPair(t *testing.T) {
+	var rates = new(RateServerMock)
+	rates.LatestFunc = func(ctx context.Context, cn string, ft string) (rate btclists.Rate, err error) {
+		t.Fatal("service should not be called for unsupported pair")
+		return
+	}
+
+	var router = chi.NewRouter()
+	router.Get("/v1/{coin}/{fiat}/latest", pkg.GetLatestForPair(rates, pkg.NewPairs(btclists.Pair{Coin: COIN, Fiat: FIAT})))
+
+	var response = httptest.NewRecorder()
+	var request = httptest.NewRequest("GET", "/v1/DOGE/USD/latest", nil)
+
+	router.ServeHTTP(response, request)
+
+	require.Equal(t, http.StatusNotFound, response.Code)
+
+	var rateError pkg.RateError
+	var err = json.NewDecoder(response.Body).Decode(&rateError)
+	require.Nil(t, err)
+	require.Equal(t, pkg.ErrUnsupportedPair.Error(), rateError.Error)
+}
+
+func TestAverageForPairHandlerSuccess(t *testing.T) {
+	var rates = new(RateServerMock)
+	rates.AverageForRangeFunc = func(ctx context.Context, cn string, ft string, from time.Time, to time.Time) (decimal.Decimal, error) {
+		require.Equal(t, "ETH", cn)
+		require.Equal(t, "EUR", ft)
+		return decimal.NewFromFloat32(12.12), nil
+	}
+
+	var router = chi.NewRouter()
+	router.Get("/v1/{coin}/{fiat}/avg", pkg.GetAverageForPair(rates, rates, pkg.NewPairs(btclists.Pair{Coin: "ETH", Fiat: "EUR"})))
+
+	var values = url.Values{}
+	values.Add("from", someTime.Format(btclists.DateTimeFormat))
+	values.Add("to", someTimeLater.Format(btclists.DateTimeFormat))
+
+	var response = httptest.NewRecorder()
+	var request = httptest.NewRequest("GET", fmt.Sprintf("/v1/ETH/EUR/avg?%s", values.Encode()), nil)
+
+	router.ServeHTTP(response, request)
+
+	require.Equal(t, http.StatusOK, response.Code)
+
+	var rateResponse pkg.RateResponse
+	var err = json.NewDecoder(response.Body).Decode(&rateResponse)
+	require.Nil(t, err)
+	require.Equal(t, "12.12", rateResponse.Data)
+}
+
+func TestRangeHandlerSuccess(t *testing.T) {
+	var rates = new(RateServerMock)
+	rates.RangePageFunc = func(ctx context.Context, cn string, ft string, from time.Time, to time.Time, page btclists.Page) ([]btclists.Rate, error) {
+		require.Equal(t, COIN, cn)
+		require.Equal(t, FIAT, ft)
+		require.Equal(t, 1, page.Limit)
+		require.Equal(t, btclists.Ascending, page.Order)
+		require.True(t, page.After.IsZero())
+		return []btclists.Rate{someRate}, nil
+	}
+
+	var httpFunc = pkg.GetRange(rates, FIAT, COIN)
+	var response = httptest.NewRecorder()
+
+	var values = url.Values{}
+	values.Add("from", someTime.Format(btclists.DateTimeFormat))
+	values.Add("to", someTimeLater.Format(btclists.DateTimeFormat))
+	values.Add("limit", "1")
+	values.Add("order", "asc")
+
+	var request = httptest.NewRequest("GET", fmt.Sprintf("/range?%s", values.Encode()), nil)
+
+	httpFunc(response, request)
+
+	require.Equal(t, http.StatusOK, response.Code)
+
+	var rangeResponse pkg.RangeResponse
+	var err = json.NewDecoder(response.Body).Decode(&rangeResponse)
+	require.Nil(t, err)
+	require.Len(t, rangeResponse.Data, 1)
+	require.Equal(t, someRate.Rate.String(), rangeResponse.Data[0].Rate.String())
+	require.NotEmpty(t, rangeResponse.Next)
+
+	t.Logf("Should pass cursor from previous page")
+	{
+		rates.RangePageFunc = func(ctx context.Context, cn string, ft string, from time.Time, to time.Time, page btclists.Page) ([]btclists.Rate, error) {
+			require.True(t, someRate.Date.Equal(page.After))
+			return nil, nil
+		}
+
+		values.Set("cursor", rangeResponse.Next)
+
+		var nextResponse = httptest.NewRecorder()
+		httpFunc(nextResponse, httptest.NewRequest("GET", fmt.Sprintf("/range?%s", values.Encode()), nil))
+
+		require.Equal(t, http.StatusOK, nextResponse.Code)
+
+		var nextRange pkg.RangeResponse
+		require.NoError(t, json.NewDecoder(nextResponse.Body).Decode(&nextRange))
+		require.Empty(t, nextRange.Data)
+		require.Empty(t, nextRange.Next)
+	}
+}
+
+func TestRangeHandlerFailure_BadPage(t *testing.T) {
+	var rates = new(RateServerMock)
+	var httpFunc = pkg.GetRange(rates, FIAT, COIN)
+
+	var values = url.Values{}
+	values.Add("from", someTime.Format(btclists.DateTimeFormat))
+	values.Add("to", someTimeLater.Format(btclists.DateTimeFormat))
+
+	for _, bad := range []url.Values{
+		{"limit": []string{"0"}},
+		{"limit": []string{"5000"}},
+		{"order": []string{"sideways"}},
+		{"cursor": []string{"not-a-cursor"}},
+	} {
+		var query = url.Values{}
+		for key, value := range values {
+			query[key] = value
+		}
+		for key, value := range bad {
+			query[key] = value
+		}
+
+		var response = httptest.NewRecorder()
+		httpFunc(response, httptest.NewRequest("GET", fmt.Sprintf("/range?%s", query.Encode()), nil))
+		require.Equal(t, http.StatusBadRequest, response.Code)
+	}
+}
+
+// CandleServiceMock implements btclists.CandleService.
+type CandleServiceMock struct {
+	CandlesFunc func(ctx context.Context, COIN string, FIAT string, from, to time.Time) ([]btclists.Candle, error)
+}
+
+func (cs CandleServiceMock) Candles(ctx context.Context, COIN string, FIAT string, from, to time.Time) ([]btclists.Candle, error) {
+	return cs.CandlesFunc(ctx, COIN, FIAT, from, to)
+}
+
+func TestCandlesHandlerSuccess(t *testing.T) {
+	var candle = btclists.Candle{
+		Coin:   COIN,
+		Fiat:   FIAT,
+		Start:  someTime,
+		End:    someTime.Add(time.Minute * 2),
+		Open:   decimal.NewFromFloat(40),
+		High:   decimal.NewFromFloat(45),
+		Low:    decimal.NewFromFloat(39),
+		Close:  decimal.NewFromFloat(43.322),
+		Volume: decimal.NewFromFloat(2.5),
+		Trades: 12,
+	}
+
+	var candles = CandleServiceMock{
+		CandlesFunc: func(ctx context.Context, cn string, ft string, from, to time.Time) ([]btclists.Candle, error) {
+			require.Equal(t, COIN, cn)
+			require.Equal(t, FIAT, ft)
+			return []btclists.Candle{candle}, nil
+		},
+	}
+
+	var httpFunc = pkg.GetCandles(candles, FIAT, COIN)
+	var response = httptest.NewRecorder()
+
+	var values = url.Values{}
+	values.Add("from", someTime.Format(btclists.DateTimeFormat))
+	values.Add("to", someTimeLater.Format(btclists.DateTimeFormat))
+
+	httpFunc(response, httptest.NewRequest("GET", fmt.Sprintf("/ohlc?%s", values.Encode()), nil))
+
+	require.Equal(t, http.StatusOK, response.Code)
+
+	var candlesResponse pkg.CandlesResponse
+	var err = json.NewDecoder(response.Body).Decode(&candlesResponse)
+	require.Nil(t, err)
+	require.Len(t, candlesResponse.Data, 1)
+	require.Equal(t, "45", candlesResponse.Data[0].High.String())
+	require.Equal(t, "39", candlesResponse.Data[0].Low.String())
+	require.Equal(t, int64(12), candlesResponse.Data[0].Trades)
+}
+
+func TestCandlesHandlerFailure_ServerIssues(t *testing.T) {
+	var candles = CandleServiceMock{
+		CandlesFunc: func(ctx context.Context, cn string, ft string, from, to time.Time) ([]btclists.Candle, error) {
+			return nil, errors.New("kaboom")
+		},
+	}
+
+	var httpFunc = pkg.GetCandles(candles, FIAT, COIN)
+	var response = httptest.NewRecorder()
+
+	var values = url.Values{}
+	values.Add("from", someTime.Format(btclists.DateTimeFormat))
+	values.Add("to", someTimeLater.Format(btclists.DateTimeFormat))
+
+	httpFunc(response, httptest.NewRequest("GET", fmt.Sprintf("/ohlc?%s", values.Encode()), nil))
+
+	require.NotEqual(t, 0, response.Body.Len())
+	require.Equal(t, http.StatusInternalServerError, response.Code)
+}
+
+func TestCandlesHandler_WithResolution(t *testing.T) {
+	var candles = CandleServiceMock{
+		CandlesFunc: func(ctx context.Context, cn string, ft string, from, to time.Time) ([]btclists.Candle, error) {
+			var resolution, ok = btclists.ResolutionFrom(ctx)
+			require.True(t, ok)
+			require.Equal(t, btclists.Resolution1Day, resolution)
+			return nil, nil
+		},
+	}
+
+	var httpFunc = pkg.GetCandles(candles, FIAT, COIN)
+
+	var values = url.Values{}
+	values.Add("from", someTime.Format(btclists.DateTimeFormat))
+	values.Add("to", someTimeLater.Format(btclists.DateTimeFormat))
+	values.Add("resolution", "1day")
+
+	var response = httptest.NewRecorder()
+	httpFunc(response, httptest.NewRequest("GET", fmt.Sprintf("/ohlc?%s", values.Encode()), nil))
+	require.Equal(t, http.StatusOK, response.Code)
+
+	t.Logf("Should reject unknown resolution")
+	{
+		values.Set("resolution", "1WEEK")
+
+		var badResponse = httptest.NewRecorder()
+		httpFunc(badResponse, httptest.NewRequest("GET", fmt.Sprintf("/ohlc?%s", values.Encode()), nil))
+		require.Equal(t, http.StatusBadRequest, badResponse.Code)
+	}
+}
+
+// ProviderStatusMock implements pkg.ProviderStatusReporter.
+type ProviderStatusMock []pkg.ProviderStatus
+
+func (p ProviderStatusMock) Status() []pkg.ProviderStatus {
+	return p
+}
+
+func TestProviderStatusHandler(t *testing.T) {
+	var reporter = ProviderStatusMock{
+		{Name: "coinapi", State: pkg.BreakerOpen, LastError: btclists.ErrLimitReached.Error()},
+		{Name: "kraken", State: pkg.BreakerClosed, Serving: true},
+	}
+
+	var response = httptest.NewRecorder()
+	pkg.GetProviderStatus(reporter)(response, httptest.NewRequest("GET", "/v1/status/providers", nil))
+
+	require.Equal(t, http.StatusOK, response.Code)
+
+	var statusResponse pkg.ProviderStatusResponse
+	require.NoError(t, json.NewDecoder(response.Body).Decode(&statusResponse))
+	require.Equal(t, []pkg.ProviderStatus(reporter), statusResponse.Data)
+}
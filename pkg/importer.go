@@ -0,0 +1,473 @@
+package pkg
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gopkg.in/yaml.v2"
+
+	"github.com/influx6/btclists"
+)
+
+// ImportFormat defines the file format rates are imported from.
+type ImportFormat string
+
+const (
+	CSVImport   ImportFormat = "csv"
+	JSONLImport ImportFormat = "jsonl"
+	YAMLImport  ImportFormat = "yaml"
+
+	// DefaultImportChunk is the number of rows inserted at once by Importer.
+	DefaultImportChunk = 500
+
+	// MaxImportRejections is the maximum number of rejected rows listed
+	// with their reasons in an ImportReport.
+	MaxImportRejections = 100
+)
+
+var (
+	ErrUnknownImportFormat = errors.New("format must be either csv, jsonl or yaml")
+)
+
+// ParseImportFormat parses giving value (case insensitive) into an ImportFormat,
+// returning ErrUnknownImportFormat if not a supported format.
+func ParseImportFormat(value string) (ImportFormat, error) {
+	var format = ImportFormat(strings.ToLower(strings.TrimSpace(value)))
+	switch format {
+	case CSVImport, JSONLImport, YAMLImport:
+		return format, nil
+	case "yml":
+		return YAMLImport, nil
+	default:
+		return "", ErrUnknownImportFormat
+	}
+}
+
+// ImportFormatOf returns the ImportFormat of a file from it's extension (e.g rates.csv).
+func ImportFormatOf(file string) (ImportFormat, error) {
+	return ParseImportFormat(strings.TrimPrefix(filepath.Ext(file), "."))
+}
+
+// Rejection describes a row rejected by an import.
+//
+// Row is the line of the row for CSV and JSON Lines files and the position
+// of the item in the list for YAML files.
+type Rejection struct {
+	Row    int    `json:"row"`
+	Reason string `json:"reason"`
+}
+
+// ImportReport describes the outcome of an import.
+//
+// Updated counts stored rates replaced by an upsert import. Rejected counts all
+// rejected rows, while Rejections lists the first MaxImportRejections of them.
+type ImportReport struct {
+	Rows       int         `json:"rows"`
+	Inserted   int         `json:"inserted"`
+	Updated    int         `json:"updated"`
+	Duplicates int         `json:"duplicates"`
+	Rejected   int         `json:"rejected"`
+	Rejections []Rejection `json:"rejections"`
+}
+
+func (r *ImportReport) reject(row int, reason string) {
+	r.Rejected++
+	if len(r.Rejections) < MaxImportRejections {
+		r.Rejections = append(r.Rejections, Rejection{Row: row, Reason: reason})
+	}
+}
+
+// rawRate is a row of an imported file before validation.
+type rawRate struct {
+	Row        int    `yaml:"-"`
+	Date       string `yaml:"date"`
+	Rate       string `yaml:"rate"`
+	Coin       string `yaml:"coin"`
+	Fiat       string `yaml:"fiat"`
+	Resolution string `yaml:"resolution"`
+}
+
+// Importer imports historical rates from CSV, JSON Lines or YAML files (in the shape
+// of fixtures/ratings.yml) into DB, validating rows and inserting them in chunks
+// with RatesDB.AddBatch.
+//
+// Rows whose pair already has a rate at the same date, either stored or earlier in
+// the file, are skipped as duplicates unless Upsert is set.
+type Importer struct {
+	DB btclists.RatesDB
+
+	// ChunkSize sets the number of rows inserted at once, defaults to DefaultImportChunk.
+	ChunkSize int
+
+	// Pair sets the coin and fiat of rows without them, e.g files with
+	// only date and rate columns.
+	Pair btclists.Pair
+
+	// Upsert sets rows to replace stored rates of the same pair and date (e.g corrections),
+	// where a pair and date is repeated within a file the last row is kept.
+	Upsert bool
+}
+
+func NewImporter(db btclists.RatesDB) *Importer {
+	return &Importer{
+		DB:        db,
+		ChunkSize: DefaultImportChunk,
+	}
+}
+
+// Import reads rates from r in provided format and stores them in DB.
+//
+// CSV files must start with a header naming their columns, of which date and rate are
+// required and coin and fiat are required unless Importer.Pair is set, other columns
+// (e.g id) are ignored. Dates are RFC3339 timestamps or YYYY-MM-DD dates.
+//
+// Invalid rows are rejected and reported, the import only fails where the file
+// can't be read or rates can't be stored.
+func (i *Importer) Import(ctx context.Context, r io.Reader, format ImportFormat) (ImportReport, error) {
+	var report ImportReport
+
+	var chunkSize = i.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = DefaultImportChunk
+	}
+
+	var chunk = make([]btclists.Rate, 0, chunkSize)
+	var collect = func(raw rawRate) error {
+		report.Rows++
+
+		var rate, err = i.parse(raw)
+		if err != nil {
+			report.reject(raw.Row, err.Error())
+			return nil
+		}
+
+		chunk = append(chunk, rate)
+		if len(chunk) < chunkSize {
+			return nil
+		}
+
+		var flushErr = i.flush(ctx, chunk, &report)
+		chunk = chunk[:0]
+		return flushErr
+	}
+
+	var readErr error
+	switch format {
+	case CSVImport:
+		readErr = readCSVRates(r, collect, &report)
+	case JSONLImport:
+		readErr = readJSONLRates(r, collect, &report)
+	case YAMLImport:
+		readErr = readYAMLRates(r, collect)
+	default:
+		return report, ErrUnknownImportFormat
+	}
+
+	if readErr != nil {
+		return report, readErr
+	}
+
+	if err := i.flush(ctx, chunk, &report); err != nil {
+		return report, err
+	}
+
+	log.Printf("[BTC Listings] | [INFO] | [IMPORT] | Imported rates | rows: %d | inserted: %d | updated: %d | duplicates: %d | rejected: %d\n", report.Rows, report.Inserted, report.Updated, report.Duplicates, report.Rejected)
+	return report, nil
+}
+
+// parse validates and converts raw into a Rate.
+func (i *Importer) parse(raw rawRate) (btclists.Rate, error) {
+	var pair = normalizePair(raw.Coin, raw.Fiat)
+	if pair.Coin == "" {
+		pair.Coin = i.Pair.Coin
+	}
+	if pair.Fiat == "" {
+		pair.Fiat = i.Pair.Fiat
+	}
+
+	if pair.Coin == "" || pair.Fiat == "" {
+		return btclists.Rate{}, errors.New("coin and fiat are required")
+	}
+
+	var date, dateErr = parseImportDate(raw.Date)
+	if dateErr != nil {
+		return btclists.Rate{}, dateErr
+	}
+
+	if date.After(time.Now()) {
+		return btclists.Rate{}, fmt.Errorf("date %q is in the future", raw.Date)
+	}
+
+	var value = strings.TrimSpace(raw.Rate)
+	if value == "" {
+		return btclists.Rate{}, errors.New("rate is required")
+	}
+
+	var rate, rateErr = decimal.NewFromString(value)
+	if rateErr != nil {
+		return btclists.Rate{}, fmt.Errorf("rate %q is not a decimal", value)
+	}
+
+	if !rate.IsPositive() {
+		return btclists.Rate{}, fmt.Errorf("rate %q must be greater than zero", value)
+	}
+
+	var resolution = btclists.Spot
+	if strings.TrimSpace(raw.Resolution) != "" {
+		var resolutionErr error
+		if resolution, resolutionErr = btclists.ParseResolution(raw.Resolution); resolutionErr != nil {
+			return btclists.Rate{}, resolutionErr
+		}
+	}
+
+	return btclists.Rate{
+		Date:       date,
+		Rate:       rate,
+		Coin:       pair.Coin,
+		Fiat:       pair.Fiat,
+		Resolution: resolution,
+	}, nil
+}
+
+// flush stores rates of chunk not yet stored, counting the others as duplicates
+// unless Upsert is set.
+func (i *Importer) flush(ctx context.Context, chunk []btclists.Rate, report *ImportReport) error {
+	if len(chunk) == 0 {
+		return nil
+	}
+
+	type key struct {
+		pair btclists.Pair
+		date int64
+	}
+
+	type span struct {
+		from time.Time
+		to   time.Time
+	}
+
+	// rates are stored at second precision, so are compared by it.
+	var seen = map[key]int{}
+	var spans = map[btclists.Pair]span{}
+	var unique = make([]btclists.Rate, 0, len(chunk))
+	for _, rate := range chunk {
+		var pair = btclists.Pair{Coin: rate.Coin, Fiat: rate.Fiat}
+		var k = key{pair: pair, date: rate.Date.Unix()}
+		if index, ok := seen[k]; ok {
+			report.Duplicates++
+			if i.Upsert {
+				unique[index] = rate
+			}
+			continue
+		}
+		seen[k] = len(unique)
+		unique = append(unique, rate)
+
+		var s, ok = spans[pair]
+		if !ok || rate.Date.Before(s.from) {
+			s.from = rate.Date
+		}
+		if !ok || rate.Date.After(s.to) {
+			s.to = rate.Date
+		}
+		spans[pair] = s
+	}
+
+	var stored = map[key]bool{}
+	for pair, s := range spans {
+		var existing, err = i.DB.Range(ctx, pair.Coin, pair.Fiat, s.from.Truncate(time.Second), s.to)
+		if err != nil {
+			log.Printf("[BTC Listings] | [ERROR] | [IMPORT] | Failed to retrieve stored rates | %s/%s | %s\n", pair.Coin, pair.Fiat, err)
+			return err
+		}
+		for _, rate := range existing {
+			stored[key{pair: pair, date: rate.Date.Unix()}] = true
+		}
+	}
+
+	var updated int
+	var rates = make([]btclists.Rate, 0, len(unique))
+	for _, rate := range unique {
+		if stored[key{pair: btclists.Pair{Coin: rate.Coin, Fiat: rate.Fiat}, date: rate.Date.Unix()}] {
+			if !i.Upsert {
+				report.Duplicates++
+				continue
+			}
+			updated++
+		}
+		rates = append(rates, rate)
+	}
+
+	if i.Upsert {
+		ctx = btclists.WithUpsert(ctx)
+	}
+
+	if err := i.DB.AddBatch(ctx, rates); err != nil {
+		log.Printf("[BTC Listings] | [CRITICAL] | [IMPORT] | Failed to store rates | %s\n", err)
+		return err
+	}
+	report.Inserted += len(rates) - updated
+	report.Updated += updated
+	return nil
+}
+
+// parseImportDate parses a RFC3339 timestamp or YYYY-MM-DD date.
+func parseImportDate(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, errors.New("date is required")
+	}
+
+	if date, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return date.UTC(), nil
+	}
+
+	if date, err := time.Parse(btclists.DateFormat, value); err == nil {
+		return date, nil
+	}
+	return time.Time{}, fmt.Errorf("date %q is neither a RFC3339 timestamp or YYYY-MM-DD date", value)
+}
+
+// readCSVRates reads rows of a CSV file with a header into collect.
+func readCSVRates(r io.Reader, collect func(rawRate) error, report *ImportReport) error {
+	var reader = csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	var header, err = reader.Read()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read csv header: %s", err)
+	}
+
+	var columns = map[string]int{}
+	for index, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = index
+	}
+
+	for _, required := range []string{"date", "rate"} {
+		if _, ok := columns[required]; !ok {
+			return fmt.Errorf("csv header is missing required column %q", required)
+		}
+	}
+
+	var row = 1
+	for {
+		var record, readErr = reader.Read()
+		if readErr == io.EOF {
+			return nil
+		}
+
+		row++
+		if parseErr, ok := readErr.(*csv.ParseError); ok {
+			report.Rows++
+			report.reject(row, parseErr.Err.Error())
+			continue
+		}
+		if readErr != nil {
+			return readErr
+		}
+
+		if len(record) != len(header) {
+			report.Rows++
+			report.reject(row, fmt.Sprintf("expected %d fields but found %d", len(header), len(record)))
+			continue
+		}
+
+		var field = func(name string) string {
+			if index, ok := columns[name]; ok {
+				return record[index]
+			}
+			return ""
+		}
+
+		if err := collect(rawRate{
+			Row:        row,
+			Date:       field("date"),
+			Rate:       field("rate"),
+			Coin:       field("coin"),
+			Fiat:       field("fiat"),
+			Resolution: field("resolution"),
+		}); err != nil {
+			return err
+		}
+	}
+}
+
+// readJSONLRates reads a JSON object per line into collect, skipping blank lines.
+func readJSONLRates(r io.Reader, collect func(rawRate) error, report *ImportReport) error {
+	var scanner = bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var row int
+	for scanner.Scan() {
+		row++
+
+		var line = bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var fields map[string]interface{}
+		var decoder = json.NewDecoder(bytes.NewReader(line))
+		decoder.UseNumber()
+		if err := decoder.Decode(&fields); err != nil {
+			report.Rows++
+			report.reject(row, fmt.Sprintf("invalid json: %s", err))
+			continue
+		}
+
+		var field = func(name string) string {
+			if value, ok := fields[name]; ok && value != nil {
+				return fmt.Sprint(value)
+			}
+			return ""
+		}
+
+		if err := collect(rawRate{
+			Row:        row,
+			Date:       field("date"),
+			Rate:       field("rate"),
+			Coin:       field("coin"),
+			Fiat:       field("fiat"),
+			Resolution: field("resolution"),
+		}); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// readYAMLRates reads a YAML list of rates into collect.
+func readYAMLRates(r io.Reader, collect func(rawRate) error) error {
+	var data, err = ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	var rows []rawRate
+	if err := yaml.Unmarshal(data, &rows); err != nil {
+		return fmt.Errorf("invalid yaml: %s", err)
+	}
+
+	for index, row := range rows {
+		row.Row = index + 1
+		if err := collect(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,200 @@
+package pkg_test
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+
+	"github.com/influx6/btclists"
+	"github.com/influx6/btclists/pkg"
+)
+
+// ImportRatesDB serves rates added with AddBatch through Range.
+type ImportRatesDB struct {
+	RecordingRatesDB
+}
+
+// AddBatch records rates, replacing recorded rates of the same pair and date
+// where ctx is set with btclists.WithUpsert.
+func (d *ImportRatesDB) AddBatch(ctx context.Context, rates []btclists.Rate) error {
+	if !btclists.UpsertFrom(ctx) {
+		return d.RecordingRatesDB.AddBatch(ctx, rates)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, rate := range rates {
+		var replaced bool
+		for index, recorded := range d.rates {
+			if recorded.Coin == rate.Coin && recorded.Fiat == rate.Fiat && recorded.Date.Equal(rate.Date) {
+				d.rates[index] = rate
+				replaced = true
+			}
+		}
+		if !replaced {
+			d.rates = append(d.rates, rate)
+		}
+	}
+	return nil
+}
+
+func (d *ImportRatesDB) Range(ctx context.Context, coin string, fiat string, from, to time.Time) ([]btclists.Rate, error) {
+	var found []btclists.Rate
+	for _, rate := range d.Rates() {
+		if rate.Coin == coin && rate.Fiat == fiat && !rate.Date.Before(from) && !rate.Date.After(to) {
+			found = append(found, rate)
+		}
+	}
+	return found, nil
+}
+
+func TestImporter_CSV(t *testing.T) {
+	var db = new(ImportRatesDB)
+	require.NoError(t, db.AddBatch(context.Background(), []btclists.Rate{
+		{Date: time.Date(2020, 4, 8, 14, 3, 0, 0, time.UTC), Coin: "BTC", Fiat: "USD", Rate: decimal.NewFromFloat(7203)},
+	}))
+
+	var file = strings.Join([]string{
+		"id,date,coin,fiat,rate",
+		"1,2020-04-08T14:00:00Z,btc,usd,7200.123456789012345678",
+		"2,2020-04-08T14:01:00Z,BTC,USD,7201",
+		"3,2020-04-08T14:01:00Z,BTC,USD,7201.5",
+		"4,2020-04-08T14:02:00Z,BTC,USD,-1",
+		"5,,BTC,USD,7202",
+		"6,2020-04-08T14:03:00Z,BTC,USD,7203",
+		"7,2020-04-08T14:04:00Z,BTC,USD",
+		"8,2020-04-08,ETH,USD,170.5",
+		"9,2999-01-01T00:00:00Z,BTC,USD,7205",
+	}, "\n")
+
+	var importer = pkg.NewImporter(db)
+	importer.ChunkSize = 2
+
+	var report, err = importer.Import(context.Background(), strings.NewReader(file), pkg.CSVImport)
+	require.NoError(t, err)
+	require.Equal(t, 9, report.Rows)
+	require.Equal(t, 3, report.Inserted)
+	require.Equal(t, 2, report.Duplicates)
+	require.Equal(t, 4, report.Rejected)
+
+	var rows []int
+	for _, rejection := range report.Rejections {
+		rows = append(rows, rejection.Row)
+	}
+	require.Equal(t, []int{5, 6, 8, 10}, rows)
+	require.Contains(t, report.Rejections[0].Reason, "greater than zero")
+	require.Contains(t, report.Rejections[1].Reason, "date is required")
+	require.Contains(t, report.Rejections[2].Reason, "expected 5 fields")
+	require.Contains(t, report.Rejections[3].Reason, "future")
+
+	var rates = db.Rates()
+	require.Len(t, rates, 4)
+	require.Equal(t, "7200.123456789012345678", rates[1].Rate.String())
+	require.Equal(t, "BTC", rates[1].Coin)
+	require.Equal(t, "7201", rates[2].Rate.String())
+	require.Equal(t, "ETH", rates[3].Coin)
+
+	_, err = importer.Import(context.Background(), strings.NewReader("date,coin,fiat\n"), pkg.CSVImport)
+	require.Error(t, err)
+}
+
+func TestImporter_JSONL(t *testing.T) {
+	var db = new(ImportRatesDB)
+
+	var file = strings.Join([]string{
+		`{"date":"2020-04-08T14:00:00Z","rate":7200.5}`,
+		``,
+		`{"date":"2020-04-08T14:01:00Z","rate":"7201.000000000000000001","resolution":"1MIN"}`,
+		`{"date":"2020-04-08T14:02:00Z","rate":"7202"`,
+		`{"date":"2020-04-08T14:03:00Z","rate":"7203","resolution":"7MIN"}`,
+	}, "\n")
+
+	var importer = pkg.NewImporter(db)
+	importer.Pair = btclists.Pair{Coin: "BTC", Fiat: "USD"}
+
+	var report, err = importer.Import(context.Background(), strings.NewReader(file), pkg.JSONLImport)
+	require.NoError(t, err)
+	require.Equal(t, 4, report.Rows)
+	require.Equal(t, 2, report.Inserted)
+	require.Equal(t, 2, report.Rejected)
+	require.Equal(t, 4, report.Rejections[0].Row)
+	require.Contains(t, report.Rejections[0].Reason, "invalid json")
+	require.Equal(t, 5, report.Rejections[1].Row)
+	require.Equal(t, btclists.ErrInvalidResolution.Error(), report.Rejections[1].Reason)
+
+	var rates = db.Rates()
+	require.Len(t, rates, 2)
+	require.Equal(t, "7200.5", rates[0].Rate.String())
+	require.Equal(t, "7201.000000000000000001", rates[1].Rate.String())
+	require.Equal(t, btclists.Resolution1Min, rates[1].Resolution)
+	require.Equal(t, "USD", rates[1].Fiat)
+}
+
+func TestImporter_YAML(t *testing.T) {
+	var db = new(ImportRatesDB)
+	var importer = pkg.NewImporter(db)
+
+	var format, formatErr = pkg.ImportFormatOf("../fixtures/ratings.yml")
+	require.NoError(t, formatErr)
+	require.Equal(t, pkg.YAMLImport, format)
+
+	var fixtures, err = getFixtures()
+	require.NoError(t, err)
+
+	var file, fileErr = os.Open("../fixtures/ratings.yml")
+	require.NoError(t, fileErr)
+	defer file.Close()
+
+	var report, importErr = importer.Import(context.Background(), file, format)
+	require.NoError(t, importErr)
+	require.Equal(t, len(fixtures), report.Inserted)
+	require.Equal(t, 0, report.Rejected)
+
+	var rates = db.Rates()
+	for index, fixture := range fixtures {
+		require.True(t, fixture.Date.Equal(rates[index].Date))
+		require.True(t, fixture.Rate.Equal(rates[index].Rate))
+	}
+
+	_, err = file.Seek(0, 0)
+	require.NoError(t, err)
+
+	report, importErr = importer.Import(context.Background(), file, format)
+	require.NoError(t, importErr)
+	require.Equal(t, 0, report.Inserted)
+	require.Equal(t, len(fixtures), report.Duplicates)
+}
+
+func TestImporter_Upsert(t *testing.T) {
+	var db = new(ImportRatesDB)
+	require.NoError(t, db.AddBatch(context.Background(), []btclists.Rate{
+		{Date: time.Date(2020, 4, 8, 14, 0, 0, 0, time.UTC), Coin: "BTC", Fiat: "USD", Rate: decimal.NewFromFloat(7200)},
+	}))
+
+	var file = strings.Join([]string{
+		"date,coin,fiat,rate",
+		"2020-04-08T14:00:00Z,BTC,USD,7200.5",
+		"2020-04-08T14:01:00Z,BTC,USD,7201",
+		"2020-04-08T14:01:00Z,BTC,USD,7201.5",
+	}, "\n")
+
+	var importer = pkg.NewImporter(db)
+	importer.Upsert = true
+
+	var report, err = importer.Import(context.Background(), strings.NewReader(file), pkg.CSVImport)
+	require.NoError(t, err)
+	require.Equal(t, 1, report.Inserted)
+	require.Equal(t, 1, report.Updated)
+	require.Equal(t, 1, report.Duplicates)
+
+	var rates = db.Rates()
+	require.Len(t, rates, 2)
+	require.Equal(t, "7200.5", rates[0].Rate.String())
+	require.Equal(t, "7201.5", rates[1].Rate.String())
+}
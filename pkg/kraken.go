@@ -0,0 +1,317 @@
+package pkg
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/influx6/btclists"
+)
+
+const (
+	KrakenURL = "https://api.kraken.com"
+)
+
+var (
+	_ CoinMarketAPI   = (*Kraken)(nil)
+	_ CandleMarketAPI = (*Kraken)(nil)
+
+	// DefaultKrakenAssets maps crypto-currency symbols to the symbols used by Kraken
+	// where they differ.
+	DefaultKrakenAssets = map[string]string{
+		"BTC": "XBT",
+	}
+
+	// krakenIntervals maps supported resolutions to Kraken OHLC intervals in minutes.
+	krakenIntervals = map[btclists.Resolution]int{
+		"1MIN":  1,
+		"5MIN":  5,
+		"15MIN": 15,
+		"30MIN": 30,
+		"1HRS":  60,
+		"4HRS":  240,
+		"1DAY":  1440,
+	}
+)
+
+// Kraken implements CoinMarketAPI and CandleMarketAPI on top of the public
+// Kraken REST API, which requires no token for market data.
+//
+// Kraken only serves the last 720 candles of an interval, hence history further
+// back than that for desired resolution will not be available.
+//
+// Only resolutions matching a Kraken interval (1MIN, 5MIN, 15MIN, 30MIN, 1HRS, 4HRS and 1DAY)
+// are supported, where an unsupported resolution is requested, Resolution is used instead.
+type Kraken struct {
+	URL        string
+	Client     btclists.Client
+	Assets     map[string]string
+	Resolution btclists.Resolution
+}
+
+func NewKraken(url string, client btclists.Client) *Kraken {
+	return &Kraken{
+		URL:        url,
+		Client:     client,
+		Assets:     DefaultKrakenAssets,
+		Resolution: btclists.Resolution1Min,
+	}
+}
+
+type krakenResponse struct {
+	Error  []string        `json:"error"`
+	Result json.RawMessage `json:"result"`
+}
+
+type krakenTicker struct {
+	// LastTrade holds the price and volume of last trade.
+	LastTrade []string `json:"c"`
+}
+
+// Rate retrieves rate for giving coin based on fiat currency for specific time,
+// if time is zero, then the price of the last trade is returned.
+//
+// Rates for specific times are the closing prices of the candles covering said times.
+func (k *Kraken) Rate(ctx context.Context, coin string, fiat string, at time.Time) (btclists.Rate, error) {
+	if !at.IsZero() {
+		var resolution = k.resolutionFor(ctx)
+		var candles, err = k.Candles(ctx, coin, fiat, at.Add(-resolution.Duration()), time.Time{}, 0)
+		if err != nil {
+			return btclists.Rate{}, err
+		}
+
+		for _, candle := range candles {
+			if candle.End.After(at) {
+				var rate = candle.Rate()
+				rate.Resolution = btclists.Spot
+				return rate, nil
+			}
+		}
+		return btclists.Rate{}, btclists.ErrRateNotFound
+	}
+
+	var query = url.Values{}
+	query.Set("pair", k.pair(coin, fiat))
+
+	var tickers map[string]krakenTicker
+	if err := k.get(ctx, "/0/public/Ticker", query, &tickers); err != nil {
+		return btclists.Rate{}, err
+	}
+
+	for _, ticker := range tickers {
+		if len(ticker.LastTrade) == 0 {
+			break
+		}
+
+		var value, err = decimal.NewFromString(ticker.LastTrade[0])
+		if err != nil {
+			return btclists.Rate{}, err
+		}
+
+		// Kraken tickers carry no timestamp, so we date them by time of retrieval.
+		return btclists.Rate{
+			Date: time.Now().UTC().Truncate(time.Second),
+			Rate: value,
+			Coin: coin,
+			Fiat: fiat,
+		}, nil
+	}
+
+	return btclists.Rate{}, btclists.ErrRateNotFound
+}
+
+// RangeFrom returns all results from giving time till provided limit.
+func (k *Kraken) RangeFrom(ctx context.Context, coin string, fiat string, from time.Time, limit int) ([]btclists.Rate, error) {
+	return k.Range(ctx, coin, fiat, from, time.Time{}, limit)
+}
+
+// Range retrieves all rates for giving coin for giving fiat from provided time range
+// (if to is not provided, then till limit requested).
+//
+// Rates are the closing prices of the candles returned by Kraken.Candles.
+func (k *Kraken) Range(ctx context.Context, coin string, fiat string, from time.Time, to time.Time, limit int) ([]btclists.Rate, error) {
+	var candles, err = k.Candles(ctx, coin, fiat, from, to, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	var rates = make([]btclists.Rate, 0, len(candles))
+	for _, candle := range candles {
+		rates = append(rates, candle.Rate())
+	}
+	return rates, nil
+}
+
+// Candles retrieves all OHLCV candles for giving fiat and crypto-coin pair starting within provided
+// time range (if to is not provided, then till limit requested).
+func (k *Kraken) Candles(ctx context.Context, coin string, fiat string, from time.Time, to time.Time, limit int) ([]btclists.Candle, error) {
+	if from.IsZero() {
+		return nil, errors.New("invalid 'from' time range provided")
+	}
+
+	var resolution = k.resolutionFor(ctx)
+
+	var query = url.Values{}
+	query.Set("pair", k.pair(coin, fiat))
+	query.Set("interval", fmt.Sprintf("%d", krakenIntervals[resolution]))
+	query.Set("since", fmt.Sprintf("%d", from.Unix()-1))
+
+	var result map[string]json.RawMessage
+	if err := k.get(ctx, "/0/public/OHLC", query, &result); err != nil {
+		return nil, err
+	}
+
+	var candles []btclists.Candle
+	for key, data := range result {
+		// result holds the candles keyed by Kraken's name for the pair
+		// alongside 'last', the cursor for the next set of candles.
+		if key == "last" {
+			continue
+		}
+
+		var entries [][]interface{}
+		var decoder = json.NewDecoder(strings.NewReader(string(data)))
+		decoder.UseNumber()
+		if err := decoder.Decode(&entries); err != nil {
+			return nil, err
+		}
+
+		for _, entry := range entries {
+			if limit > 0 && len(candles) == limit {
+				break
+			}
+
+			var candle, err = krakenCandle(entry, coin, fiat, resolution)
+			if err != nil {
+				return nil, err
+			}
+
+			if candle.Start.Before(from) || (!to.IsZero() && candle.Start.After(to)) {
+				continue
+			}
+			candles = append(candles, candle)
+		}
+	}
+
+	return candles, nil
+}
+
+// krakenCandle transforms a Kraken OHLC entry in the form of
+// [time, open, high, low, close, vwap, volume, count] into a btclists.Candle.
+func krakenCandle(entry []interface{}, coin string, fiat string, resolution btclists.Resolution) (btclists.Candle, error) {
+	var candle = btclists.Candle{Coin: coin, Fiat: fiat, Resolution: resolution}
+	if len(entry) != 8 {
+		return candle, errors.New("invalid OHLC entry received from kraken")
+	}
+
+	var start, startOk = entry[0].(json.Number)
+	var count, countOk = entry[7].(json.Number)
+	if !startOk || !countOk {
+		return candle, errors.New("invalid OHLC entry received from kraken")
+	}
+
+	var startTime, startErr = start.Int64()
+	if startErr != nil {
+		return candle, startErr
+	}
+
+	var trades, tradesErr = count.Int64()
+	if tradesErr != nil {
+		return candle, tradesErr
+	}
+
+	var values = make([]decimal.Decimal, 0, 5)
+	for _, index := range []int{1, 2, 3, 4, 6} {
+		var text, ok = entry[index].(string)
+		if !ok {
+			return candle, errors.New("invalid OHLC entry received from kraken")
+		}
+
+		var value, err = decimal.NewFromString(text)
+		if err != nil {
+			return candle, err
+		}
+		values = append(values, value)
+	}
+
+	candle.Start = time.Unix(startTime, 0).UTC()
+	candle.End = candle.Start.Add(resolution.Duration())
+	candle.Open = values[0]
+	candle.High = values[1]
+	candle.Low = values[2]
+	candle.Close = values[3]
+	candle.Volume = values[4]
+	candle.Trades = trades
+	return candle, nil
+}
+
+func (k *Kraken) resolutionFor(ctx context.Context) btclists.Resolution {
+	if resolution, ok := btclists.ResolutionFrom(ctx); ok {
+		if _, supported := krakenIntervals[resolution]; supported {
+			return resolution
+		}
+	}
+	if _, supported := krakenIntervals[k.Resolution]; supported {
+		return k.Resolution
+	}
+	return btclists.Resolution1Min
+}
+
+func (k *Kraken) pair(coin string, fiat string) string {
+	var asset = strings.ToUpper(coin)
+	if mapped, ok := k.Assets[asset]; ok {
+		asset = mapped
+	}
+	return asset + strings.ToUpper(fiat)
+}
+
+func (k *Kraken) get(ctx context.Context, path string, query url.Values, target interface{}) error {
+	var req, err = http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s%s?%s", k.URL, path, query.Encode()), nil)
+	if err != nil {
+		return err
+	}
+
+	var res, resErr = k.Client.Do(req)
+	if resErr != nil {
+		return resErr
+	}
+
+	defer res.Body.Close()
+
+	if statusErr := providerStatusError(res.StatusCode); statusErr != nil {
+		return statusErr
+	}
+
+	var response krakenResponse
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+		return err
+	}
+
+	if len(response.Error) != 0 {
+		return krakenError(response.Error[0])
+	}
+
+	return json.Unmarshal(response.Result, target)
+}
+
+// krakenError maps errors returned by Kraken into their equivalent errors.
+func krakenError(message string) error {
+	switch {
+	case strings.Contains(message, "Rate limit exceeded"), strings.Contains(message, "Too many requests"):
+		return btclists.ErrLimitReached
+	case strings.Contains(message, "Unknown asset pair"):
+		return btclists.ErrRateNotFound
+	case strings.Contains(message, "Invalid key"):
+		return btclists.ErrInvalidToken
+	case strings.Contains(message, "Permission denied"):
+		return btclists.ErrUnauthorized
+	}
+	return fmt.Errorf("kraken: %s", message)
+}
@@ -0,0 +1,111 @@
+package pkg_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influx6/btclists"
+	"github.com/influx6/btclists/pkg"
+)
+
+// newKrakenStandIn returns a httptest server standing in for the Kraken API.
+func newKrakenStandIn(t *testing.T) *httptest.Server {
+	var mux = http.NewServeMux()
+	mux.HandleFunc("/0/public/Ticker", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("pair") != "XBTUSD" {
+			_, _ = fmt.Fprint(w, `{"error":["EQuery:Unknown asset pair"]}`)
+			return
+		}
+		_, _ = fmt.Fprint(w, `{"error":[],"result":{"XXBTZUSD":{"a":["7234.20000","1","1.000"],"b":["7234.10000","2","2.000"],"c":["7234.15000","0.01000000"]}}}`)
+	})
+	mux.HandleFunc("/0/public/OHLC", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "XBTUSD", r.URL.Query().Get("pair"))
+		if r.URL.Query().Get("interval") == "1440" {
+			_, _ = fmt.Fprint(w, `{"error":["EAPI:Rate limit exceeded"]}`)
+			return
+		}
+		require.Equal(t, "1", r.URL.Query().Get("interval"))
+		_, _ = fmt.Fprint(w, `{"error":[],"result":{"XXBTZUSD":[
+			[1586355360,"7230.1","7235.0","7229.9","7234.1","7232.5","1.23400000",12],
+			[1586355420,"7234.1","7240.0","7233.0","7238.2","7236.7","0.50000000",7],
+			[1586355480,"7238.2","7239.0","7230.0","7231.0","7234.0","2.00000000",20]
+		],"last":1586355480}}`)
+	})
+	return httptest.NewServer(mux)
+}
+
+func TestKraken_Rate_Latest(t *testing.T) {
+	var server = newKrakenStandIn(t)
+	defer server.Close()
+
+	var kraken = pkg.NewKraken(server.URL, server.Client())
+
+	var rate, err = kraken.Rate(context.Background(), COIN, FIAT, time.Time{})
+	require.NoError(t, err)
+	require.Equal(t, "7234.15", rate.Rate.String())
+	require.Equal(t, COIN, rate.Coin)
+	require.Equal(t, FIAT, rate.Fiat)
+	require.False(t, rate.Date.IsZero())
+
+	t.Logf("Should map unknown pair to not found")
+	{
+		var _, unknownErr = kraken.Rate(context.Background(), "DOGE", FIAT, time.Time{})
+		require.Equal(t, btclists.ErrRateNotFound, unknownErr)
+	}
+}
+
+func TestKraken_Rate_At(t *testing.T) {
+	var server = newKrakenStandIn(t)
+	defer server.Close()
+
+	var kraken = pkg.NewKraken(server.URL, server.Client())
+
+	var rate, err = kraken.Rate(context.Background(), COIN, FIAT, time.Unix(1586355430, 0))
+	require.NoError(t, err)
+	require.Equal(t, "7238.2", rate.Rate.String())
+	require.Equal(t, btclists.Spot, rate.Resolution)
+}
+
+func TestKraken_Candles(t *testing.T) {
+	var server = newKrakenStandIn(t)
+	defer server.Close()
+
+	var kraken = pkg.NewKraken(server.URL, server.Client())
+
+	var candles, err = kraken.Candles(context.Background(), COIN, FIAT, time.Unix(1586355420, 0), time.Unix(1586355480, 0), 0)
+	require.NoError(t, err)
+	require.Len(t, candles, 2)
+
+	var candle = candles[0]
+	require.Equal(t, time.Unix(1586355420, 0).UTC(), candle.Start)
+	require.Equal(t, time.Unix(1586355480, 0).UTC(), candle.End)
+	require.Equal(t, "7234.1", candle.Open.String())
+	require.Equal(t, "7240", candle.High.String())
+	require.Equal(t, "7233", candle.Low.String())
+	require.Equal(t, "7238.2", candle.Close.String())
+	require.Equal(t, "0.5", candle.Volume.String())
+	require.Equal(t, int64(7), candle.Trades)
+	require.Equal(t, btclists.Resolution1Min, candle.Resolution)
+
+	var rates, rangeErr = kraken.Range(context.Background(), COIN, FIAT, time.Unix(1586355420, 0), time.Time{}, 1)
+	require.NoError(t, rangeErr)
+	require.Len(t, rates, 1)
+	require.Equal(t, candle.Rate(), rates[0])
+}
+
+func TestKraken_Candles_LimitReached(t *testing.T) {
+	var server = newKrakenStandIn(t)
+	defer server.Close()
+
+	var kraken = pkg.NewKraken(server.URL, server.Client())
+
+	var ctx = btclists.WithResolution(context.Background(), btclists.Resolution1Day)
+	var _, err = kraken.Candles(ctx, COIN, FIAT, someTime, time.Time{}, 0)
+	require.Equal(t, btclists.ErrLimitReached, err)
+}
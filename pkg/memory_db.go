@@ -0,0 +1,433 @@
+package pkg
+
+import (
+	"context"
+	"database/sql"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/influx6/btclists"
+)
+
+const (
+	// StdDevPrecision is the number of decimal places the standard deviation of
+	// rates is rounded to by RatesDB implementations.
+	StdDevPrecision = 20
+
+	// varianceScale is the number of decimal places the variance of rates is
+	// computed at, before taking it's square root.
+	varianceScale = 2 * StdDevPrecision
+)
+
+var (
+	_ btclists.RatesDB          = (*MemoryDB)(nil)
+	_ btclists.BudgetStore      = (*MemoryDB)(nil)
+	_ btclists.PriorRateService = (*MemoryDB)(nil)
+	_ RangeScanner              = (*MemoryDB)(nil)
+)
+
+// MemoryDB implements btclists.RatesDB and btclists.BudgetStore in memory, following
+// the semantics of PostgresDB: a pair holds a single rate per date, dates are kept
+// at second precision and returned in UTC, and lookups finding no record return
+// sql.ErrNoRows.
+//
+// MemoryDB is safe for concurrent use, it is meant for tests and local development
+// as all records are lost once the process ends.
+type MemoryDB struct {
+	mu      sync.RWMutex
+	lastId  int
+	rates   map[btclists.Pair][]btclists.Rate
+	candles map[btclists.Pair][]btclists.Candle
+	budgets map[string]btclists.Budget
+}
+
+func NewMemoryDB() *MemoryDB {
+	return &MemoryDB{
+		rates:   map[btclists.Pair][]btclists.Rate{},
+		candles: map[btclists.Pair][]btclists.Candle{},
+		budgets: map[string]btclists.Budget{},
+	}
+}
+
+// Close implements io.Closer, it does nothing as there is nothing to release.
+func (m *MemoryDB) Close() error {
+	return nil
+}
+
+func (m *MemoryDB) Add(ctx context.Context, rate btclists.Rate) error {
+	return m.AddBatch(ctx, []btclists.Rate{rate})
+}
+
+// AddBatch adds provided rates, a rate already stored for the same pair and date is kept
+// unless ctx is set with btclists.WithUpsert, where it's rate, resolution and sources are replaced.
+func (m *MemoryDB) AddBatch(ctx context.Context, rates []btclists.Rate) error {
+	var upsert = btclists.UpsertFrom(ctx)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, rate := range rates {
+		var pair = btclists.Pair{Coin: rate.Coin, Fiat: rate.Fiat}
+		var date = storedTime(rate.Date)
+		var stored = m.rates[pair]
+
+		var index = sort.Search(len(stored), func(i int) bool {
+			return !stored[i].Date.Before(date)
+		})
+
+		if index < len(stored) && stored[index].Date.Equal(date) {
+			if upsert {
+				stored[index].Rate = rate.Rate
+				stored[index].Resolution = rate.Resolution
+				stored[index].Sources = append([]string(nil), rate.Sources...)
+			}
+			continue
+		}
+
+		m.lastId++
+		rate.Id = m.lastId
+		rate.Date = date
+		rate.Sources = append([]string(nil), rate.Sources...)
+
+		stored = append(stored, btclists.Rate{})
+		copy(stored[index+1:], stored[index:])
+		stored[index] = rate
+		m.rates[pair] = stored
+	}
+	return nil
+}
+
+// AddCandles adds provided candles, ignoring candles already existing for the same
+// pair and period.
+func (m *MemoryDB) AddCandles(ctx context.Context, candles []btclists.Candle) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, candle := range candles {
+		var pair = btclists.Pair{Coin: candle.Coin, Fiat: candle.Fiat}
+		candle.Start = storedTime(candle.Start)
+		candle.End = storedTime(candle.End)
+
+		var stored = m.candles[pair]
+		var index = sort.Search(len(stored), func(i int) bool {
+			return stored[i].Start.After(candle.Start)
+		})
+
+		var exists bool
+		for i := index - 1; i >= 0 && stored[i].Start.Equal(candle.Start); i-- {
+			if stored[i].End.Equal(candle.End) {
+				exists = true
+				break
+			}
+		}
+		if exists {
+			continue
+		}
+
+		m.lastId++
+		candle.Id = m.lastId
+
+		stored = append(stored, btclists.Candle{})
+		copy(stored[index+1:], stored[index:])
+		stored[index] = candle
+		m.candles[pair] = stored
+	}
+	return nil
+}
+
+// Candles returns all candles for pair whose period starts within provided time range,
+// ordered by start of period. If a resolution is set on the context (see btclists.WithResolution),
+// only candles of said resolution are returned.
+func (m *MemoryDB) Candles(ctx context.Context, coin string, fiat string, from time.Time, to time.Time) ([]btclists.Candle, error) {
+	var resolution, byResolution = btclists.ResolutionFrom(ctx)
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var candles []btclists.Candle
+	for _, candle := range m.candles[btclists.Pair{Coin: coin, Fiat: fiat}] {
+		if candle.Start.Before(from) || candle.Start.After(to) {
+			continue
+		}
+		if byResolution && candle.Resolution != resolution {
+			continue
+		}
+		candles = append(candles, candle)
+	}
+	return candles, nil
+}
+
+// UpdateBudget stores provided budget, ignoring it if the stored budget of the
+// provider was updated after it.
+func (m *MemoryDB) UpdateBudget(ctx context.Context, budget btclists.Budget) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if stored, ok := m.budgets[budget.Provider]; ok && stored.UpdatedAt.After(budget.UpdatedAt) {
+		return nil
+	}
+
+	// times are kept at the microsecond precision of timestamptz.
+	if !budget.ResetAt.IsZero() {
+		budget.ResetAt = budget.ResetAt.UTC().Truncate(time.Microsecond)
+	}
+	budget.UpdatedAt = budget.UpdatedAt.UTC().Truncate(time.Microsecond)
+
+	m.budgets[budget.Provider] = budget
+	return nil
+}
+
+// Budget returns last known budget of provider.
+func (m *MemoryDB) Budget(ctx context.Context, provider string) (btclists.Budget, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var budget, ok = m.budgets[provider]
+	if !ok {
+		return budget, sql.ErrNoRows
+	}
+	return budget, nil
+}
+
+func (m *MemoryDB) Latest(ctx context.Context, coin string, fiat string) (btclists.Rate, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var stored = m.rates[btclists.Pair{Coin: coin, Fiat: fiat}]
+	if len(stored) == 0 {
+		return btclists.Rate{}, sql.ErrNoRows
+	}
+	return stored[len(stored)-1], nil
+}
+
+func (m *MemoryDB) Oldest(ctx context.Context, coin string, fiat string) (btclists.Rate, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var stored = m.rates[btclists.Pair{Coin: coin, Fiat: fiat}]
+	if len(stored) == 0 {
+		return btclists.Rate{}, sql.ErrNoRows
+	}
+	return stored[0], nil
+}
+
+// At returns the rate at giving timestamp, or if there is none, the first rate
+// within a 1 min after it.
+func (m *MemoryDB) At(ctx context.Context, coin string, fiat string, tm time.Time) (btclists.Rate, error) {
+	var rates = m.between(coin, fiat, tm, tm.Add(acceptableRange))
+	if len(rates) == 0 {
+		return btclists.Rate{}, sql.ErrNoRows
+	}
+	return rates[0], nil
+}
+
+// Before returns the last rate at or before provided time, dated no earlier than
+// maxAge before it.
+func (m *MemoryDB) Before(ctx context.Context, coin string, fiat string, tm time.Time, maxAge time.Duration) (btclists.Rate, error) {
+	var rates = m.between(coin, fiat, tm.Add(-maxAge), tm)
+	if len(rates) == 0 {
+		return btclists.Rate{}, sql.ErrNoRows
+	}
+	return rates[len(rates)-1], nil
+}
+
+// Range returns all rates within provided time range, ordered by date from latest
+// to oldest.
+func (m *MemoryDB) Range(ctx context.Context, coin string, fiat string, from time.Time, to time.Time) ([]btclists.Rate, error) {
+	var rates = m.between(coin, fiat, from, to)
+	reverseRates(rates)
+	return rates, nil
+}
+
+// RangePage returns a page of rates within provided time range, where page.After
+// is set, only rates after it in direction of page.Order are returned.
+func (m *MemoryDB) RangePage(ctx context.Context, coin string, fiat string, from time.Time, to time.Time, page btclists.Page) ([]btclists.Rate, error) {
+	var rates = m.between(coin, fiat, from, to)
+	if page.Order != btclists.Ascending {
+		reverseRates(rates)
+	}
+
+	var paged []btclists.Rate
+	for _, rate := range rates {
+		if !page.After.IsZero() {
+			if page.Order == btclists.Ascending && !rate.Date.After(page.After) {
+				continue
+			}
+			if page.Order != btclists.Ascending && !rate.Date.Before(page.After) {
+				continue
+			}
+		}
+
+		paged = append(paged, rate)
+		if page.Limit > 0 && len(paged) == page.Limit {
+			break
+		}
+	}
+	return paged, nil
+}
+
+// AverageForRange returns the average of rates within provided time range.
+//
+// Returns btclists.ErrRateNotFound if there are no rates within time range.
+func (m *MemoryDB) AverageForRange(ctx context.Context, coin string, fiat string, from time.Time, to time.Time) (decimal.Decimal, error) {
+	var rates = m.between(coin, fiat, from, to)
+	if len(rates) == 0 {
+		return decimal.Decimal{}, btclists.ErrRateNotFound
+	}
+	return meanOf(rates), nil
+}
+
+// StatsForRange returns summary statistics of rates within provided time range, where
+// the median and percentiles are interpolated between rates as done by PostgresDB.
+//
+// Returns btclists.ErrRateNotFound if there are no rates within time range.
+func (m *MemoryDB) StatsForRange(ctx context.Context, coin string, fiat string, from time.Time, to time.Time, percentiles []float64) (btclists.Stats, error) {
+	var stats = btclists.Stats{Coin: coin, Fiat: fiat, From: from, To: to}
+
+	var rates = m.between(coin, fiat, from, to)
+	if len(rates) == 0 {
+		return stats, btclists.ErrRateNotFound
+	}
+
+	var values = make([]decimal.Decimal, len(rates))
+	for index, rate := range rates {
+		values[index] = rate.Rate
+	}
+	sort.Slice(values, func(i, j int) bool {
+		return values[i].LessThan(values[j])
+	})
+
+	stats.Count = len(rates)
+	stats.Min = values[0]
+	stats.Max = values[len(values)-1]
+	stats.First = rates[0].Rate
+	stats.Last = rates[len(rates)-1].Rate
+	stats.Mean = meanOf(rates)
+	stats.Median = percentileOf(values, 50)
+
+	// sample standard deviation, there is no deviation for a single rate.
+	if len(rates) > 1 {
+		stats.StdDev = stdDevOf(values)
+	}
+
+	for _, percent := range percentiles {
+		stats.Percentiles = append(stats.Percentiles, btclists.Percentile{
+			Percent: percent,
+			Value:   percentileOf(values, percent),
+		})
+	}
+
+	return stats, nil
+}
+
+// ScanRange implements RangeScanner, see ScanRates.
+func (m *MemoryDB) ScanRange(ctx context.Context, coin string, fiat string, from time.Time, to time.Time, cadence time.Duration) (RangeScan, error) {
+	return ScanRates(m.between(coin, fiat, from, to), from, to, cadence), nil
+}
+
+func (m *MemoryDB) CountForRange(ctx context.Context, coin string, fiat string, from time.Time, to time.Time) (int, error) {
+	return len(m.between(coin, fiat, from, to)), nil
+}
+
+// between returns a copy of rates of pair within provided time range (inclusive),
+// ordered by date from oldest to latest.
+func (m *MemoryDB) between(coin string, fiat string, from time.Time, to time.Time) []btclists.Rate {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var stored = m.rates[btclists.Pair{Coin: coin, Fiat: fiat}]
+	var start = sort.Search(len(stored), func(i int) bool {
+		return !stored[i].Date.Before(from)
+	})
+	var end = sort.Search(len(stored), func(i int) bool {
+		return stored[i].Date.After(to)
+	})
+
+	if start >= end {
+		return nil
+	}
+	return append([]btclists.Rate(nil), stored[start:end]...)
+}
+
+func reverseRates(rates []btclists.Rate) {
+	for i, j := 0, len(rates)-1; i < j; i, j = i+1, j-1 {
+		rates[i], rates[j] = rates[j], rates[i]
+	}
+}
+
+func meanOf(rates []btclists.Rate) decimal.Decimal {
+	var sum decimal.Decimal
+	for _, rate := range rates {
+		sum = sum.Add(rate.Rate)
+	}
+	return sum.Div(decimal.NewFromInt(int64(len(rates))))
+}
+
+// percentileOf returns the value below which percent (0 to 100) of sorted values
+// fall, interpolating between the closest values.
+//
+// Interpolation is done in decimal as PostgresDB does in numeric, so both agree.
+func percentileOf(sorted []decimal.Decimal, percent float64) decimal.Decimal {
+	var position = percentFraction(percent).Mul(decimal.NewFromInt(int64(len(sorted) - 1)))
+	var lower = position.Floor()
+
+	var index = int(lower.IntPart())
+	if index >= len(sorted)-1 {
+		return sorted[len(sorted)-1]
+	}
+
+	var fraction = position.Sub(lower)
+	return sorted[index].Add(sorted[index+1].Sub(sorted[index]).Mul(fraction))
+}
+
+// stdDevOf returns the sample standard deviation of values rounded to StdDevPrecision.
+//
+// It is computed in decimal as PostgresDB does in numeric (see stdDevColumn), so both
+// agree: the variance is rounded to varianceScale places before taking it's square root.
+func stdDevOf(values []decimal.Decimal) decimal.Decimal {
+	var count = decimal.NewFromInt(int64(len(values)))
+
+	var sum, squares decimal.Decimal
+	for _, value := range values {
+		sum = sum.Add(value)
+		squares = squares.Add(value.Mul(value))
+	}
+
+	var deviations = count.Mul(squares).Sub(sum.Mul(sum))
+	var variance = deviations.DivRound(count.Mul(count.Sub(decimal.NewFromInt(1))), varianceScale)
+	return sqrtOf(variance, varianceScale).Round(StdDevPrecision)
+}
+
+// sqrtOf returns the square root of value rounded to places, found by Newton's method
+// starting from the float64 square root.
+func sqrtOf(value decimal.Decimal, places int32) decimal.Decimal {
+	if value.Sign() <= 0 {
+		return decimal.Zero
+	}
+
+	var float, _ = value.Float64()
+	var root = decimal.NewFromFloat(math.Sqrt(float))
+	if root.Sign() <= 0 {
+		root = value
+	}
+
+	var two = decimal.NewFromInt(2)
+	var precision = places + 10
+	for iteration := 0; iteration < 100; iteration++ {
+		var next = root.Add(value.DivRound(root, precision)).DivRound(two, precision)
+		if next.Equal(root) {
+			break
+		}
+		root = next
+	}
+	return root.Round(places)
+}
+
+// percentFraction returns percent (0 to 100) as an exact fraction (0 to 1).
+func percentFraction(percent float64) decimal.Decimal {
+	return decimal.NewFromFloat(percent).Div(decimal.NewFromInt(100))
+}
@@ -0,0 +1,233 @@
+package pkg_test
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+
+	"github.com/influx6/btclists"
+	"github.com/influx6/btclists/pkg"
+)
+
+func TestMemoryDB_RatesDB(t *testing.T) {
+	testRatesDB(t, pkg.NewMemoryDB())
+}
+
+func TestMemoryDB_Rates(t *testing.T) {
+	var db = pkg.NewMemoryDB()
+	var ctx = context.Background()
+	var start = time.Date(2020, 4, 8, 14, 0, 0, 0, time.UTC)
+
+	t.Logf("Should return sql.ErrNoRows for pairs without rates")
+	{
+		var _, err = db.Latest(ctx, COIN, FIAT)
+		require.Equal(t, sql.ErrNoRows, err)
+
+		_, err = db.Oldest(ctx, COIN, FIAT)
+		require.Equal(t, sql.ErrNoRows, err)
+
+		_, err = db.At(ctx, COIN, FIAT, start)
+		require.Equal(t, sql.ErrNoRows, err)
+
+		_, err = db.AverageForRange(ctx, COIN, FIAT, start, start.Add(time.Hour))
+		require.Equal(t, btclists.ErrRateNotFound, err)
+
+		var rates, rangeErr = db.Range(ctx, COIN, FIAT, start, start.Add(time.Hour))
+		require.NoError(t, rangeErr)
+		require.Len(t, rates, 0)
+	}
+
+	var newYork = time.FixedZone("EDT", -4*60*60)
+	require.NoError(t, db.AddBatch(ctx, []btclists.Rate{
+		{Date: start.Add(2 * time.Minute), Coin: COIN, Fiat: FIAT, Rate: decimal.NewFromFloat(7202)},
+		{Date: start.In(newYork).Add(500 * time.Millisecond), Coin: COIN, Fiat: FIAT, Rate: decimal.NewFromFloat(7200)},
+		{Date: start.Add(time.Minute), Coin: COIN, Fiat: FIAT, Rate: decimal.NewFromFloat(7201)},
+		{Date: start, Coin: "ETH", Fiat: FIAT, Rate: decimal.NewFromFloat(170)},
+	}))
+
+	t.Logf("Should keep a single rate per pair and date at second precision")
+	{
+		require.NoError(t, db.Add(ctx, btclists.Rate{Date: start, Coin: COIN, Fiat: FIAT, Rate: decimal.NewFromFloat(1)}))
+
+		var oldest, err = db.Oldest(ctx, COIN, FIAT)
+		require.NoError(t, err)
+		require.Equal(t, start, oldest.Date)
+		require.Equal(t, time.UTC, oldest.Date.Location())
+		require.Equal(t, "7200", oldest.Rate.String())
+
+		require.NoError(t, db.Add(btclists.WithUpsert(ctx), btclists.Rate{
+			Date: start, Coin: COIN, Fiat: FIAT, Rate: decimal.NewFromFloat(7200.5), Resolution: btclists.Resolution1Min,
+			Sources: []string{"coinapi", "kraken"},
+		}))
+
+		var upserted, upsertErr = db.Oldest(ctx, COIN, FIAT)
+		require.NoError(t, upsertErr)
+		require.Equal(t, oldest.Id, upserted.Id)
+		require.Equal(t, "7200.5", upserted.Rate.String())
+		require.Equal(t, btclists.Resolution1Min, upserted.Resolution)
+		require.Equal(t, []string{"coinapi", "kraken"}, upserted.Sources)
+
+		var count, countErr = db.CountForRange(ctx, COIN, FIAT, start, start.Add(time.Hour))
+		require.NoError(t, countErr)
+		require.Equal(t, 3, count)
+	}
+
+	t.Logf("Should retrieve rates as PostgresDB does")
+	{
+		var latest, err = db.Latest(ctx, COIN, FIAT)
+		require.NoError(t, err)
+		require.Equal(t, start.Add(2*time.Minute), latest.Date)
+
+		var at, atErr = db.At(ctx, COIN, FIAT, start.Add(30*time.Second))
+		require.NoError(t, atErr)
+		require.Equal(t, start.Add(time.Minute), at.Date)
+
+		_, atErr = db.At(ctx, COIN, FIAT, start.Add(2*time.Minute+time.Second))
+		require.Equal(t, sql.ErrNoRows, atErr)
+
+		var rates, rangeErr = db.Range(ctx, COIN, FIAT, start, start.Add(time.Minute))
+		require.NoError(t, rangeErr)
+		require.Len(t, rates, 2)
+		require.Equal(t, start.Add(time.Minute), rates[0].Date)
+		require.Equal(t, start, rates[1].Date)
+
+		var page, pageErr = db.RangePage(ctx, COIN, FIAT, start, start.Add(time.Hour), btclists.Page{
+			Limit: 1,
+			After: start,
+			Order: btclists.Ascending,
+		})
+		require.NoError(t, pageErr)
+		require.Len(t, page, 1)
+		require.Equal(t, start.Add(time.Minute), page[0].Date)
+
+		page, pageErr = db.RangePage(ctx, COIN, FIAT, start, start.Add(time.Hour), btclists.Page{Limit: 2})
+		require.NoError(t, pageErr)
+		require.Len(t, page, 2)
+		require.Equal(t, start.Add(2*time.Minute), page[0].Date)
+
+		var average, avgErr = db.AverageForRange(ctx, COIN, FIAT, start, start.Add(time.Hour))
+		require.NoError(t, avgErr)
+		require.Equal(t, "7201.1666666666666667", average.String())
+	}
+
+	t.Logf("Should return summary statistics of rates")
+	{
+		var stats, err = db.StatsForRange(ctx, COIN, FIAT, start, start.Add(time.Hour), []float64{25, 100})
+		require.NoError(t, err)
+		require.Equal(t, 3, stats.Count)
+		require.Equal(t, "7200.5", stats.Min.String())
+		require.Equal(t, "7202", stats.Max.String())
+		require.Equal(t, "7200.5", stats.First.String())
+		require.Equal(t, "7202", stats.Last.String())
+		require.Equal(t, "7201", stats.Median.String())
+		require.Equal(t, "0.76376261582597333443", stats.StdDev.String())
+		require.Equal(t, "7200.75", stats.Percentiles[0].Value.String())
+		require.Equal(t, "7202", stats.Percentiles[1].Value.String())
+
+		_, err = db.StatsForRange(ctx, COIN, "EUR", start, start.Add(time.Hour), nil)
+		require.Equal(t, btclists.ErrRateNotFound, err)
+	}
+}
+
+func TestMemoryDB_CandlesAndBudgets(t *testing.T) {
+	var db = pkg.NewMemoryDB()
+	var ctx = context.Background()
+	var start = time.Date(2020, 4, 8, 14, 0, 0, 0, time.UTC)
+
+	var candle = func(offset time.Duration, period time.Duration, resolution btclists.Resolution) btclists.Candle {
+		return btclists.Candle{
+			Coin:       COIN,
+			Fiat:       FIAT,
+			Resolution: resolution,
+			Start:      start.Add(offset),
+			End:        start.Add(offset + period),
+			Close:      decimal.NewFromFloat(7200),
+		}
+	}
+
+	require.NoError(t, db.AddCandles(ctx, []btclists.Candle{
+		candle(time.Minute, time.Minute, btclists.Resolution1Min),
+		candle(0, time.Hour, btclists.Resolution1Hour),
+		candle(0, time.Minute, btclists.Resolution1Min),
+		candle(0, time.Minute, btclists.Resolution1Min),
+	}))
+
+	var candles, err = db.Candles(ctx, COIN, FIAT, start, start.Add(time.Hour))
+	require.NoError(t, err)
+	require.Len(t, candles, 3)
+	require.Equal(t, start, candles[0].Start)
+	require.Equal(t, start.Add(time.Minute), candles[2].Start)
+
+	candles, err = db.Candles(btclists.WithResolution(ctx, btclists.Resolution1Hour), COIN, FIAT, start, start.Add(time.Hour))
+	require.NoError(t, err)
+	require.Len(t, candles, 1)
+	require.Equal(t, start.Add(time.Hour), candles[0].End)
+
+	var _, budgetErr = db.Budget(ctx, pkg.CoinAPIProvider)
+	require.Equal(t, sql.ErrNoRows, budgetErr)
+
+	require.NoError(t, db.UpdateBudget(ctx, btclists.Budget{Provider: pkg.CoinAPIProvider, Remaining: 80, UpdatedAt: start.Add(time.Minute)}))
+	require.NoError(t, db.UpdateBudget(ctx, btclists.Budget{Provider: pkg.CoinAPIProvider, Remaining: 90, UpdatedAt: start}))
+
+	var budget, getErr = db.Budget(ctx, pkg.CoinAPIProvider)
+	require.NoError(t, getErr)
+	require.Equal(t, int64(80), budget.Remaining)
+}
+
+func TestMemoryDB_Concurrency(t *testing.T) {
+	var db = pkg.NewMemoryDB()
+	var ctx = context.Background()
+	var start = time.Date(2020, 4, 8, 14, 0, 0, 0, time.UTC)
+
+	var wg sync.WaitGroup
+	for worker := 0; worker < 8; worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := 0; index < 100; index++ {
+				var rate = btclists.Rate{
+					Date: start.Add(time.Duration(index) * time.Second),
+					Coin: COIN,
+					Fiat: FIAT,
+					Rate: decimal.NewFromInt(int64(index)),
+				}
+				require.NoError(t, db.Add(ctx, rate))
+
+				var _, err = db.Range(ctx, COIN, FIAT, start, rate.Date)
+				require.NoError(t, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	var count, err = db.CountForRange(ctx, COIN, FIAT, start, start.Add(time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, 100, count)
+}
+
+func TestNewCoinRatingService_MemoryDB(t *testing.T) {
+	var db = pkg.NewMemoryDB()
+	var market = new(MockCoinMarket)
+
+	var calls int
+	market.RateFunc = func(ctx context.Context, coin string, fiat string, at time.Time) (btclists.Rate, error) {
+		calls++
+		return someRate, nil
+	}
+
+	var service = pkg.NewCoinRatingService(context.Background(), db, market)
+
+	var first, err = service.Latest(context.Background(), COIN, FIAT)
+	require.NoError(t, err)
+	require.True(t, someRate.Rate.Equal(first.Rate))
+
+	var second, secondErr = service.Latest(context.Background(), COIN, FIAT)
+	require.NoError(t, secondErr)
+	require.True(t, someRate.Date.Truncate(time.Second).Equal(second.Date))
+	require.Equal(t, 1, calls)
+}
@@ -0,0 +1,233 @@
+package pkg
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"log"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// MigrationsTable is the table applied migrations are recorded within.
+	MigrationsTable = "schema_migrations"
+
+	// migrationsLock is the key of the advisory lock held while migrating, keeping
+	// instances booting together from applying the same migrations.
+	migrationsLock = 7263541
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// Migration is a numbered schema change, with the sql applying it (Up) and reverting it (Down).
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Migrations returns the migrations embedded from the migrations directory, ordered
+// by version. Migration files are named {version}_{name}.up.sql and {version}_{name}.down.sql
+// (e.g 0001_create_ratings.up.sql).
+func Migrations() ([]Migration, error) {
+	var files, err = migrationFiles.ReadDir("migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	var byVersion = map[int]*Migration{}
+	for _, file := range files {
+		var name = file.Name()
+
+		var direction string
+		switch {
+		case strings.HasSuffix(name, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(name, ".down.sql"):
+			direction = "down"
+		default:
+			return nil, fmt.Errorf("migration %q must end with .up.sql or .down.sql", name)
+		}
+
+		var parts = strings.SplitN(strings.TrimSuffix(name, "."+direction+".sql"), "_", 2)
+		var version, versionErr = strconv.Atoi(parts[0])
+		if versionErr != nil || len(parts) != 2 {
+			return nil, fmt.Errorf("migration %q must be named {version}_{name}.%s.sql", name, direction)
+		}
+
+		var content, readErr = migrationFiles.ReadFile(path.Join("migrations", name))
+		if readErr != nil {
+			return nil, readErr
+		}
+
+		var migration, ok = byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: parts[1]}
+			byVersion[version] = migration
+		}
+
+		if direction == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	var migrations = make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s must have both an up and down file", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// Migrator applies and reverts Migrations on a database, recording applied
+// migrations within MigrationsTable.
+//
+// Each migration runs within a transaction holding an advisory lock, so instances
+// migrating the same database at once apply each migration only once.
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+func NewMigrator(db *sql.DB) (*Migrator, error) {
+	var migrations, err = Migrations()
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// Version returns the version of the latest applied migration, 0 if none.
+func (m *Migrator) Version(ctx context.Context) (int, error) {
+	if err := m.createTable(ctx); err != nil {
+		return 0, err
+	}
+
+	var version int
+	var row = m.db.QueryRowContext(ctx, fmt.Sprintf("SELECT COALESCE(MAX(version), 0) FROM %s", MigrationsTable))
+	if err := row.Scan(&version); err != nil {
+		log.Printf("[BTC Listings] | [ERROR] | [MIGRATE] | Failed to retrieve schema version | %s\n", err)
+		return 0, err
+	}
+	return version, nil
+}
+
+// Up applies all migrations not yet applied, returning those applied.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	if err := m.createTable(ctx); err != nil {
+		return nil, err
+	}
+
+	var applied []Migration
+	for _, migration := range m.migrations {
+		var ran, err = m.run(ctx, migration, true)
+		if err != nil {
+			return applied, err
+		}
+		if ran {
+			applied = append(applied, migration)
+		}
+	}
+	return applied, nil
+}
+
+// Down reverts up to steps of the latest applied migrations, returning those reverted.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	if err := m.createTable(ctx); err != nil {
+		return nil, err
+	}
+
+	var reverted []Migration
+	for index := len(m.migrations) - 1; index >= 0 && len(reverted) < steps; index-- {
+		var ran, err = m.run(ctx, m.migrations[index], false)
+		if err != nil {
+			return reverted, err
+		}
+		if ran {
+			reverted = append(reverted, m.migrations[index])
+		}
+	}
+	return reverted, nil
+}
+
+// run applies (up) or reverts migration within a transaction, returning false
+// where migration was already applied or reverted.
+func (m *Migrator) run(ctx context.Context, migration Migration, up bool) (bool, error) {
+	var tx, err = m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock($1)", migrationsLock); err != nil {
+		return false, err
+	}
+
+	var count int
+	var row = tx.QueryRowContext(ctx, fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE version = $1", MigrationsTable), migration.Version)
+	if err := row.Scan(&count); err != nil {
+		return false, err
+	}
+
+	if (count == 1) == up {
+		return false, nil
+	}
+
+	var statement = migration.Down
+	if up {
+		statement = migration.Up
+	}
+
+	if _, err := tx.ExecContext(ctx, statement); err != nil {
+		log.Printf("[BTC Listings] | [ERROR] | [MIGRATE] | Failed to run migration | %d_%s | %s\n", migration.Version, migration.Name, err)
+		return false, fmt.Errorf("migration %d_%s failed: %s", migration.Version, migration.Name, err)
+	}
+
+	var recordErr error
+	if up {
+		_, recordErr = tx.ExecContext(ctx, fmt.Sprintf("INSERT INTO %s (version, name) VALUES ($1, $2)", MigrationsTable), migration.Version, migration.Name)
+	} else {
+		_, recordErr = tx.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE version = $1", MigrationsTable), migration.Version)
+	}
+	if recordErr != nil {
+		return false, recordErr
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+
+	log.Printf("[BTC Listings] | [INFO] | [MIGRATE] | Ran migration | %d_%s | up: %t\n", migration.Version, migration.Name, up)
+	return true, nil
+}
+
+func (m *Migrator) createTable(ctx context.Context) error {
+	var _, err = m.db.ExecContext(ctx, fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+			version INTEGER PRIMARY KEY,
+			name VARCHAR(255) NOT NULL,
+			applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		)
+	`, MigrationsTable))
+	if err != nil {
+		log.Printf("[BTC Listings] | [ERROR] | [MIGRATE] | Failed to create migrations table | %s\n", err)
+	}
+	return err
+}
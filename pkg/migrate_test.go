@@ -0,0 +1,150 @@
+package pkg_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/stdlib"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+
+	"github.com/influx6/btclists"
+	"github.com/influx6/btclists/pkg"
+)
+
+// baselineSchema is the schema created by the former migrations/setup_db.sh,
+// which databases predating migrations hold.
+const baselineSchema = `
+	CREATE TABLE IF NOT EXISTS ratings (
+		ID SERIAL NOT NULL,
+		rate NUMERIC NOT NULL,
+		coin VARCHAR(7) NOT NULL,
+		fiat VARCHAR(7) NOT NULL,
+		date TIMESTAMP UNIQUE NOT NULL
+	);
+
+	ALTER TABLE ratings ADD PRIMARY KEY (id);
+	ALTER TABLE ratings ADD CONSTRAINT fait_coin_date_unique unique (fiat, coin, date);
+`
+
+func TestMigrations(t *testing.T) {
+	var migrations, err = pkg.Migrations()
+	require.NoError(t, err)
+	require.NotEmpty(t, migrations)
+
+	for index, migration := range migrations {
+		require.Equal(t, index+1, migration.Version)
+		require.NotEmpty(t, migration.Name)
+		require.NotEmpty(t, migration.Up)
+		require.NotEmpty(t, migration.Down)
+	}
+}
+
+func TestRatingsDB_Migrator(t *testing.T) {
+	var db, err = pkg.NewPostgresDBFromURL(dbURL, tableName)
+	require.NoError(t, err)
+	defer db.Close()
+
+	var migrations, migrationsErr = pkg.Migrations()
+	require.NoError(t, migrationsErr)
+
+	var migrator, migratorErr = pkg.NewMigrator(db.DB())
+	require.NoError(t, migratorErr)
+
+	var ctx = context.Background()
+
+	t.Logf("Should have applied all migrations")
+	{
+		var _, upErr = migrator.Up(ctx)
+		require.NoError(t, upErr)
+
+		var version, versionErr = migrator.Version(ctx)
+		require.NoError(t, versionErr)
+		require.Equal(t, len(migrations), version)
+	}
+
+	t.Logf("Should revert and re-apply latest migration")
+	{
+		var reverted, downErr = migrator.Down(ctx, 1)
+		require.NoError(t, downErr)
+		require.Len(t, reverted, 1)
+		require.Equal(t, len(migrations), reverted[0].Version)
+
+		var version, versionErr = migrator.Version(ctx)
+		require.NoError(t, versionErr)
+		require.Equal(t, len(migrations)-1, version)
+
+		var applied, upErr = migrator.Up(ctx)
+		require.NoError(t, upErr)
+		require.Len(t, applied, 1)
+
+		applied, upErr = migrator.Up(ctx)
+		require.NoError(t, upErr)
+		require.Empty(t, applied)
+	}
+}
+
+func TestRatingsDB_Migrator_Baseline(t *testing.T) {
+	var admin, err = pkg.NewPostgresDBFromURL(dbURL, tableName)
+	require.NoError(t, err)
+	defer admin.Close()
+
+	var ctx = context.Background()
+
+	// the baseline database lives in it's own schema, keeping tables of other tests intact.
+	_, err = admin.DB().ExecContext(ctx, "DROP SCHEMA IF EXISTS baseline CASCADE; CREATE SCHEMA baseline")
+	require.NoError(t, err)
+
+	defer func() {
+		var _, dropErr = admin.DB().ExecContext(ctx, "DROP SCHEMA IF EXISTS baseline CASCADE")
+		require.NoError(t, dropErr)
+	}()
+
+	var config, configErr = pgx.ParseConfig(dbURL)
+	require.NoError(t, configErr)
+	config.RuntimeParams["search_path"] = "baseline"
+
+	var sqlDB = stdlib.OpenDB(*config)
+	defer sqlDB.Close()
+
+	_, err = sqlDB.ExecContext(ctx, baselineSchema)
+	require.NoError(t, err)
+
+	_, err = sqlDB.ExecContext(ctx, "INSERT INTO ratings (rate, coin, fiat, date) VALUES (7200.5, 'BTC', 'USD', '2020-04-08 14:00:00')")
+	require.NoError(t, err)
+
+	t.Logf("Should migrate a database created by setup_db.sh")
+	{
+		var migrator, migratorErr = pkg.NewMigrator(sqlDB)
+		require.NoError(t, migratorErr)
+
+		var _, upErr = migrator.Up(ctx)
+		require.NoError(t, upErr)
+	}
+
+	t.Logf("Should keep existing rates and store new ones")
+	{
+		var db, dbErr = pkg.NewPostgresDB(sqlDB, tableName)
+		require.NoError(t, dbErr)
+
+		var start = time.Date(2020, 4, 8, 14, 0, 0, 0, time.UTC)
+
+		var oldest, oldestErr = db.Oldest(ctx, COIN, FIAT)
+		require.NoError(t, oldestErr)
+		require.Equal(t, start, oldest.Date)
+		require.Equal(t, "7200.5", oldest.Rate.String())
+		require.Equal(t, btclists.Spot, oldest.Resolution)
+
+		require.NoError(t, db.AddBatch(ctx, []btclists.Rate{
+			{Date: start.Add(time.Minute), Coin: COIN, Fiat: FIAT, Rate: decimal.NewFromFloat(7201), Resolution: btclists.Resolution1Min},
+			{Date: start.Add(time.Minute), Coin: "ETH", Fiat: FIAT, Rate: decimal.NewFromFloat(170)},
+		}))
+
+		var rates, rangeErr = db.Range(ctx, COIN, FIAT, start, start.Add(time.Minute))
+		require.NoError(t, rangeErr)
+		require.Len(t, rates, 2)
+		require.Equal(t, btclists.Resolution1Min, rates[0].Resolution)
+	}
+}
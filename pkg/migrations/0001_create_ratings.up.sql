@@ -0,0 +1,14 @@
+-- Create ratings table for rates of pairs.
+CREATE TABLE IF NOT EXISTS ratings (
+    ID SERIAL PRIMARY KEY,
+    rate NUMERIC NOT NULL,
+    coin VARCHAR(7) NOT NULL,
+    fiat VARCHAR(7) NOT NULL,
+    resolution VARCHAR(6) NOT NULL DEFAULT '',
+    date TIMESTAMP UNIQUE NOT NULL,
+    CONSTRAINT fait_coin_date_unique UNIQUE (fiat, coin, date)
+);
+
+-- Adopt ratings tables created by the former migrations/setup_db.sh, which
+-- predate the resolution column. Existing rates are spot rates.
+ALTER TABLE ratings ADD COLUMN IF NOT EXISTS resolution VARCHAR(6) NOT NULL DEFAULT '';
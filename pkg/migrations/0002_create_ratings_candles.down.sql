@@ -0,0 +1 @@
+DROP TABLE IF EXISTS ratings_candles;
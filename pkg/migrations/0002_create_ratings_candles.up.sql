@@ -0,0 +1,16 @@
+-- Create candles table for full OHLCV candles of a pair.
+CREATE TABLE IF NOT EXISTS ratings_candles (
+    ID SERIAL PRIMARY KEY,
+    coin VARCHAR(7) NOT NULL,
+    fiat VARCHAR(7) NOT NULL,
+    resolution VARCHAR(6) NOT NULL DEFAULT '',
+    time_start TIMESTAMP NOT NULL,
+    time_end TIMESTAMP NOT NULL,
+    price_open NUMERIC NOT NULL,
+    price_high NUMERIC NOT NULL,
+    price_low NUMERIC NOT NULL,
+    price_close NUMERIC NOT NULL,
+    volume_traded NUMERIC NOT NULL,
+    trades_count BIGINT NOT NULL DEFAULT 0,
+    CONSTRAINT ratings_candles_period_unique UNIQUE (coin, fiat, time_start, time_end)
+);
@@ -0,0 +1,8 @@
+-- Create budgets table for request credits left with market data providers.
+CREATE TABLE IF NOT EXISTS provider_budgets (
+    provider VARCHAR(32) PRIMARY KEY,
+    credit_limit BIGINT NOT NULL DEFAULT 0,
+    remaining BIGINT NOT NULL,
+    reset_at TIMESTAMP NULL,
+    updated_at TIMESTAMP NOT NULL
+);
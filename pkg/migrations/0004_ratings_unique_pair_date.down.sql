@@ -0,0 +1,5 @@
+-- Restoring uniqueness per date fails where rates of different pairs share a
+-- date, those must be removed first as this migration will not drop rates.
+ALTER TABLE ratings DROP CONSTRAINT IF EXISTS ratings_coin_fiat_date_unique;
+ALTER TABLE ratings ADD CONSTRAINT ratings_date_key UNIQUE (date);
+ALTER TABLE ratings ADD CONSTRAINT fait_coin_date_unique UNIQUE (fiat, coin, date);
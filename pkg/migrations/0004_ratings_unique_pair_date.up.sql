@@ -0,0 +1,6 @@
+-- Rates are unique per pair and date rather than per date alone, so rates of
+-- different pairs at the same date can coexist. Existing rows are unique per
+-- date, hence also per pair and date, and are kept as is.
+ALTER TABLE ratings DROP CONSTRAINT IF EXISTS ratings_date_key;
+ALTER TABLE ratings DROP CONSTRAINT IF EXISTS fait_coin_date_unique;
+ALTER TABLE ratings ADD CONSTRAINT ratings_coin_fiat_date_unique UNIQUE (coin, fiat, date);
@@ -0,0 +1,9 @@
+ALTER TABLE ratings ALTER COLUMN date TYPE TIMESTAMP USING date AT TIME ZONE 'UTC';
+
+ALTER TABLE ratings_candles
+    ALTER COLUMN time_start TYPE TIMESTAMP USING time_start AT TIME ZONE 'UTC',
+    ALTER COLUMN time_end TYPE TIMESTAMP USING time_end AT TIME ZONE 'UTC';
+
+ALTER TABLE provider_budgets
+    ALTER COLUMN reset_at TYPE TIMESTAMP USING reset_at AT TIME ZONE 'UTC',
+    ALTER COLUMN updated_at TYPE TIMESTAMP USING updated_at AT TIME ZONE 'UTC';
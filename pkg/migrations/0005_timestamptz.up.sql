@@ -0,0 +1,11 @@
+-- Store dates as timestamptz, existing dates were written in UTC so are
+-- converted as such, regardless of the session time zone.
+ALTER TABLE ratings ALTER COLUMN date TYPE TIMESTAMPTZ USING date AT TIME ZONE 'UTC';
+
+ALTER TABLE ratings_candles
+    ALTER COLUMN time_start TYPE TIMESTAMPTZ USING time_start AT TIME ZONE 'UTC',
+    ALTER COLUMN time_end TYPE TIMESTAMPTZ USING time_end AT TIME ZONE 'UTC';
+
+ALTER TABLE provider_budgets
+    ALTER COLUMN reset_at TYPE TIMESTAMPTZ USING reset_at AT TIME ZONE 'UTC',
+    ALTER COLUMN updated_at TYPE TIMESTAMPTZ USING updated_at AT TIME ZONE 'UTC';
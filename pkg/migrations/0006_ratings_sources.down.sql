@@ -0,0 +1 @@
+ALTER TABLE ratings DROP COLUMN IF EXISTS sources;
@@ -0,0 +1,4 @@
+-- Record the providers which agreed on a consensus rate, as a comma separated
+-- list of provider names. Existing rates were not derived from several
+-- providers, so are left without sources.
+ALTER TABLE ratings ADD COLUMN IF NOT EXISTS sources TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,116 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/influx6/btclists"
+)
+
+var (
+	ErrUnsupportedPair = errors.New("crypto-currency and fiat-currency pair is not supported")
+)
+
+// Pairs defines an allow-list of supported crypto-currency and fiat-currency pairs.
+//
+// It exists so requests for unknown pairs are rejected early, instead of
+// burning through precious API credits on pairs we never intend to serve.
+type Pairs map[btclists.Pair]struct{}
+
+// NewPairs returns a new Pairs allow-list containing provided pairs.
+func NewPairs(pairs ...btclists.Pair) Pairs {
+	var list = Pairs{}
+	for _, pair := range pairs {
+		list.Add(pair.Coin, pair.Fiat)
+	}
+	return list
+}
+
+// ParsePairs parses a comma separated list of pairs in the format
+// {coin}/{fiat} (e.g "BTC/USD,ETH/EUR") into a Pairs allow-list.
+func ParsePairs(list string) (Pairs, error) {
+	var pairs = Pairs{}
+	for _, item := range strings.Split(list, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		var parts = strings.Split(item, "/")
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" || strings.TrimSpace(parts[1]) == "" {
+			return nil, fmt.Errorf("invalid pair %q, expected format {coin}/{fiat}", item)
+		}
+
+		pairs.Add(parts[0], parts[1])
+	}
+
+	if len(pairs) == 0 {
+		return nil, errors.New("no pairs provided")
+	}
+	return pairs, nil
+}
+
+// ParsePairResolutions parses a comma separated list of pairs and their candle resolution
+// in the format {coin}/{fiat}={resolution} (e.g "BTC/USD=1MIN,ETH/EUR=1HRS").
+func ParsePairResolutions(list string) (map[btclists.Pair]btclists.Resolution, error) {
+	var resolutions = map[btclists.Pair]btclists.Resolution{}
+	for _, item := range strings.Split(list, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		var parts = strings.Split(item, "=")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid pair resolution %q, expected format {coin}/{fiat}={resolution}", item)
+		}
+
+		var pair, pairErr = ParsePairs(parts[0])
+		if pairErr != nil {
+			return nil, pairErr
+		}
+
+		var resolution, resErr = btclists.ParseResolution(parts[1])
+		if resErr != nil {
+			return nil, fmt.Errorf("invalid pair resolution %q: %s", item, resErr)
+		}
+
+		for key := range pair {
+			resolutions[key] = resolution
+		}
+	}
+	return resolutions, nil
+}
+
+// Add adds giving coin and fiat pair into allow-list.
+func (p Pairs) Add(coin string, fiat string) {
+	p[normalizePair(coin, fiat)] = struct{}{}
+}
+
+// Has returns true/false if giving coin and fiat pair is supported.
+func (p Pairs) Has(coin string, fiat string) bool {
+	var _, ok = p[normalizePair(coin, fiat)]
+	return ok
+}
+
+// List returns all pairs in allow-list sorted by their string form.
+func (p Pairs) List() []btclists.Pair {
+	var list = make([]btclists.Pair, 0, len(p))
+	for pair := range p {
+		list = append(list, pair)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].String() < list[j].String()
+	})
+	return list
+}
+
+func normalizePair(coin string, fiat string) btclists.Pair {
+	return btclists.Pair{
+		Coin: strings.ToUpper(strings.TrimSpace(coin)),
+		Fiat: strings.ToUpper(strings.TrimSpace(fiat)),
+	}
+}
@@ -0,0 +1,50 @@
+package pkg_test
+
+import (
+	"testing"
+
+	"github.com/influx6/btclists"
+	"github.com/influx6/btclists/pkg"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePairs(t *testing.T) {
+	var pairs, err = pkg.ParsePairs("BTC/USD, eth/eur,")
+	require.NoError(t, err)
+
+	require.True(t, pairs.Has(COIN, FIAT))
+	require.True(t, pairs.Has("ETH", "EUR"))
+	require.True(t, pairs.Has("btc", "usd"))
+	require.False(t, pairs.Has("BTC", "EUR"))
+
+	require.Equal(t, []btclists.Pair{
+		{Coin: "BTC", Fiat: "USD"},
+		{Coin: "ETH", Fiat: "EUR"},
+	}, pairs.List())
+}
+
+func TestParsePairs_Invalid(t *testing.T) {
+	var _, err = pkg.ParsePairs("BTC-USD")
+	require.Error(t, err)
+
+	_, err = pkg.ParsePairs("BTC/")
+	require.Error(t, err)
+
+	_, err = pkg.ParsePairs("")
+	require.Error(t, err)
+}
+
+func TestParsePairResolutions(t *testing.T) {
+	var resolutions, err = pkg.ParsePairResolutions("BTC/USD=1min, ETH/EUR=1DAY")
+	require.NoError(t, err)
+	require.Equal(t, map[btclists.Pair]btclists.Resolution{
+		{Coin: "BTC", Fiat: "USD"}: btclists.Resolution1Min,
+		{Coin: "ETH", Fiat: "EUR"}: btclists.Resolution1Day,
+	}, resolutions)
+
+	_, err = pkg.ParsePairResolutions("BTC/USD=1WEEK")
+	require.Error(t, err)
+
+	_, err = pkg.ParsePairResolutions("BTC/USD")
+	require.Error(t, err)
+}
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"fmt"
 	"log"
+	"strings"
 	"time"
 
 	"github.com/shopspring/decimal"
@@ -20,12 +21,32 @@ import (
 
 const (
 	acceptableRange = 1 * time.Minute
+
+	// BudgetsTable is the table provider budgets are stored within.
+	BudgetsTable = "provider_budgets"
+)
+
+var (
+	_ btclists.RatesDB          = (*PostgresDB)(nil)
+	_ btclists.BudgetStore      = (*PostgresDB)(nil)
+	_ btclists.PriorRateService = (*PostgresDB)(nil)
+	_ RangeScanner              = (*PostgresDB)(nil)
 )
 
+// PostgresDB implements btclists.RatesDB on top of a PostgreSQL database.
+//
+// Rates are stored within provided table, while candles are stored within
+// a sibling table named {table}_candles (e.g ratings_candles).
+//
+// Times are stored as timestamptz, rate and candle times at second precision, and
+// are always returned in UTC whatever the session time zone.
+//
+// PostgresDB also implements btclists.BudgetStore, storing budgets within BudgetsTable.
 type PostgresDB struct {
-	db    *sql.DB
-	table string
-	sdb   squirrel.StatementBuilderType
+	db           *sql.DB
+	table        string
+	candlesTable string
+	sdb          squirrel.StatementBuilderType
 }
 
 func NewPostgresDB(db *sql.DB, table string) (*PostgresDB, error) {
@@ -34,6 +55,7 @@ func NewPostgresDB(db *sql.DB, table string) (*PostgresDB, error) {
 	tdb.db = db
 	tdb.sdb = sqdb
 	tdb.table = table
+	tdb.candlesTable = fmt.Sprintf("%s_candles", table)
 	return &tdb, nil
 }
 
@@ -63,15 +85,15 @@ func (t *PostgresDB) Add(ctx context.Context, rate btclists.Rate) error {
 	}
 
 	var q = t.sdb.Insert(t.table).
-		Columns("date", "rate", "coin", "fiat").
+		Columns("date", "rate", "coin", "fiat", "resolution", "sources").
 		Values(
-			rate.Date.Format(btclists.DateTimeFormat),
+			storedTime(rate.Date),
 			rating,
 			rate.Coin,
 			rate.Fiat,
-		).Suffix(`
-			ON CONFLICT (date) DO NOTHING
-		`)
+			rate.Resolution,
+			strings.Join(rate.Sources, ","),
+		).Suffix(onRateConflict(ctx))
 	if _, err := q.ExecContext(ctx); err != nil {
 		log.Printf("[BTC Listings] | [ERROR] | [DB] | Failed insert record into db.Value | %s\n", err)
 		return err
@@ -84,8 +106,13 @@ func (t *PostgresDB) AddBatch(ctx context.Context, rates []btclists.Rate) error
 		return nil
 	}
 
+	// an upsert may not update a row twice, so only the last rate of a pair and date is kept.
+	if btclists.UpsertFrom(ctx) {
+		rates = lastRatePerPairAndDate(rates)
+	}
+
 	var q = t.sdb.Insert(t.table).
-		Columns("date", "rate", "coin", "fiat")
+		Columns("date", "rate", "coin", "fiat", "resolution", "sources")
 
 	for _, rate := range rates {
 		var ratings, err = rate.Rate.Value()
@@ -93,25 +120,232 @@ func (t *PostgresDB) AddBatch(ctx context.Context, rates []btclists.Rate) error
 			return err
 		}
 		q = q.Values(
-			rate.Date.Format(btclists.DateTimeFormat),
+			storedTime(rate.Date),
 			ratings,
 			rate.Coin,
 			rate.Fiat,
+			rate.Resolution,
+			strings.Join(rate.Sources, ","),
+		)
+	}
+
+	q = q.Suffix(onRateConflict(ctx))
+	if _, err := q.ExecContext(ctx); err != nil {
+		return err
+	}
+	return nil
+}
+
+// onRateConflict returns the conflict clause of rate inserts, rates already stored for
+// the same pair and date are replaced where ctx is set with btclists.WithUpsert.
+func onRateConflict(ctx context.Context) string {
+	if btclists.UpsertFrom(ctx) {
+		return `
+			ON CONFLICT (coin, fiat, date) DO UPDATE SET
+				rate = EXCLUDED.rate,
+				resolution = EXCLUDED.resolution,
+				sources = EXCLUDED.sources
+		`
+	}
+	return `
+		ON CONFLICT (coin, fiat, date) DO NOTHING
+	`
+}
+
+// lastRatePerPairAndDate returns rates without those followed by a rate of the
+// same pair and date, as stored at second precision.
+func lastRatePerPairAndDate(rates []btclists.Rate) []btclists.Rate {
+	type key struct {
+		pair btclists.Pair
+		date int64
+	}
+
+	var last = make(map[key]int, len(rates))
+	for index, rate := range rates {
+		last[key{pair: btclists.Pair{Coin: rate.Coin, Fiat: rate.Fiat}, date: rate.Date.Unix()}] = index
+	}
+
+	var kept = make([]btclists.Rate, 0, len(last))
+	for index, rate := range rates {
+		if last[key{pair: btclists.Pair{Coin: rate.Coin, Fiat: rate.Fiat}, date: rate.Date.Unix()}] == index {
+			kept = append(kept, rate)
+		}
+	}
+	return kept
+}
+
+// splitSources returns the sources of a rate as stored by PostgresDB, that is as a
+// comma separated list of provider names.
+func splitSources(sources string) []string {
+	if sources == "" {
+		return nil
+	}
+	return strings.Split(sources, ",")
+}
+
+// storedTime returns date as stored by PostgresDB, that is in UTC at second precision.
+func storedTime(date time.Time) time.Time {
+	return date.UTC().Truncate(time.Second)
+}
+
+// AddCandles adds provided candles into db, ignoring candles already existing for
+// the same pair and period.
+func (t *PostgresDB) AddCandles(ctx context.Context, candles []btclists.Candle) error {
+	if len(candles) == 0 {
+		return nil
+	}
+
+	var q = t.sdb.Insert(t.candlesTable).
+		Columns(
+			"coin", "fiat", "resolution", "time_start", "time_end",
+			"price_open", "price_high", "price_low", "price_close",
+			"volume_traded", "trades_count",
+		)
+
+	for _, candle := range candles {
+		q = q.Values(
+			candle.Coin,
+			candle.Fiat,
+			candle.Resolution,
+			storedTime(candle.Start),
+			storedTime(candle.End),
+			candle.Open.String(),
+			candle.High.String(),
+			candle.Low.String(),
+			candle.Close.String(),
+			candle.Volume.String(),
+			candle.Trades,
 		)
 	}
 
 	q = q.Suffix(`
-		ON CONFLICT (date) DO NOTHING
+		ON CONFLICT (coin, fiat, time_start, time_end) DO NOTHING
 	`)
 	if _, err := q.ExecContext(ctx); err != nil {
+		log.Printf("[BTC Listings] | [ERROR] | [DB] | Failed insert candles into db | %s\n", err)
 		return err
 	}
 	return nil
 }
 
+// Candles returns all candles for pair whose period starts within provided time range,
+// ordered by start of period. If a resolution is set on the context (see btclists.WithResolution),
+// only candles of said resolution are returned.
+func (t *PostgresDB) Candles(ctx context.Context, coin string, fiat string, from time.Time, to time.Time) ([]btclists.Candle, error) {
+	var q = t.sdb.
+		Select(
+			"id", "coin", "fiat", "resolution", "time_start", "time_end",
+			"price_open", "price_high", "price_low", "price_close",
+			"volume_traded", "trades_count",
+		).
+		From(t.candlesTable).
+		Where(squirrel.Eq{
+			"coin": coin,
+			"fiat": fiat,
+		}).
+		Where(
+			"time_start BETWEEN ? AND ?",
+			from.UTC(),
+			to.UTC(),
+		).
+		OrderBy("time_start ASC")
+
+	if resolution, ok := btclists.ResolutionFrom(ctx); ok {
+		q = q.Where(squirrel.Eq{"resolution": resolution})
+	}
+
+	var rows, err = q.QueryContext(ctx)
+	if err != nil {
+		log.Printf("[BTC Listings] | [ERROR] | [DB] | Failed query request | %s\n", err)
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var candles []btclists.Candle
+	for rows.Next() {
+		var candle btclists.Candle
+
+		var start, end pgtype.Timestamptz
+		if err := rows.Scan(
+			&candle.Id, &candle.Coin, &candle.Fiat, &candle.Resolution, &start, &end,
+			&candle.Open, &candle.High, &candle.Low, &candle.Close,
+			&candle.Volume, &candle.Trades,
+		); err != nil {
+			log.Printf("[BTC Listings] | [ERROR] | [DB] | Failed scan row into struct | %s\n", err)
+			return nil, err
+		}
+
+		candle.Start = start.Time.UTC()
+		candle.End = end.Time.UTC()
+		candles = append(candles, candle)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("[BTC Listings] | [ERROR] | [DB] | Failed iterating rows | %s\n", err)
+		return nil, err
+	}
+
+	return candles, nil
+}
+
+// UpdateBudget stores provided budget, ignoring it if the stored budget of the
+// provider was updated after it.
+func (t *PostgresDB) UpdateBudget(ctx context.Context, budget btclists.Budget) error {
+	var resetAt interface{}
+	if !budget.ResetAt.IsZero() {
+		resetAt = budget.ResetAt.UTC()
+	}
+
+	var q = t.sdb.Insert(BudgetsTable).
+		Columns("provider", "credit_limit", "remaining", "reset_at", "updated_at").
+		Values(
+			budget.Provider,
+			budget.Limit,
+			budget.Remaining,
+			resetAt,
+			budget.UpdatedAt.UTC(),
+		).Suffix(fmt.Sprintf(`
+			ON CONFLICT (provider) DO UPDATE SET
+				credit_limit = EXCLUDED.credit_limit,
+				remaining = EXCLUDED.remaining,
+				reset_at = EXCLUDED.reset_at,
+				updated_at = EXCLUDED.updated_at
+			WHERE %s.updated_at <= EXCLUDED.updated_at
+		`, BudgetsTable))
+	if _, err := q.ExecContext(ctx); err != nil {
+		log.Printf("[BTC Listings] | [ERROR] | [DB] | Failed to update budget | %s\n", err)
+		return err
+	}
+	return nil
+}
+
+// Budget returns last known budget of provider.
+func (t *PostgresDB) Budget(ctx context.Context, provider string) (btclists.Budget, error) {
+	var q = t.sdb.
+		Select("provider", "credit_limit", "remaining", "reset_at", "updated_at").
+		From(BudgetsTable).
+		Where(squirrel.Eq{"provider": provider})
+
+	var budget btclists.Budget
+	var resetAt, updatedAt pgtype.Timestamptz
+
+	var row = q.QueryRowContext(ctx)
+	if err := row.Scan(&budget.Provider, &budget.Limit, &budget.Remaining, &resetAt, &updatedAt); err != nil {
+		log.Printf("[BTC Listings] | [ERROR] | [DB] | Failed to marshal row | %s\n", err)
+		return budget, err
+	}
+
+	if resetAt.Status == pgtype.Present {
+		budget.ResetAt = resetAt.Time.UTC()
+	}
+	budget.UpdatedAt = updatedAt.Time.UTC()
+	return budget, nil
+}
+
 func (t *PostgresDB) Latest(ctx context.Context, coin string, fiat string) (btclists.Rate, error) {
 	var q = t.sdb.
-		Select("id", "date", "rate", "coin", "fiat").
+		Select("id", "date", "rate", "coin", "fiat", "resolution", "sources").
 		From(t.table).
 		Where(squirrel.Eq{
 			"coin": coin,
@@ -122,21 +356,23 @@ func (t *PostgresDB) Latest(ctx context.Context, coin string, fiat string) (btcl
 
 	var row = q.QueryRowContext(ctx)
 
-	var ts pgtype.Timestamp
+	var ts pgtype.Timestamptz
+	var sources string
 	var rate btclists.Rate
-	if err := row.Scan(&rate.Id, &ts, &rate.Rate, &rate.Coin, &rate.Fiat); err != nil {
+	if err := row.Scan(&rate.Id, &ts, &rate.Rate, &rate.Coin, &rate.Fiat, &rate.Resolution, &sources); err != nil {
 		log.Printf("[BTC Listings] | [ERROR] | [DB] | Failed to marshal row | %s\n", err)
 		return rate, err
 	}
 
 	rate.Date = ts.Time.UTC()
+	rate.Sources = splitSources(sources)
 
 	return rate, nil
 }
 
 func (t *PostgresDB) Oldest(ctx context.Context, coin string, fiat string) (btclists.Rate, error) {
 	var q = t.sdb.
-		Select("id", "date", "rate", "coin", "fiat").
+		Select("id", "date", "rate", "coin", "fiat", "resolution", "sources").
 		From(t.table).
 		Where(squirrel.Eq{
 			"coin": coin,
@@ -148,13 +384,15 @@ func (t *PostgresDB) Oldest(ctx context.Context, coin string, fiat string) (btcl
 	var row = q.QueryRowContext(ctx)
 	var rate btclists.Rate
 
-	var ts pgtype.Timestamp
-	if err := row.Scan(&rate.Id, &ts, &rate.Rate, &rate.Coin, &rate.Fiat); err != nil {
+	var ts pgtype.Timestamptz
+	var sources string
+	if err := row.Scan(&rate.Id, &ts, &rate.Rate, &rate.Coin, &rate.Fiat, &rate.Resolution, &sources); err != nil {
 		log.Printf("[BTC Listings] | [ERROR] | [DB] | Failed to marshal row | %s\n", err)
 		return rate, err
 	}
 
 	rate.Date = ts.Time.UTC()
+	rate.Sources = splitSources(sources)
 
 	return rate, nil
 }
@@ -164,16 +402,16 @@ func (t *PostgresDB) Oldest(ctx context.Context, coin string, fiat string) (btcl
 // range would be returned.
 func (t *PostgresDB) At(ctx context.Context, coin string, fiat string, tm time.Time) (btclists.Rate, error) {
 	var q = t.sdb.
-		Select("t.id", "t.date", "t.rate", "t.coin", "t.fiat").
+		Select("t.id", "t.date", "t.rate", "t.coin", "t.fiat", "t.resolution", "t.sources").
 		From(fmt.Sprintf("%s t", t.table)).
 		Where(squirrel.Eq{
 			"t.coin": coin,
 			"t.fiat": fiat,
 		}).
 		Where(
-			"t.date BETWEEN $3::timestamp AND $4::timestamp",
-			tm.Format(btclists.DateTimeFormat),
-			tm.Add(acceptableRange).Format(btclists.DateTimeFormat), // scale this over 1 minutes, so we should be able to get exact or closest.
+			"t.date BETWEEN $3 AND $4",
+			tm.UTC(),
+			tm.Add(acceptableRange).UTC(), // scale this over 1 minutes, so we should be able to get exact or closest.
 		).
 		OrderBy("t.date ASC").
 		Limit(1)
@@ -182,28 +420,64 @@ func (t *PostgresDB) At(ctx context.Context, coin string, fiat string, tm time.T
 
 	var rate btclists.Rate
 
-	var ts pgtype.Timestamp
-	if err := row.Scan(&rate.Id, &ts, &rate.Rate, &rate.Coin, &rate.Fiat); err != nil {
+	var ts pgtype.Timestamptz
+	var sources string
+	if err := row.Scan(&rate.Id, &ts, &rate.Rate, &rate.Coin, &rate.Fiat, &rate.Resolution, &sources); err != nil {
 		log.Printf("[BTC Listings] | [ERROR] | [DB] | Failed to marshal row | %s\n", err)
 		return rate, err
 	}
 
-	rate.Date = ts.Time
+	rate.Date = ts.Time.UTC()
+	rate.Sources = splitSources(sources)
+	return rate, nil
+}
+
+// Before returns the last rate at or before provided time, dated no earlier than
+// maxAge before it.
+func (t *PostgresDB) Before(ctx context.Context, coin string, fiat string, tm time.Time, maxAge time.Duration) (btclists.Rate, error) {
+	var q = t.sdb.
+		Select("id", "date", "rate", "coin", "fiat", "resolution", "sources").
+		From(t.table).
+		Where(squirrel.Eq{
+			"coin": coin,
+			"fiat": fiat,
+		}).
+		Where(
+			"date BETWEEN ? AND ?",
+			tm.Add(-maxAge).UTC(),
+			tm.UTC(),
+		).
+		OrderBy("date DESC").
+		Limit(1)
+
+	var row = q.QueryRowContext(ctx)
+
+	var rate btclists.Rate
+
+	var ts pgtype.Timestamptz
+	var sources string
+	if err := row.Scan(&rate.Id, &ts, &rate.Rate, &rate.Coin, &rate.Fiat, &rate.Resolution, &sources); err != nil {
+		log.Printf("[BTC Listings] | [ERROR] | [DB] | Failed to marshal row | %s\n", err)
+		return rate, err
+	}
+
+	rate.Date = ts.Time.UTC()
+	rate.Sources = splitSources(sources)
 	return rate, nil
 }
 
 func (t *PostgresDB) Range(ctx context.Context, coin string, fiat string, from time.Time, to time.Time) ([]btclists.Rate, error) {
 	var q = t.sdb.
-		Select("t.id", "t.date", "t.rate", "t.coin", "t.fiat").
+		Select("t.id", "t.date", "t.rate", "t.coin", "t.fiat", "t.resolution", "t.sources").
 		From(fmt.Sprintf("%s t", t.table)).
 		Where(squirrel.Eq{
 			"t.coin": coin,
 			"t.fiat": fiat,
 		}).
 		Where(
-			"t.date BETWEEN $3::timestamp AND $4::timestamp",
-			from.Format(btclists.DateTimeFormat),
-			to.Format(btclists.DateTimeFormat),
+			"t.date BETWEEN $3 AND $4",
+			from.UTC(),
+			to.UTC(),
 		).
 		OrderBy("date DESC")
 
@@ -213,23 +487,83 @@ func (t *PostgresDB) Range(ctx context.Context, coin string, fiat string, from t
 		return nil, err
 	}
 
+	return scanRates(rows)
+}
+
+// RangePage returns a page of rates within provided time range, where page.After
+// is set, only rates after it in direction of page.Order are returned.
+//
+// Pagination is keyset based on the date column, hence cursors remain valid even as
+// new records are added.
+func (t *PostgresDB) RangePage(ctx context.Context, coin string, fiat string, from time.Time, to time.Time, page btclists.Page) ([]btclists.Rate, error) {
+	var q = t.sdb.
+		Select("t.id", "t.date", "t.rate", "t.coin", "t.fiat", "t.resolution", "t.sources").
+		From(fmt.Sprintf("%s t", t.table)).
+		Where(squirrel.Eq{
+			"t.coin": coin,
+			"t.fiat": fiat,
+		}).
+		Where(
+			"t.date BETWEEN ? AND ?",
+			from.UTC(),
+			to.UTC(),
+		)
+
+	if page.Order == btclists.Ascending {
+		if !page.After.IsZero() {
+			q = q.Where("t.date > ?", page.After.UTC())
+		}
+		q = q.OrderBy("t.date ASC")
+	} else {
+		if !page.After.IsZero() {
+			q = q.Where("t.date < ?", page.After.UTC())
+		}
+		q = q.OrderBy("t.date DESC")
+	}
+
+	if page.Limit > 0 {
+		q = q.Limit(uint64(page.Limit))
+	}
+
+	var rows, err = q.QueryContext(ctx)
+	if err != nil {
+		log.Printf("[BTC Listings] | [ERROR] | [DB] | Failed query request | %s\n", err)
+		return nil, err
+	}
+
+	return scanRates(rows)
+}
+
+func scanRates(rows *sql.Rows) ([]btclists.Rate, error) {
+	defer rows.Close()
+
 	var rates []btclists.Rate
 	for rows.Next() {
 		var rate btclists.Rate
 
-		var ts pgtype.Timestamp
-		if err := rows.Scan(&rate.Id, &ts, &rate.Rate, &rate.Coin, &rate.Fiat); err != nil {
+		var ts pgtype.Timestamptz
+		var sources string
+		if err := rows.Scan(&rate.Id, &ts, &rate.Rate, &rate.Coin, &rate.Fiat, &rate.Resolution, &sources); err != nil {
 			log.Printf("[BTC Listings] | [ERROR] | [DB] | Failed scan row into struct | %s\n", err)
 			return nil, err
 		}
 
 		rate.Date = ts.Time.UTC()
+		rate.Sources = splitSources(sources)
 		rates = append(rates, rate)
 	}
 
+	if err := rows.Err(); err != nil {
+		log.Printf("[BTC Listings] | [ERROR] | [DB] | Failed iterating rows | %s\n", err)
+		return nil, err
+	}
+
 	return rates, nil
 }
 
+// AverageForRange returns the average of rates within provided time range.
+//
+// Returns btclists.ErrRateNotFound if there are no rates within time range.
 func (t *PostgresDB) AverageForRange(ctx context.Context, coin string, fiat string, from time.Time, to time.Time) (decimal.Decimal, error) {
 	var q = t.sdb.
 		Select("AVG(rate)").
@@ -239,20 +573,211 @@ func (t *PostgresDB) AverageForRange(ctx context.Context, coin string, fiat stri
 			"fiat": fiat,
 		}).
 		Where(
-			"date between $3::timestamp and $4::timestamp",
-			from.Format(btclists.DateTimeFormat),
-			to.Format(btclists.DateTimeFormat),
+			"date between $3 and $4",
+			from.UTC(),
+			to.UTC(),
 		)
 
-	var avg decimal.Decimal
+	var avg decimal.NullDecimal
 
 	var row = q.QueryRowContext(ctx)
 	if err := row.Scan(&avg); err != nil {
 		log.Printf("[BTC Listings] | [ERROR] | [DB] | Failed to marshal row | %s\n", err)
-		return avg, err
+		return avg.Decimal, err
+	}
+
+	// AVG is null where there are no rates within time range.
+	if !avg.Valid {
+		return avg.Decimal, btclists.ErrRateNotFound
+	}
+
+	return avg.Decimal, nil
+}
+
+// resolutionSeconds is the SQL expression returning the duration in seconds of the
+// resolution column, 0 for spot rates (e.g '5MIN' is 300).
+const resolutionSeconds = `CASE
+		WHEN resolution LIKE '%SEC' THEN LEFT(resolution, -3)::int
+		WHEN resolution LIKE '%MIN' THEN LEFT(resolution, -3)::int * 60
+		WHEN resolution LIKE '%HRS' THEN LEFT(resolution, -3)::int * 3600
+		WHEN resolution LIKE '%DAY' THEN LEFT(resolution, -3)::int * 86400
+		ELSE 0
+	END`
+
+// ScanRange implements RangeScanner, computing the gaps and coverage of stored rates
+// within provided time range in Postgres, so rates of the range are never loaded.
+//
+// Gaps are found by comparing each date with the previous one (i.e lag over date), while
+// coverage sums the period each rate covers past those covered by earlier rates.
+func (t *PostgresDB) ScanRange(ctx context.Context, coin string, fiat string, from time.Time, to time.Time, cadence time.Duration) (RangeScan, error) {
+	var scan RangeScan
+
+	var coverageQuery = fmt.Sprintf(`
+WITH spans AS (
+	SELECT date, date + GREATEST($5::float8, %s) * INTERVAL '1 second' AS ends
+	FROM %s
+	WHERE coin = $1 AND fiat = $2 AND date BETWEEN $3 AND $4
+), covered AS (
+	SELECT date, ends, MAX(ends) OVER (ORDER BY date ROWS BETWEEN UNBOUNDED PRECEDING AND 1 PRECEDING) AS covered_till
+	FROM spans
+)
+SELECT COUNT(*), COALESCE(SUM(GREATEST(0, EXTRACT(EPOCH FROM LEAST(ends, $4) - GREATEST(date, covered_till, $3)))), 0)::float8
+FROM covered`, resolutionSeconds, t.table)
+
+	var covered float64
+	var row = t.db.QueryRowContext(ctx, coverageQuery, coin, fiat, from.UTC(), to.UTC(), cadence.Seconds())
+	if err := row.Scan(&scan.Count, &covered); err != nil {
+		log.Printf("[BTC Listings] | [ERROR] | [DB] | Failed to marshal row | %s\n", err)
+		return scan, err
+	}
+
+	// mirrors CoverageOf, an empty time range is covered by any rate.
+	if total := to.Sub(from).Seconds(); total > 0 {
+		scan.Coverage = covered / total
+	} else if scan.Count > 0 {
+		scan.Coverage = 1
+	}
+
+	var gapsQuery = fmt.Sprintf(`
+WITH dates AS (
+	SELECT date FROM %s WHERE coin = $1 AND fiat = $2 AND date BETWEEN $3 AND $4
+	UNION ALL SELECT $3::timestamptz
+	UNION ALL SELECT $4::timestamptz
+), steps AS (
+	SELECT LAG(date) OVER (ORDER BY date) AS previous, date FROM dates
+), gaps AS (
+	SELECT previous, date FROM steps WHERE date - previous > $5::float8 * INTERVAL '1 second'
+)
+SELECT previous, date, COUNT(*) OVER (), MAX(date) OVER () FROM gaps ORDER BY previous LIMIT $6`, t.table)
+
+	var rows, err = t.db.QueryContext(ctx, gapsQuery, coin, fiat, from.UTC(), to.UTC(), (2 * cadence).Seconds(), MaxGapRequests)
+	if err != nil {
+		log.Printf("[BTC Listings] | [ERROR] | [DB] | Failed to scan range | %s\n", err)
+		return scan, err
+	}
+	defer rows.Close()
+
+	var total int
+	var lastTo time.Time
+	for rows.Next() {
+		var gap = Gap{Coin: coin, Fiat: fiat}
+		if err := rows.Scan(&gap.From, &gap.To, &total, &lastTo); err != nil {
+			log.Printf("[BTC Listings] | [ERROR] | [DB] | Failed to marshal row | %s\n", err)
+			return scan, err
+		}
+		gap.From, gap.To = gap.From.UTC(), gap.To.UTC()
+		scan.Gaps = append(scan.Gaps, gap)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("[BTC Listings] | [ERROR] | [DB] | Failed iterating rows | %s\n", err)
+		return scan, err
+	}
+
+	// as coalesceGaps does, too many gaps are fetched as a single window.
+	if total > MaxGapRequests {
+		scan.Gaps = []Gap{{Coin: coin, Fiat: fiat, From: scan.Gaps[0].From, To: lastTo.UTC()}}
+	}
+
+	return scan, nil
+}
+
+// StatsForRange returns summary statistics of rates within provided time range, computed
+// by Postgres in a single query using it's aggregates, where the median and percentiles
+// are interpolated between rates (see percentileColumn) and the standard deviation computed
+// (see stdDevColumn) in numeric.
+//
+// Returns btclists.ErrRateNotFound if there are no rates within time range.
+func (t *PostgresDB) StatsForRange(ctx context.Context, coin string, fiat string, from time.Time, to time.Time, percentiles []float64) (btclists.Stats, error) {
+	var q = t.sdb.
+		Select(
+			"COUNT(*)",
+			"MIN(rate)",
+			"MAX(rate)",
+			"(ARRAY_AGG(rate ORDER BY date ASC))[1]",
+			"(ARRAY_AGG(rate ORDER BY date DESC))[1]",
+			"AVG(rate)",
+			percentileColumn(50),
+			stdDevColumn(),
+		).
+		From(t.table).
+		Where(squirrel.Eq{
+			"coin": coin,
+			"fiat": fiat,
+		}).
+		Where(
+			"date BETWEEN ? AND ?",
+			from.UTC(),
+			to.UTC(),
+		)
+
+	for _, percent := range percentiles {
+		q = q.Column(percentileColumn(percent))
+	}
+
+	var stats = btclists.Stats{Coin: coin, Fiat: fiat, From: from, To: to}
+	var min, max, first, last, mean, median, stddev decimal.NullDecimal
+	var values = make([]decimal.NullDecimal, len(percentiles))
+
+	var targets = []interface{}{&stats.Count, &min, &max, &first, &last, &mean, &median, &stddev}
+	for index := range values {
+		targets = append(targets, &values[index])
+	}
+
+	var row = q.QueryRowContext(ctx)
+	if err := row.Scan(targets...); err != nil {
+		log.Printf("[BTC Listings] | [ERROR] | [DB] | Failed to marshal row | %s\n", err)
+		return stats, err
+	}
+
+	if stats.Count == 0 {
+		return stats, btclists.ErrRateNotFound
 	}
 
-	return avg, nil
+	stats.Min = min.Decimal
+	stats.Max = max.Decimal
+	stats.First = first.Decimal
+	stats.Last = last.Decimal
+	stats.Mean = mean.Decimal
+	stats.Median = median.Decimal
+
+	// stddev is null for a single rate, for which there is no deviation.
+	stats.StdDev = stddev.Decimal
+
+	for index, percent := range percentiles {
+		stats.Percentiles = append(stats.Percentiles, btclists.Percentile{
+			Percent: percent,
+			Value:   values[index].Decimal,
+		})
+	}
+
+	return stats, nil
+}
+
+// percentileColumn returns the SQL expression of the percent (0 to 100) percentile of rates,
+// interpolating between the closest rates as percentileOf does.
+//
+// Postgres's percentile_cont interpolates in float8, losing the precision of numeric rates,
+// so rates are interpolated by hand from their sorted array instead.
+func percentileColumn(percent float64) string {
+	var sorted = "(ARRAY_AGG(rate ORDER BY rate))"
+	var position = fmt.Sprintf("(%s * (COUNT(*) - 1))", percentFraction(percent))
+	var lower = fmt.Sprintf("%s[FLOOR(%s)::int + 1]", sorted, position)
+	var upper = fmt.Sprintf("%s[FLOOR(%s)::int + 2]", sorted, position)
+
+	// upper is null for the last rate, which is then the percentile.
+	return fmt.Sprintf("COALESCE(%s + (%s - %s) * (%s - FLOOR(%s)), %s)", lower, upper, lower, position, position, lower)
+}
+
+// stdDevColumn returns the SQL expression of the sample standard deviation of rates,
+// rounded to StdDevPrecision as stdDevOf does.
+//
+// Postgres's stddev_samp picks the scale of it's result by the magnitude of rates, so the
+// variance is computed by hand at varianceScale places instead.
+func stdDevColumn() string {
+	var deviations = fmt.Sprintf("ROUND(COUNT(*) * SUM(rate * rate) - SUM(rate) * SUM(rate), %d)", varianceScale)
+	var variance = fmt.Sprintf("ROUND(%s / NULLIF(COUNT(*) * (COUNT(*) - 1), 0), %d)", deviations, varianceScale)
+	return fmt.Sprintf("ROUND(SQRT(%s), %d)", variance, StdDevPrecision)
 }
 
 func (t *PostgresDB) CountForRange(ctx context.Context, coin string, fiat string, from time.Time, to time.Time) (int, error) {
@@ -264,9 +789,9 @@ func (t *PostgresDB) CountForRange(ctx context.Context, coin string, fiat string
 			"fiat": fiat,
 		}).
 		Where(
-			"date between $3::timestamp and $4::timestamp",
-			from.Format(btclists.DateTimeFormat),
-			to.Format(btclists.DateTimeFormat),
+			"date between $3 and $4",
+			from.UTC(),
+			to.UTC(),
 		)
 
 	var total int
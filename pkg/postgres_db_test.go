@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"fmt"
 	"io/ioutil"
+	"log"
 	"os"
 	"testing"
 	"time"
@@ -19,13 +20,35 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/go-testfixtures/testfixtures/v3"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/stdlib"
 )
 
 var (
-	tableName = "ratings"
-	dbURL     = os.Getenv("DATABASE_URL")
+	tableName        = "ratings"
+	candlesTableName = "ratings_candles"
+	dbURL            = os.Getenv("DATABASE_URL")
 )
 
+// TestMain creates tables of the test database from the schema migrations.
+func TestMain(m *testing.M) {
+	if err := migrateTestDatabase(); err != nil {
+		log.Printf("[BTC Listings] | [ERROR] | [TEST] | Failed to migrate test database | %s\n", err)
+	}
+	os.Exit(m.Run())
+}
+
+func TestRatingsDB_RatesDB(t *testing.T) {
+	var db, err = pkg.NewPostgresDBFromURL(dbURL, tableName)
+	require.NoError(t, err)
+
+	defer func() {
+		require.NoError(t, tearDownTable(db.DB(), tableName))
+	}()
+
+	testRatesDB(t, db)
+}
+
 func TestRatingsDB_Add(t *testing.T) {
 	var db, err = pkg.NewPostgresDBFromURL(dbURL, tableName)
 	require.NoError(t, err)
@@ -89,6 +112,185 @@ func TestRatingsDB_AddBatch_WithDups(t *testing.T) {
 	}
 }
 
+func TestRatingsDB_AddBatch_Pairs(t *testing.T) {
+	var db, err = pkg.NewPostgresDBFromURL(dbURL, tableName)
+	require.NoError(t, err)
+
+	defer func() {
+		require.NoError(t, tearDownTable(db.DB(), tableName))
+	}()
+
+	var date = time.Now().UTC()
+	var rates = []btclists.Rate{
+		{Date: date, Coin: "BTC", Fiat: "USD", Rate: decimal.NewFromFloat(7200.5)},
+		{Date: date, Coin: "BTC", Fiat: "EUR", Rate: decimal.NewFromFloat(6600.5)},
+		{Date: date, Coin: "ETH", Fiat: "USD", Rate: decimal.NewFromFloat(170.5)},
+	}
+
+	t.Logf("Should store rates of different pairs at the same date")
+	{
+		require.NoError(t, db.AddBatch(context.Background(), rates))
+		require.NoError(t, db.Add(context.Background(), rates[1]))
+
+		var count, countErr = getTableCount(db.DB(), tableName)
+		require.NoError(t, countErr)
+		require.Equal(t, 3, count)
+	}
+}
+
+func TestRatingsDB_Upsert(t *testing.T) {
+	var db, err = pkg.NewPostgresDBFromURL(dbURL, tableName)
+	require.NoError(t, err)
+
+	defer func() {
+		require.NoError(t, tearDownTable(db.DB(), tableName))
+	}()
+
+	var rate btclists.Rate
+	rate.Fiat = FIAT
+	rate.Coin = COIN
+	rate.Date = time.Now().UTC().Truncate(time.Second)
+	rate.Rate = decimal.NewFromFloat(432.12)
+	require.NoError(t, db.Add(context.Background(), rate))
+
+	t.Logf("Should keep stored rate without upsert")
+	{
+		var corrected = rate
+		corrected.Rate = decimal.NewFromFloat(433.12)
+		require.NoError(t, db.Add(context.Background(), corrected))
+
+		var latest, latestErr = db.Latest(context.Background(), COIN, FIAT)
+		require.NoError(t, latestErr)
+		require.Equal(t, "432.12", latest.Rate.String())
+	}
+
+	t.Logf("Should replace stored rate with upsert")
+	{
+		var first, last = rate, rate
+		first.Rate = decimal.NewFromFloat(433.12)
+		last.Rate = decimal.NewFromFloat(434.12)
+
+		var ctx = btclists.WithUpsert(context.Background())
+		require.NoError(t, db.AddBatch(ctx, []btclists.Rate{first, last}))
+
+		var latest, latestErr = db.Latest(context.Background(), COIN, FIAT)
+		require.NoError(t, latestErr)
+		require.Equal(t, "434.12", latest.Rate.String())
+
+		var count, countErr = getTableCount(db.DB(), tableName)
+		require.NoError(t, countErr)
+		require.Equal(t, 1, count)
+	}
+
+	t.Logf("Should store sources of consensus rates")
+	{
+		var agreed = rate
+		agreed.Sources = []string{"coinapi", "kraken"}
+		require.NoError(t, db.Add(btclists.WithUpsert(context.Background()), agreed))
+
+		var latest, latestErr = db.Latest(context.Background(), COIN, FIAT)
+		require.NoError(t, latestErr)
+		require.Equal(t, []string{"coinapi", "kraken"}, latest.Sources)
+
+		var rates, rangeErr = db.Range(context.Background(), COIN, FIAT, rate.Date, rate.Date)
+		require.NoError(t, rangeErr)
+		require.Len(t, rates, 1)
+		require.Equal(t, []string{"coinapi", "kraken"}, rates[0].Sources)
+	}
+}
+
+func TestRatingsDB_TimeZones(t *testing.T) {
+	var db = newZonedDB(t, "Asia/Kathmandu")
+	defer db.Close()
+
+	var utcDB = newZonedDB(t, "UTC")
+	defer utcDB.Close()
+
+	defer func() {
+		require.NoError(t, tearDownTable(db.DB(), tableName))
+		require.NoError(t, tearDownTable(db.DB(), candlesTableName))
+		require.NoError(t, tearDownTable(db.DB(), pkg.BudgetsTable))
+	}()
+
+	var ctx = context.Background()
+	var newYork = time.FixedZone("EDT", -4*60*60)
+	var date = time.Date(2020, 4, 8, 10, 0, 0, 0, newYork)
+
+	var rate btclists.Rate
+	rate.Fiat = FIAT
+	rate.Coin = COIN
+	rate.Date = date
+	rate.Rate = decimal.NewFromFloat(432.12)
+
+	t.Logf("Should store rates written in any zone at their instant")
+	{
+		require.NoError(t, db.Add(ctx, rate))
+
+		for _, zoned := range []*pkg.PostgresDB{db, utcDB} {
+			var latest, err = zoned.Latest(ctx, COIN, FIAT)
+			require.NoError(t, err)
+			require.Equal(t, time.UTC, latest.Date.Location())
+			require.True(t, date.Equal(latest.Date))
+			require.Equal(t, "2020-04-08T14:00:00Z", latest.Date.Format(btclists.DateTimeFormat))
+		}
+	}
+
+	t.Logf("Should return rates in UTC from At, Oldest and Range")
+	{
+		var at, atErr = db.At(ctx, COIN, FIAT, date.Add(-30*time.Second))
+		require.NoError(t, atErr)
+		require.Equal(t, time.UTC, at.Date.Location())
+		require.True(t, date.Equal(at.Date))
+
+		var oldest, oldestErr = db.Oldest(ctx, COIN, FIAT)
+		require.NoError(t, oldestErr)
+		require.Equal(t, time.UTC, oldest.Date.Location())
+
+		var rates, rangeErr = db.Range(ctx, COIN, FIAT, date.UTC().Add(-time.Second), date.UTC().Add(time.Second))
+		require.NoError(t, rangeErr)
+		require.Len(t, rates, 1)
+		require.Equal(t, time.UTC, rates[0].Date.Location())
+
+		rates, rangeErr = db.Range(ctx, COIN, FIAT, date.Add(time.Second), date.Add(time.Hour))
+		require.NoError(t, rangeErr)
+		require.Len(t, rates, 0)
+
+		var count, countErr = db.CountForRange(ctx, COIN, FIAT, date, date)
+		require.NoError(t, countErr)
+		require.Equal(t, 1, count)
+	}
+
+	t.Logf("Should return candles and budgets in UTC")
+	{
+		require.NoError(t, db.AddCandles(ctx, []btclists.Candle{{
+			Coin:  COIN,
+			Fiat:  FIAT,
+			Start: date,
+			End:   date.Add(time.Minute),
+			Open:  decimal.NewFromFloat(432.12),
+			High:  decimal.NewFromFloat(432.12),
+			Low:   decimal.NewFromFloat(432.12),
+			Close: decimal.NewFromFloat(432.12),
+		}}))
+
+		var candles, candlesErr = utcDB.Candles(ctx, COIN, FIAT, date, date)
+		require.NoError(t, candlesErr)
+		require.Len(t, candles, 1)
+		require.Equal(t, time.UTC, candles[0].Start.Location())
+		require.True(t, date.Equal(candles[0].Start))
+		require.True(t, date.Add(time.Minute).Equal(candles[0].End))
+
+		var updatedAt = time.Date(2020, 4, 8, 10, 0, 0, 123456000, newYork)
+		require.NoError(t, db.UpdateBudget(ctx, btclists.Budget{Provider: "zoned", Remaining: 10, UpdatedAt: updatedAt}))
+
+		var budget, budgetErr = utcDB.Budget(ctx, "zoned")
+		require.NoError(t, budgetErr)
+		require.Equal(t, time.UTC, budget.UpdatedAt.Location())
+		require.True(t, updatedAt.Equal(budget.UpdatedAt))
+		require.True(t, budget.ResetAt.IsZero())
+	}
+}
+
 func TestRatingsDB_Latest(t *testing.T) {
 	var db, err = pkg.NewPostgresDBFromURL(dbURL, tableName)
 	require.NoError(t, err)
@@ -151,6 +353,29 @@ func TestRatingsDB_At(t *testing.T) {
 	require.Equal(t, first.Date, latestAt.Date)
 }
 
+func TestRatingsDB_Before(t *testing.T) {
+	var db, err = pkg.NewPostgresDBFromURL(dbURL, tableName)
+	require.NoError(t, err)
+	require.NoError(t, prepareTestDatabase(db.DB()))
+
+	defer func() {
+		require.NoError(t, tearDownTable(db.DB(), tableName))
+	}()
+
+	var fixtures, fixtureErr = getFixtures()
+	require.NoError(t, fixtureErr)
+
+	var second = fixtures[1]
+
+	var before, beforeErr = db.Before(context.Background(), COIN, FIAT, second.Date.Add(5*time.Minute), 10*time.Minute)
+	require.NoError(t, beforeErr)
+	require.Equal(t, 2, before.Id)
+	require.Equal(t, second.Date, before.Date)
+
+	_, beforeErr = db.Before(context.Background(), COIN, FIAT, second.Date.Add(5*time.Minute), time.Minute)
+	require.Equal(t, sql.ErrNoRows, beforeErr)
+}
+
 func TestRatingsDB_Range(t *testing.T) {
 	var db, err = pkg.NewPostgresDBFromURL(dbURL, tableName)
 	require.NoError(t, err)
@@ -174,6 +399,48 @@ func TestRatingsDB_Range(t *testing.T) {
 	require.Equal(t, []btclists.Rate{fixtures[5], fixtures[4], fixtures[3]}, records)
 }
 
+func TestRatingsDB_RangePage(t *testing.T) {
+	var db, err = pkg.NewPostgresDBFromURL(dbURL, tableName)
+	require.NoError(t, err)
+	require.NoError(t, prepareTestDatabase(db.DB()))
+
+	defer func() {
+		require.NoError(t, tearDownTable(db.DB(), tableName))
+	}()
+
+	var fixtures, fixtureErr = getFixtures()
+	require.NoError(t, fixtureErr)
+
+	var fromRate = fixtures[1]
+	var toRate = fixtures[5]
+
+	t.Logf("Should return first page in ascending order")
+	var firstPage, firstErr = db.RangePage(context.Background(), COIN, FIAT, fromRate.Date, toRate.Date, btclists.Page{
+		Limit: 2,
+		Order: btclists.Ascending,
+	})
+	require.NoError(t, firstErr)
+	require.Equal(t, []btclists.Rate{fixtures[1], fixtures[2]}, firstPage)
+
+	t.Logf("Should return next page after cursor")
+	var nextPage, nextErr = db.RangePage(context.Background(), COIN, FIAT, fromRate.Date, toRate.Date, btclists.Page{
+		Limit: 2,
+		Order: btclists.Ascending,
+		After: firstPage[1].Date,
+	})
+	require.NoError(t, nextErr)
+	require.Equal(t, []btclists.Rate{fixtures[3], fixtures[4]}, nextPage)
+
+	t.Logf("Should return page in descending order")
+	var descPage, descErr = db.RangePage(context.Background(), COIN, FIAT, fromRate.Date, toRate.Date, btclists.Page{
+		Limit: 2,
+		Order: btclists.Descending,
+		After: fixtures[4].Date,
+	})
+	require.NoError(t, descErr)
+	require.Equal(t, []btclists.Rate{fixtures[3], fixtures[2]}, descPage)
+}
+
 func TestRatingsDB_AverageForRange(t *testing.T) {
 	var db, err = pkg.NewPostgresDBFromURL(dbURL, tableName)
 	require.NoError(t, err)
@@ -200,6 +467,42 @@ func TestRatingsDB_AverageForRange(t *testing.T) {
 	require.NotEmpty(t, expectedAvg, avg)
 }
 
+func TestRatingsDB_StatsForRange(t *testing.T) {
+	var db, err = pkg.NewPostgresDBFromURL(dbURL, tableName)
+	require.NoError(t, err)
+	require.NoError(t, prepareTestDatabase(db.DB()))
+
+	defer func() {
+		require.NoError(t, tearDownTable(db.DB(), tableName))
+	}()
+
+	var fixtures, fixtureErr = getFixtures()
+	require.NoError(t, fixtureErr)
+
+	var fromRate = fixtures[3]
+	var toRate = fixtures[5]
+
+	var stats, statsErr = db.StatsForRange(context.Background(), COIN, FIAT, fromRate.Date, toRate.Date, []float64{0, 100})
+	require.NoError(t, statsErr)
+	require.Equal(t, 3, stats.Count)
+	require.Equal(t, fixtures[4].Rate.String(), stats.Min.String())
+	require.Equal(t, fixtures[5].Rate.String(), stats.Max.String())
+	require.Equal(t, fixtures[3].Rate.String(), stats.First.String())
+	require.Equal(t, fixtures[5].Rate.String(), stats.Last.String())
+	require.Equal(t, fixtures[3].Rate.String(), stats.Median.String())
+	require.True(t, stats.StdDev.GreaterThan(decimal.Zero))
+
+	require.Len(t, stats.Percentiles, 2)
+	require.Equal(t, stats.Min.String(), stats.Percentiles[0].Value.String())
+	require.Equal(t, stats.Max.String(), stats.Percentiles[1].Value.String())
+
+	t.Logf("Should fail for range without rates")
+	{
+		var _, emptyErr = db.StatsForRange(context.Background(), COIN, FIAT, time.Unix(0, 0), time.Unix(60, 0), nil)
+		require.Equal(t, btclists.ErrRateNotFound, emptyErr)
+	}
+}
+
 func TestRatingsDB_CountForRange(t *testing.T) {
 	var db, err = pkg.NewPostgresDBFromURL(dbURL, tableName)
 	require.NoError(t, err)
@@ -220,6 +523,177 @@ func TestRatingsDB_CountForRange(t *testing.T) {
 	require.NotEmpty(t, 3, count)
 }
 
+func TestRatingsDB_ScanRange(t *testing.T) {
+	var db, err = pkg.NewPostgresDBFromURL(dbURL, tableName)
+	require.NoError(t, err)
+
+	defer func() {
+		require.NoError(t, tearDownTable(db.DB(), tableName))
+	}()
+
+	var ctx = context.Background()
+	var from = time.Date(2020, 4, 8, 14, 0, 0, 0, time.UTC)
+	var to = from.Add(time.Hour)
+
+	require.NoError(t, db.AddBatch(ctx, []btclists.Rate{
+		{Date: from, Coin: COIN, Fiat: FIAT, Rate: decimal.NewFromInt(1)},
+		{Date: from.Add(time.Minute), Coin: COIN, Fiat: FIAT, Rate: decimal.NewFromInt(1)},
+		{Date: from.Add(10 * time.Minute), Coin: COIN, Fiat: FIAT, Rate: decimal.NewFromInt(1), Resolution: btclists.Resolution1Hour},
+		{Date: from.Add(20 * time.Minute), Coin: COIN, Fiat: FIAT, Rate: decimal.NewFromInt(1), Resolution: btclists.Resolution2Min},
+		{Date: from.Add(50 * time.Minute), Coin: COIN, Fiat: FIAT, Rate: decimal.NewFromInt(1)},
+		{Date: from.Add(10 * time.Minute), Coin: "ETH", Fiat: FIAT, Rate: decimal.NewFromInt(1)},
+	}))
+
+	t.Logf("Should scan range as ScanRates does over stored rates")
+	{
+		var stored, rangeErr = db.Range(ctx, COIN, FIAT, from, to)
+		require.NoError(t, rangeErr)
+
+		var expected = pkg.ScanRates(stored, from, to, time.Minute)
+
+		var scan, scanErr = db.ScanRange(ctx, COIN, FIAT, from, to, time.Minute)
+		require.NoError(t, scanErr)
+		require.Equal(t, expected.Count, scan.Count)
+		require.InDelta(t, expected.Coverage, scan.Coverage, 0.0001)
+		require.Len(t, scan.Gaps, len(expected.Gaps))
+		for index, gap := range scan.Gaps {
+			require.Equal(t, expected.Gaps[index].From, gap.From)
+			require.Equal(t, expected.Gaps[index].To, gap.To)
+		}
+	}
+
+	t.Logf("Should treat a range without rates as a single gap")
+	{
+		var scan, scanErr = db.ScanRange(ctx, COIN, "EUR", from, to, time.Minute)
+		require.NoError(t, scanErr)
+		require.Equal(t, 0, scan.Count)
+		require.Equal(t, 0.0, scan.Coverage)
+		require.Equal(t, []pkg.Gap{{Coin: COIN, Fiat: "EUR", From: from, To: to}}, scan.Gaps)
+	}
+}
+
+func TestRatingsDB_Candles(t *testing.T) {
+	var db, err = pkg.NewPostgresDBFromURL(dbURL, tableName)
+	require.NoError(t, err)
+
+	defer func() {
+		require.NoError(t, tearDownTable(db.DB(), candlesTableName))
+	}()
+
+	var start = time.Date(2020, 4, 8, 14, 0, 0, 0, time.UTC)
+
+	var candles []btclists.Candle
+	for i := 0; i < 3; i++ {
+		candles = append(candles, btclists.Candle{
+			Coin:   COIN,
+			Fiat:   FIAT,
+			Start:  start.Add(time.Duration(i) * 2 * time.Minute),
+			End:    start.Add(time.Duration(i+1) * 2 * time.Minute),
+			Open:   decimal.NewFromFloat(7201.5),
+			High:   decimal.NewFromFloat(7210.25),
+			Low:    decimal.NewFromFloat(7199.1),
+			Close:  decimal.NewFromFloat(7205.75),
+			Volume: decimal.NewFromFloat(12.3456789),
+			Trades: int64(230 + i),
+		})
+	}
+
+	t.Logf("Should succesfully add candles, ignoring duplicates")
+	{
+		require.NoError(t, db.AddCandles(context.Background(), candles))
+		require.NoError(t, db.AddCandles(context.Background(), candles[:1]))
+
+		var count, countErr = getTableCount(db.DB(), candlesTableName)
+		require.NoError(t, countErr)
+		require.Equal(t, 3, count)
+	}
+
+	t.Logf("Should retrieve candles within range")
+	{
+		var results, candlesErr = db.Candles(context.Background(), COIN, FIAT, candles[1].Start, candles[2].Start)
+		require.NoError(t, candlesErr)
+		require.Len(t, results, 2)
+
+		for index, result := range results {
+			var expected = candles[index+1]
+			require.True(t, expected.Start.Equal(result.Start))
+			require.True(t, expected.End.Equal(result.End))
+			require.Equal(t, expected.High.String(), result.High.String())
+			require.Equal(t, expected.Low.String(), result.Low.String())
+			require.Equal(t, expected.Volume.String(), result.Volume.String())
+			require.Equal(t, expected.Trades, result.Trades)
+		}
+	}
+}
+
+func TestRatingsDB_Budget(t *testing.T) {
+	var db, err = pkg.NewPostgresDBFromURL(dbURL, tableName)
+	require.NoError(t, err)
+
+	defer func() {
+		require.NoError(t, tearDownTable(db.DB(), pkg.BudgetsTable))
+	}()
+
+	var now = time.Now().UTC().Truncate(time.Second)
+	var budget = btclists.Budget{
+		Provider:  pkg.CoinAPIProvider,
+		Limit:     100,
+		Remaining: 42,
+		ResetAt:   now.Add(time.Hour),
+		UpdatedAt: now,
+	}
+
+	t.Logf("Should succesfully store budget")
+	{
+		require.NoError(t, db.UpdateBudget(context.Background(), budget))
+
+		var stored, budgetErr = db.Budget(context.Background(), pkg.CoinAPIProvider)
+		require.NoError(t, budgetErr)
+		require.Equal(t, int64(42), stored.Remaining)
+		require.True(t, budget.ResetAt.Equal(stored.ResetAt))
+	}
+
+	t.Logf("Should ignore budget older than stored budget")
+	{
+		var older = budget
+		older.Remaining = 90
+		older.UpdatedAt = now.Add(-time.Minute)
+		require.NoError(t, db.UpdateBudget(context.Background(), older))
+
+		var stored, budgetErr = db.Budget(context.Background(), pkg.CoinAPIProvider)
+		require.NoError(t, budgetErr)
+		require.Equal(t, int64(42), stored.Remaining)
+	}
+}
+
+// newZonedDB returns a PostgresDB whose sessions use provided time zone.
+func newZonedDB(t *testing.T, zone string) *pkg.PostgresDB {
+	var config, err = pgx.ParseConfig(dbURL)
+	require.NoError(t, err)
+	config.RuntimeParams["timezone"] = zone
+
+	var db, dbErr = pkg.NewPostgresDB(stdlib.OpenDB(*config), tableName)
+	require.NoError(t, dbErr)
+	return db
+}
+
+func migrateTestDatabase() error {
+	var db, err = pkg.NewPostgresDBFromURL(dbURL, tableName)
+	if err != nil {
+		return err
+	}
+
+	defer db.Close()
+
+	var migrator, migratorErr = pkg.NewMigrator(db.DB())
+	if migratorErr != nil {
+		return migratorErr
+	}
+
+	var _, upErr = migrator.Up(context.Background())
+	return upErr
+}
+
 func prepareTestDatabase(db *sql.DB) error {
 	var fixtures, err = testfixtures.New(
 		testfixtures.Database(db),
@@ -0,0 +1,104 @@
+package pkg_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+
+	"github.com/influx6/btclists"
+)
+
+// testRatesDB checks the behaviour shared by all btclists.RatesDB implementations,
+// so PostgresDB and MemoryDB can be swapped for one another.
+func testRatesDB(t *testing.T, db btclists.RatesDB) {
+	var ctx = context.Background()
+	var start = time.Date(2020, 4, 8, 14, 0, 0, 0, time.UTC)
+	var end = start.Add(time.Hour)
+
+	t.Logf("Should report missing rates of an empty range")
+	{
+		var _, err = db.Latest(ctx, COIN, FIAT)
+		require.Equal(t, sql.ErrNoRows, err)
+
+		_, err = db.Oldest(ctx, COIN, FIAT)
+		require.Equal(t, sql.ErrNoRows, err)
+
+		_, err = db.At(ctx, COIN, FIAT, start)
+		require.Equal(t, sql.ErrNoRows, err)
+
+		_, err = db.AverageForRange(ctx, COIN, FIAT, start, end)
+		require.Equal(t, btclists.ErrRateNotFound, err)
+
+		_, err = db.StatsForRange(ctx, COIN, FIAT, start, end, []float64{50})
+		require.Equal(t, btclists.ErrRateNotFound, err)
+
+		var rates, rangeErr = db.Range(ctx, COIN, FIAT, start, end)
+		require.NoError(t, rangeErr)
+		require.Empty(t, rates)
+
+		var count, countErr = db.CountForRange(ctx, COIN, FIAT, start, end)
+		require.NoError(t, countErr)
+		require.Equal(t, 0, count)
+	}
+
+	require.NoError(t, db.AddBatch(ctx, []btclists.Rate{
+		{Date: start, Coin: COIN, Fiat: FIAT, Rate: decimal.NewFromFloat(7200)},
+		{Date: start.Add(time.Minute), Coin: COIN, Fiat: FIAT, Rate: decimal.NewFromFloat(7201)},
+		{Date: start.Add(2 * time.Minute), Coin: COIN, Fiat: FIAT, Rate: decimal.NewFromFloat(7205)},
+		{Date: start.Add(time.Minute), Coin: "ETH", Fiat: FIAT, Rate: decimal.NewFromFloat(170)},
+	}))
+
+	t.Logf("Should serve stored rates of pair")
+	{
+		var latest, err = db.Latest(ctx, COIN, FIAT)
+		require.NoError(t, err)
+		require.Equal(t, start.Add(2*time.Minute), latest.Date)
+
+		var oldest, oldestErr = db.Oldest(ctx, COIN, FIAT)
+		require.NoError(t, oldestErr)
+		require.Equal(t, start, oldest.Date)
+
+		var rates, rangeErr = db.Range(ctx, COIN, FIAT, start, end)
+		require.NoError(t, rangeErr)
+		require.Len(t, rates, 3)
+		require.Equal(t, start.Add(2*time.Minute), rates[0].Date)
+
+		var count, countErr = db.CountForRange(ctx, COIN, FIAT, start, end)
+		require.NoError(t, countErr)
+		require.Equal(t, 3, count)
+	}
+
+	t.Logf("Should aggregate stored rates of pair")
+	{
+		var average, err = db.AverageForRange(ctx, COIN, FIAT, start, end)
+		require.NoError(t, err)
+		require.Equal(t, "7202", average.String())
+
+		var stats, statsErr = db.StatsForRange(ctx, COIN, FIAT, start, end, []float64{50})
+		require.NoError(t, statsErr)
+		require.Equal(t, 3, stats.Count)
+		require.Equal(t, "7200", stats.First.String())
+		require.Equal(t, "7205", stats.Last.String())
+		require.Equal(t, "7201", stats.Median.String())
+		require.Equal(t, "2.6457513110645905905", stats.StdDev.String())
+		require.Equal(t, "7201", stats.Percentiles[0].Value.String())
+	}
+
+	t.Logf("Should compute percentiles and deviation without losing precision of rates")
+	{
+		require.NoError(t, db.AddBatch(ctx, []btclists.Rate{
+			{Date: start, Coin: "XRP", Fiat: FIAT, Rate: decimal.RequireFromString("0.123456789012345678")},
+			{Date: start.Add(time.Minute), Coin: "XRP", Fiat: FIAT, Rate: decimal.RequireFromString("0.123456789012345679")},
+		}))
+
+		var stats, err = db.StatsForRange(ctx, "XRP", FIAT, start, end, []float64{99.9})
+		require.NoError(t, err)
+		require.Equal(t, "0.1234567890123456785", stats.Median.String())
+		require.Equal(t, "0.123456789012345678999", stats.Percentiles[0].Value.String())
+		require.Equal(t, "0.00000000000000000071", stats.StdDev.String())
+	}
+}
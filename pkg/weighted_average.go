@@ -0,0 +1,85 @@
+package pkg
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/influx6/btclists"
+)
+
+var (
+	ErrNoVolume = errors.New("no traded volume known for time range")
+)
+
+// TimeWeightedAverage returns the time-weighted average price (TWAP) of rates within
+// provided time range, where each rate holds till the date of the next rate (or 'to' for
+// the last rate), so densely sampled periods do not skew the average.
+//
+// Where rates span no time (e.g a single rate at 'to'), their plain average is returned.
+func TimeWeightedAverage(rates []btclists.Rate, from time.Time, to time.Time) (decimal.Decimal, error) {
+	if len(rates) == 0 {
+		return decimal.Decimal{}, btclists.ErrRateNotFound
+	}
+
+	var sorted = make([]btclists.Rate, len(rates))
+	copy(sorted, rates)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Date.Before(sorted[j].Date)
+	})
+
+	var total, weights decimal.Decimal
+	for index, rate := range sorted {
+		var start = rate.Date
+		if start.Before(from) {
+			start = from
+		}
+
+		var end = to
+		if index+1 < len(sorted) {
+			end = sorted[index+1].Date
+		}
+
+		if !end.After(start) {
+			continue
+		}
+
+		var weight = decimal.NewFromInt(int64(end.Sub(start) / time.Second))
+		total = total.Add(rate.Rate.Mul(weight))
+		weights = weights.Add(weight)
+	}
+
+	if weights.IsZero() {
+		var sum decimal.Decimal
+		for _, rate := range sorted {
+			sum = sum.Add(rate.Rate)
+		}
+		return sum.Div(decimal.NewFromInt(int64(len(sorted)))), nil
+	}
+	return total.DivRound(weights, 16), nil
+}
+
+// VolumeWeightedAverage returns the volume-weighted average price (VWAP) of candles,
+// weighting the typical price (i.e (high + low + close) / 3) of each candle by it's
+// traded volume.
+func VolumeWeightedAverage(candles []btclists.Candle) (decimal.Decimal, error) {
+	if len(candles) == 0 {
+		return decimal.Decimal{}, btclists.ErrRateNotFound
+	}
+
+	var three = decimal.NewFromInt(3)
+
+	var total, volume decimal.Decimal
+	for _, candle := range candles {
+		var typical = candle.High.Add(candle.Low).Add(candle.Close).Div(three)
+		total = total.Add(typical.Mul(candle.Volume))
+		volume = volume.Add(candle.Volume)
+	}
+
+	if volume.IsZero() {
+		return decimal.Decimal{}, ErrNoVolume
+	}
+	return total.DivRound(volume, 16), nil
+}
@@ -0,0 +1,66 @@
+package pkg_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+
+	"github.com/influx6/btclists"
+	"github.com/influx6/btclists/pkg"
+)
+
+func TestTimeWeightedAverage(t *testing.T) {
+	var from = time.Date(2020, 4, 8, 14, 0, 0, 0, time.UTC)
+	var to = from.Add(10 * time.Minute)
+
+	// A burst of samples at 20 within a minute should not outweigh
+	// the price of 10 holding for nine minutes.
+	var rates = []btclists.Rate{
+		{Date: from.Add(9 * time.Minute), Rate: decimal.NewFromInt(20)},
+		{Date: from, Rate: decimal.NewFromInt(10)},
+		{Date: from.Add(9*time.Minute + 20*time.Second), Rate: decimal.NewFromInt(20)},
+		{Date: from.Add(9*time.Minute + 40*time.Second), Rate: decimal.NewFromInt(20)},
+	}
+
+	var average, err = pkg.TimeWeightedAverage(rates, from, to)
+	require.NoError(t, err)
+	require.Equal(t, "11", average.String())
+
+	t.Logf("Should fail without rates")
+	{
+		var _, err = pkg.TimeWeightedAverage(nil, from, to)
+		require.Equal(t, btclists.ErrRateNotFound, err)
+	}
+}
+
+func TestVolumeWeightedAverage(t *testing.T) {
+	var candles = []btclists.Candle{
+		{
+			High:   decimal.NewFromInt(12),
+			Low:    decimal.NewFromInt(9),
+			Close:  decimal.NewFromInt(9),
+			Volume: decimal.NewFromInt(3),
+		},
+		{
+			High:   decimal.NewFromInt(21),
+			Low:    decimal.NewFromInt(19),
+			Close:  decimal.NewFromInt(20),
+			Volume: decimal.NewFromInt(1),
+		},
+	}
+
+	var average, err = pkg.VolumeWeightedAverage(candles)
+	require.NoError(t, err)
+	require.Equal(t, "12.5", average.String())
+
+	t.Logf("Should fail without volume")
+	{
+		candles[0].Volume = decimal.Zero
+		candles[1].Volume = decimal.Zero
+
+		var _, err = pkg.VolumeWeightedAverage(candles)
+		require.Equal(t, pkg.ErrNoVolume, err)
+	}
+}
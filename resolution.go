@@ -0,0 +1,95 @@
+package btclists
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidResolution = errors.New("resolution is not valid, expected one of 1SEC through 1DAY (e.g 1MIN, 1HRS, 1DAY)")
+)
+
+// Resolution defines the period of time a rate or candle covers, using the
+// period identifiers of CoinAPI (e.g 1MIN, 1HRS, 1DAY).
+//
+// Rates with an empty resolution are spot rates, that is the exchange rate
+// at a specific point in time rather than the close of a period.
+type Resolution string
+
+const (
+	Spot            Resolution = ""
+	Resolution1Sec  Resolution = "1SEC"
+	Resolution1Min  Resolution = "1MIN"
+	Resolution2Min  Resolution = "2MIN"
+	Resolution1Hour Resolution = "1HRS"
+	Resolution1Day  Resolution = "1DAY"
+)
+
+var resolutions = map[Resolution]time.Duration{
+	"1SEC":  time.Second,
+	"2SEC":  2 * time.Second,
+	"3SEC":  3 * time.Second,
+	"4SEC":  4 * time.Second,
+	"5SEC":  5 * time.Second,
+	"6SEC":  6 * time.Second,
+	"10SEC": 10 * time.Second,
+	"15SEC": 15 * time.Second,
+	"20SEC": 20 * time.Second,
+	"30SEC": 30 * time.Second,
+	"1MIN":  time.Minute,
+	"2MIN":  2 * time.Minute,
+	"3MIN":  3 * time.Minute,
+	"4MIN":  4 * time.Minute,
+	"5MIN":  5 * time.Minute,
+	"6MIN":  6 * time.Minute,
+	"10MIN": 10 * time.Minute,
+	"15MIN": 15 * time.Minute,
+	"20MIN": 20 * time.Minute,
+	"30MIN": 30 * time.Minute,
+	"1HRS":  time.Hour,
+	"2HRS":  2 * time.Hour,
+	"3HRS":  3 * time.Hour,
+	"4HRS":  4 * time.Hour,
+	"6HRS":  6 * time.Hour,
+	"8HRS":  8 * time.Hour,
+	"12HRS": 12 * time.Hour,
+	"1DAY":  24 * time.Hour,
+}
+
+// ParseResolution parses giving value (case insensitive) into a Resolution,
+// returning ErrInvalidResolution if not a supported resolution.
+func ParseResolution(value string) (Resolution, error) {
+	var resolution = Resolution(strings.ToUpper(strings.TrimSpace(value)))
+	if !resolution.Valid() {
+		return Spot, ErrInvalidResolution
+	}
+	return resolution, nil
+}
+
+// Valid returns true/false if resolution is a supported candle resolution.
+func (r Resolution) Valid() bool {
+	var _, ok = resolutions[r]
+	return ok
+}
+
+// Duration returns the period of time covered by resolution, it returns
+// zero for spot or unknown resolutions.
+func (r Resolution) Duration() time.Duration {
+	return resolutions[r]
+}
+
+type resolutionKey struct{}
+
+// WithResolution returns a new context carrying desired resolution for
+// requests made with it.
+func WithResolution(ctx context.Context, resolution Resolution) context.Context {
+	return context.WithValue(ctx, resolutionKey{}, resolution)
+}
+
+// ResolutionFrom returns resolution set on context with WithResolution if any.
+func ResolutionFrom(ctx context.Context) (Resolution, bool) {
+	var resolution, ok = ctx.Value(resolutionKey{}).(Resolution)
+	return resolution, ok && resolution != Spot
+}